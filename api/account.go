@@ -3,17 +3,26 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
 
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fx"
 	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
 	"github.com/lib/pq"
 )
 
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	// checking if it is empty, the user can have one account of every type in each currency
+	Type string `json:"type" binding:"omitempty,account_type"`
+	// other users who own the business account together with the authenticated user,
+	// the accounts of the other types have only one owner
+	CoOwners []string `json:"co_owners" binding:"omitempty,unique,dive,alphanum"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -30,14 +39,51 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	// to dostanu rovnou ve formatu token.Payload
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	arg := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
-		Balance:  0,
+	if len(req.CoOwners) > 0 {
+		if req.Type != util.AccountBusiness {
+			err := errors.New("only business accounts can have co-owners")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		for _, coOwner := range req.CoOwners {
+			if coOwner == authPayload.Username {
+				err := errors.New("owner of the account can't be its co-owner")
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+		}
+	}
+
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    authPayload.Username,
+			Currency: req.Currency,
+			Balance:  0,
+			Type:     req.Type,
+		},
+		CoOwners:    req.CoOwners,
+		Idempotency: idempotency,
+	}
+	// pravidla produktu se nastaví podle konfigurace, bankéř může debet účtu později změnit
+	switch arg.Type {
+	case "", util.AccountChecking:
+		arg.Type = util.AccountChecking
+		arg.OverdraftLimit = server.config.CheckingOverdraftLimit
+	case util.AccountSavings:
+		arg.MaxMonthlyTransfers = server.config.SavingsMonthlyTransfers
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyUsed) {
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+			return
+		}
 		// zde je třeba vracející se erro převést na pq Error, aby server nevracel status 500
 		// což je chyba na straně serveru, jelikož tento error se vrací pokud není danný uživatel v databázi,
 		// což je chyba na straně klienta, takže by se mělo vracet spíš něco jako 403 (StatusForbidden)
@@ -69,27 +115,64 @@ func (server *Server) getAccountByID(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, valid := server.authorizedAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	rsp, err := server.newAccountResponse(ctx, account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// accountResponse adds both balances to the account, the ledger balance is the sum of its posted entries
+// and the available balance is the ledger balance with the overdraft limit and without the money reserved
+// by its active holds and pending transfers, transfers from the account can't spend more than the available balance
+type accountResponse struct {
+	db.Account
+	LedgerBalance    int64 `json:"ledger_balance"`
+	AvailableBalance int64 `json:"available_balance"`
+}
+
+func (server *Server) newAccountResponse(ctx *gin.Context, account db.Account) (accountResponse, error) {
+	reservedAmount, err := server.store.GetAccountReservedAmount(ctx, account.ID)
+	if err != nil {
+		return accountResponse{}, err
+	}
+
+	return accountResponse{
+		Account:          account,
+		LedgerBalance:    account.Balance,
+		AvailableBalance: account.Balance + account.OverdraftLimit - reservedAmount,
+	}, nil
+}
+
+// authorizedAccount returns the account if the authenticated user can view it,
+// otherwise it writes the error response and returns false
+func (server *Server) authorizedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// if we get an error type ErrNoRows server will respond with code 404 (not found)
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+			return account, false
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return account, false
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	// The logged-in user can create account just him/her self so it is neccessary to
-	// check if request for creating new acc is from same user as provided auth token.
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
+	// Bankers can view any account, depositors only the accounts they are members of.
+	if authPayload.Role == util.BankerRole {
+		return account, true
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	_, valid := server.authorizeMember(ctx, account, util.CanViewAccount, "account doesn't belong to the authenticated user")
+	return account, valid
 }
 
 // form tag zařídí, že se hodnoty do reqestu dostanout z QueryParam, page size má nadefinované tagy min a max pro rozmezí
@@ -108,10 +191,11 @@ func (server *Server) listAccount(ctx *gin.Context) {
 		return
 	}
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	// vypíšou se i společné účty, kterých je uživatel členem
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Username: authPayload.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
 	}
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
@@ -120,5 +204,206 @@ func (server *Server) listAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	// stránka má nejvýš 10 účtů, takže rezervované částky stačí dočíst po jednom
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		accountRsp, err := server.newAccountResponse(ctx, account)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp = append(rsp, accountRsp)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type accountStatusRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// freezeAccount blocks all transfers from and to the account, only bankers can do it
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, util.AccountFrozen)
+}
+
+// unfreezeAccount allows the transfers of the frozen account again, only bankers can do it
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, util.AccountActive)
+}
+
+func (server *Server) setAccountStatus(ctx *gin.Context, status string) {
+	var req accountStatusRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:     req.ID,
+		Status: status,
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// UpdateAccountStatus nemění uzavřené účty, takže je nutné zjistit, jestli účet vůbec existuje
+		if _, err := server.store.GetAccount(ctx, req.ID); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		err := fmt.Errorf("account [%d] is closed", req.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+type closeAccountRequest struct {
+	// optional, another account managed by the user which receives the remaining balance
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+	// optional, fx quote for the sweep to an account with another currency, the current rate is used without it
+	QuoteID string `json:"quote_id" binding:"omitempty,uuid"`
+}
+
+// closeAccount closes the account of the authenticated user for good, the owner and the co-owners can do it.
+// The account must have zero balance or the balance is swept to another account managed by the user. Bankers can't close the accounts of depositors,
+// they can freeze them.
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// tělo je nepovinné, účet s nulovým zůstatkem jde uzavřít bez něj
+	var req closeAccountRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	account, valid := server.activeAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if _, valid := server.authorizeMember(ctx, account, util.CanManageAccount, "authenticated user can't close the account"); !valid {
+		return
+	}
+
+	arg := db.CloseAccountTxParams{AccountID: account.ID}
+	if req.SweepAccountID != 0 {
+		if req.SweepAccountID == account.ID {
+			err := errors.New("sweep account must be another account")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		// uživatel má v každé měně nejvýš jeden otevřený účet, takže převod zůstatku je většinou směna
+		sweepAccount, valid := server.activeAccount(ctx, req.SweepAccountID)
+		if !valid {
+			return
+		}
+		if _, valid := server.authorizeMember(ctx, sweepAccount, util.CanManageAccount, "sweep account doesn't belong to the authenticated user"); !valid {
+			return
+		}
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		rate, valid := server.transferRate(ctx, authPayload, req.QuoteID, account.Currency, sweepAccount.Currency)
+		if !valid {
+			return
+		}
+
+		arg.SweepAccountID = sweepAccount.ID
+		arg.ExchangeRate = rate.Rate
+		arg.Convert = func(amount int64) (int64, error) {
+			return fx.Convert(amount, rate.Rate)
+		}
+	}
+
+	result, err := server.store.CloseAccountTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrAccountNotEmpty):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, fx.ErrAmountTooSmall):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type setOverdraftLimitRequest struct {
+	OverdraftLimit int64 `json:"overdraft_limit" binding:"min=0"`
+}
+
+// setOverdraftLimit changes how much the balance of the checking account can go below zero, only bankers can do it.
+// The limit can't be lowered below the current overdrawn amount of the account.
+func (server *Server) setOverdraftLimit(ctx *gin.Context) {
+	var uri accountStatusRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if account.Type != util.AccountChecking || account.Owner == util.SystemUser {
+		err := fmt.Errorf("account [%d] is a %s account, only checking accounts have an overdraft", account.ID, account.Type)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err = server.store.UpdateAccountOverdraftLimit(ctx, db.UpdateAccountOverdraftLimitParams{
+		ID:             account.ID,
+		OverdraftLimit: req.OverdraftLimit,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("account [%d] is closed", uri.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		// balance_non_negative nepustí debet menší než současný mínus účtu
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			err := fmt.Errorf("account [%d] is overdrawn more than the new limit", uri.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
 }
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type accountHoldURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type placeHoldRequest struct {
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Description string `json:"description" binding:"max=200"`
+	// optional, holds without it expire after HOLD_DURATION
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// placeHold reserves the money on the account, e.g. for a card authorization, only bankers can do it
+func (server *Server) placeHold(ctx *gin.Context) {
+	var uri accountHoldURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req placeHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(server.config.HoldDuration)
+	}
+	if !expiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.activeAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	hold, err := server.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   uri.ID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		ExpiresAt:   expiresAt,
+		CreatedBy:   authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type listHoldsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listHolds lists the holds of the account from the newest one
+func (server *Server) listHolds(ctx *gin.Context) {
+	var uri accountHoldURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listHoldsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	holds, err := server.store.ListAccountHolds(ctx, db.ListAccountHoldsParams{
+		AccountID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+type captureHoldRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	// optional, zero captures the whole hold
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// captureHold converts the hold into a transfer to the given account, only bankers can do it
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri accountHoldURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.activeHold(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	fromAccount, valid := server.activeAccount(ctx, hold.AccountID)
+	if !valid {
+		return
+	}
+	// blokace je v měně blokovaného účtu, převod mezi měnami by potřeboval kurz
+	if _, valid := server.validAccount(ctx, req.ToAccountID, fromAccount.Currency); !valid {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		HoldID:      hold.ID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrHoldNotActive):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInvalidCapture):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// releaseHold releases the hold without moving any money, only bankers can do it
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var uri accountHoldURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.activeHold(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	released, err := server.store.CloseAccountHold(ctx, db.CloseAccountHoldParams{
+		Status: util.HoldReleased,
+		ID:     hold.ID,
+	})
+	if err != nil {
+		// update mění jen aktivní blokace, takže ji mezitím zachytil nebo uvolnil někdo jiný
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("%w: hold %d was closed concurrently", db.ErrHoldNotActive, hold.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, released)
+}
+
+// activeHold returns the hold if it exists and it is still active and not expired
+func (server *Server) activeHold(ctx *gin.Context, holdID int64) (db.AccountHold, bool) {
+	hold, err := server.store.GetAccountHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	if hold.Status != util.HoldActive {
+		err := fmt.Errorf("%w: hold %d is %s", db.ErrHoldNotActive, hold.ID, hold.Status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return hold, false
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		err := fmt.Errorf("%w: hold %d expired at %s", db.ErrHoldNotActive, hold.ID, hold.ExpiresAt)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return hold, false
+	}
+
+	return hold, true
+}
+
+// runHoldExpiration marks the expired holds periodically until the context is cancelled, expired holds
+// don't reduce the available balance even before it, so the interval only affects their status
+func (server *Server) runHoldExpiration(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := server.store.ExpireAccountHolds(ctx, time.Now()); err != nil {
+				log.Println("cannot expire account holds:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+	"github.com/lib/pq"
+)
+
+// accountMemberRole returns the role of the user in the account or an empty string if the user isn't its member
+func (server *Server) accountMemberRole(ctx *gin.Context, account db.Account, username string) (string, error) {
+	// vlastník je členem každého svého účtu, takže se nemusí hledat v databázi
+	if account.Owner == username {
+		return util.MemberOwner, nil
+	}
+
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+// authorizeMember returns the role of the authenticated user in the account if the role is allowed,
+// otherwise it writes the error response and returns false
+func (server *Server) authorizeMember(ctx *gin.Context, account db.Account, allowed func(role string) bool, message string) (string, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	role, err := server.accountMemberRole(ctx, account, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return role, false
+	}
+
+	if !allowed(role) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New(message)))
+		return role, false
+	}
+
+	return role, true
+}
+
+// listAccountMembers lists the users who share the account, every member can see them
+func (server *Server) listAccountMembers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.authorizedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	members, err := server.store.ListAccountMembers(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+type addAccountMemberRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	// the account has only one owner, so the owner role can't be granted
+	Role string `json:"role" binding:"required,oneof=co-owner viewer can-initiate needs-approval"`
+}
+
+// addAccountMember shares the account with another user, only the owner and the co-owners can do it
+func (server *Server) addAccountMember(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req addAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.activeAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	if _, valid := server.authorizeMember(ctx, account, util.CanManageAccount, "authenticated user can't manage the account members"); !valid {
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, req.Username); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  req.Username,
+		Role:      req.Role,
+		AddedBy:   authPayload.Username,
+	})
+	if err != nil {
+		// uživatel už je členem účtu, roli je nutné nejdřív odebrat
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+type accountMemberURI struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// removeAccountMember stops sharing the account with the user, the owner and the co-owners can remove any member
+// except the owner and the other members can only leave the account
+func (server *Server) removeAccountMember(ctx *gin.Context) {
+	var uri accountMemberURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if uri.Username != authPayload.Username {
+		if _, valid := server.authorizeMember(ctx, account, util.CanManageAccount, "authenticated user can't manage the account members"); !valid {
+			return
+		}
+	}
+
+	// vlastník se z účtu odebrat nedá, účet může jen uzavřít
+	if uri.Username == account.Owner {
+		err := errors.New("owner can't be removed from the account")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	member, err := server.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
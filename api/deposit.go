@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type cashURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type cashRequest struct {
+	// account_id je z URI, hash idempotentního requestu ho má v cestě
+	AccountID int64  `json:"-"`
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+}
+
+// bindCashRequest binds the account ID from the URI and the amount from the body
+func bindCashRequest(ctx *gin.Context) (cashRequest, bool) {
+	var uri cashURI
+	var req cashRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, false
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, false
+	}
+	req.AccountID = uri.ID
+
+	return req, true
+}
+
+// createDeposit deposits cash to the account, only bankers can do it
+func (server *Server) createDeposit(ctx *gin.Context) {
+	req, valid := bindCashRequest(ctx)
+	if !valid {
+		return
+	}
+
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	if _, valid := server.validAccount(ctx, req.AccountID, req.Currency); !valid {
+		return
+	}
+
+	result, err := server.store.DepositTx(ctx, db.DepositTxParams{
+		AccountID:   req.AccountID,
+		Amount:      req.Amount,
+		Idempotency: idempotency,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyUsed) {
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// createWithdrawal withdraws cash from the account, the members who can send transfers from the account can do it
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	req, valid := bindCashRequest(ctx)
+	if !valid {
+		return
+	}
+
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	account, valid := server.validAccount(ctx, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	role, valid := server.authorizeMember(ctx, account, util.CanInitiateTransfers, "authenticated user can't withdraw from the account")
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.WithdrawTx(ctx, db.WithdrawTxParams{
+		AccountID:   req.AccountID,
+		Amount:      req.Amount,
+		Idempotency: idempotency,
+		Limits:      server.transferLimits(),
+		InitiatedBy: authPayload.Username,
+		// výběr člena, který potřebuje schválení, čeká na vlastníka účtu stejně jako jeho převody
+		RequireApproval: role == util.MemberNeedsApproval,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyUsed) {
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) {
+			ctx.JSON(http.StatusForbidden, errorCodeResponse(transferLimitExceededCode, err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fee"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type feeScheduleResponse struct {
+	Kind       string    `json:"kind"`
+	Currency   string    `json:"currency"`
+	Method     string    `json:"method"`
+	FlatAmount int64     `json:"flat_amount"`
+	Rate       string    `json:"rate"`
+	MinFee     int64     `json:"min_fee"`
+	MaxFee     int64     `json:"max_fee"`
+	MinBalance int64     `json:"min_balance"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newFeeScheduleResponse(schedule db.FeeSchedule) feeScheduleResponse {
+	return feeScheduleResponse{
+		Kind:       schedule.Kind,
+		Currency:   schedule.Currency,
+		Method:     schedule.Method,
+		FlatAmount: schedule.FlatAmount,
+		Rate:       schedule.Rate,
+		MinFee:     schedule.MinFee,
+		MaxFee:     schedule.MaxFee,
+		MinBalance: schedule.MinBalance,
+		UpdatedAt:  schedule.UpdatedAt,
+	}
+}
+
+// listFeeSchedules lists the fee schedules of all fee kinds and currencies, only bankers can do it
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	schedules, err := server.store.ListFeeSchedules(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]feeScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		rsp[i] = newFeeScheduleResponse(schedule)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type setFeeScheduleRequest struct {
+	Kind       string `json:"kind" binding:"required,fee_kind"`
+	Currency   string `json:"currency" binding:"required,currency"`
+	Method     string `json:"method" binding:"required,fee_method"`
+	FlatAmount int64  `json:"flat_amount" binding:"min=0"`
+	// decimal part of the transferred amount charged by the percentage method, e.g. "0.005" is 0.5 %
+	Rate   string `json:"rate"`
+	MinFee int64  `json:"min_fee" binding:"min=0"`
+	// zero is unlimited
+	MaxFee int64 `json:"max_fee" binding:"min=0"`
+	// only for the maintenance fee, accounts with a lower balance at the end of the month pay it
+	MinBalance int64 `json:"min_balance"`
+}
+
+// setFeeSchedule sets the fee schedule of the fee kind in the currency, only bankers can do it. The new transfer
+// fee is charged from the next transfer, the pending transfers keep the fee computed when they were created.
+func (server *Server) setFeeSchedule(ctx *gin.Context) {
+	var req setFeeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Kind == util.FeeKindMaintenance && req.Method != util.FeeFlat {
+		err := fmt.Errorf("maintenance fee must be %s", util.FeeFlat)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.MaxFee > 0 && req.MinFee > req.MaxFee {
+		err := fmt.Errorf("min fee %d is greater than max fee %d", req.MinFee, req.MaxFee)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Rate == "" {
+		req.Rate = "0"
+	}
+	rate, err := fee.NormalizeRate(req.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.UpsertFeeSchedule(ctx, db.UpsertFeeScheduleParams{
+		Kind:       req.Kind,
+		Currency:   req.Currency,
+		Method:     req.Method,
+		FlatAmount: req.FlatAmount,
+		Rate:       rate,
+		MinFee:     req.MinFee,
+		MaxFee:     req.MaxFee,
+		MinBalance: req.MinBalance,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newFeeScheduleResponse(schedule))
+}
+
+type deleteFeeScheduleURI struct {
+	Kind     string `uri:"kind" binding:"required,fee_kind"`
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+// deleteFeeSchedule removes the fee schedule, the fee of its kind and currency is no longer charged,
+// only bankers can do it
+func (server *Server) deleteFeeSchedule(ctx *gin.Context) {
+	var uri deleteFeeScheduleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.DeleteFeeSchedule(ctx, db.DeleteFeeScheduleParams{
+		Kind:     uri.Kind,
+		Currency: uri.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newFeeScheduleResponse(schedule))
+}
+
+type listFeeWaiversURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// listFeeWaivers lists the fee kinds waived for the user, only bankers can do it
+func (server *Server) listFeeWaivers(ctx *gin.Context) {
+	var uri listFeeWaiversURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	waivers, err := server.store.ListFeeWaivers(ctx, uri.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, waivers)
+}
+
+type feeWaiverURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+	Kind     string `uri:"kind" binding:"required,fee_kind"`
+}
+
+// waiveFee waives the fees of the kind for the user until the waiver is removed, only bankers can do it.
+// The waiver applies to the fees charged after it, pending transfers keep the fee computed when they were created.
+func (server *Server) waiveFee(ctx *gin.Context) {
+	var uri feeWaiverURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, uri.Username); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	waiver, err := server.store.UpsertFeeWaiver(ctx, db.UpsertFeeWaiverParams{
+		Username: uri.Username,
+		Kind:     uri.Kind,
+		WaivedBy: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, waiver)
+}
+
+// deleteFeeWaiver removes the waiver, the user pays the fees of its kind again, only bankers can do it
+func (server *Server) deleteFeeWaiver(ctx *gin.Context) {
+	var uri feeWaiverURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	waiver, err := server.store.DeleteFeeWaiver(ctx, db.DeleteFeeWaiverParams{
+		Username: uri.Username,
+		Kind:     uri.Kind,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, waiver)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fx"
+	"github.com/karlib/simple_bank/token"
+)
+
+type createFxQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	// optional, if it is set the response contains the converted amount
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+type fxQuoteResponse struct {
+	ID           uuid.UUID `json:"id"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	Amount       int64     `json:"amount,omitempty"`
+	ToAmount     int64     `json:"to_amount,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// createFxQuote locks the current rate of the currency pair for FX_QUOTE_DURATION,
+// the ID of the quote can be sent with the transfer to use the locked rate
+func (server *Server) createFxQuote(ctx *gin.Context) {
+	var req createFxQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, valid := server.currentRate(ctx, req.FromCurrency, req.ToCurrency)
+	if !valid {
+		return
+	}
+
+	var toAmount int64
+	if req.Amount > 0 {
+		var err error
+		toAmount, err = fx.Convert(req.Amount, rate.Rate)
+		if err != nil {
+			if errors.Is(err, fx.ErrAmountTooSmall) {
+				ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	quote, err := server.store.CreateFxQuote(ctx, db.CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     authPayload.Username,
+		FromCurrency: rate.From,
+		ToCurrency:   rate.To,
+		Rate:         rate.Rate,
+		ExpiresAt:    time.Now().Add(server.config.FXQuoteDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, fxQuoteResponse{
+		ID:           quote.ID,
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Rate:         rate.Rate,
+		Amount:       req.Amount,
+		ToAmount:     toAmount,
+		ExpiresAt:    quote.ExpiresAt,
+	})
+}
+
+// currentRate returns the current rate of the currency pair from the fx provider
+func (server *Server) currentRate(ctx *gin.Context, from string, to string) (fx.Rate, bool) {
+	rate, err := server.fxProvider.Rate(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			err := fmt.Errorf("%w: %s/%s", err, from, to)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return rate, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return rate, false
+	}
+
+	return rate, true
+}
+
+// transferRate returns the rate used for the transfer. The rate of the quote is used if the quoteID is set,
+// otherwise the current rate of the fx provider. Transfers between the same currencies use fx.UnitRate.
+func (server *Server) transferRate(ctx *gin.Context, authPayload *token.Payload, quoteID string, from string, to string) (fx.Rate, bool) {
+	if quoteID == "" {
+		if from == to {
+			return fx.Rate{From: from, To: to, Rate: fx.UnitRate}, true
+		}
+		return server.currentRate(ctx, from, to)
+	}
+
+	// quote_id je už zvalidované jako uuid v bindingu
+	quote, err := server.store.GetFxQuote(ctx, uuid.MustParse(quoteID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return fx.Rate{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return fx.Rate{}, false
+	}
+
+	if quote.Username != authPayload.Username {
+		err := errors.New("fx quote doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return fx.Rate{}, false
+	}
+
+	if quote.FromCurrency != from || quote.ToCurrency != to {
+		err := fmt.Errorf("fx quote is for %s/%s, not %s/%s", quote.FromCurrency, quote.ToCurrency, from, to)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return fx.Rate{}, false
+	}
+
+	if time.Now().After(quote.ExpiresAt) {
+		err := errors.New("fx quote has expired")
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return fx.Rate{}, false
+	}
+
+	return fx.Rate{From: quote.FromCurrency, To: quote.ToCurrency, Rate: quote.Rate}, true
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// header which tells the client that the response is a replay of the stored one
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+var errIdempotencyKeyMismatch = errors.New("idempotency key is already used for a different request")
+
+// idempotentRequest reads the Idempotency-Key header of the request. If the header is missing it returns
+// nil params and the request is executed as usual. If a response is already stored under the key,
+// it is written back to the client (or 409 if the request differs) and the handler must stop.
+// req is the bound request body, its hash together with the request path decides if the request is the same.
+func (server *Server) idempotentRequest(ctx *gin.Context, req interface{}) (*db.IdempotencyParams, bool) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return nil, true
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("%s header is longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return nil, false
+	}
+
+	// skutečná cesta obsahuje i ID z URI, takže stejný klíč na jiném účtu je jiný request
+	hash, err := requestHash(ctx.Request.URL.Path, req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	params := &db.IdempotencyParams{
+		Username:       authPayload.Username,
+		Key:            key,
+		RequestHash:    hash,
+		ResponseStatus: http.StatusOK,
+		ExpiresAt:      time.Now().Add(server.config.IdempotencyKeyTTL),
+	}
+
+	if server.replayResponse(ctx, params) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// requestHash returns the hex encoded sha256 of the request path and the JSON encoded request,
+// the same key used on another path is a different request so the path is part of the hash
+func requestHash(path string, req interface{}) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(append([]byte(path+"\n"), body...))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// replayResponse writes the response stored under the idempotency key,
+// it returns false if nothing is stored and the request has to be executed
+func (server *Server) replayResponse(ctx *gin.Context, params *db.IdempotencyParams) bool {
+	stored, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: params.Username,
+		Key:      params.Key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+
+	if stored.RequestHash != params.RequestHash {
+		ctx.JSON(http.StatusConflict, errorResponse(errIdempotencyKeyMismatch))
+		return true
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(stored.ResponseStatus), "application/json; charset=utf-8", stored.ResponseBody)
+	return true
+}
+
+// handleIdempotencyKeyUsed handles db.ErrIdempotencyKeyUsed returned by a transaction, it means that
+// the same key was saved by a concurrent request in the meantime, so its response is replayed
+func (server *Server) handleIdempotencyKeyUsed(ctx *gin.Context, params *db.IdempotencyParams) {
+	if !server.replayResponse(ctx, params) {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrIdempotencyKeyUsed))
+	}
+}
+
+// runIdempotencyKeyGarbageCollector deletes the expired idempotency keys periodically until the context is cancelled
+func (server *Server) runIdempotencyKeyGarbageCollector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := server.store.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				log.Println("cannot delete expired idempotency keys:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/interest"
+)
+
+type interestRateResponse struct {
+	AccountType string    `json:"account_type"`
+	Currency    string    `json:"currency"`
+	AnnualRate  string    `json:"annual_rate"`
+	DayCount    string    `json:"day_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newInterestRateResponse(rate db.InterestRate) interestRateResponse {
+	return interestRateResponse{
+		AccountType: rate.AccountType,
+		Currency:    rate.Currency,
+		AnnualRate:  rate.AnnualRate,
+		DayCount:    rate.DayCount,
+		UpdatedAt:   rate.UpdatedAt,
+	}
+}
+
+// listInterestRates lists the annual interest rates of all account types and currencies, only bankers can do it
+func (server *Server) listInterestRates(ctx *gin.Context) {
+	rates, err := server.store.ListInterestRates(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]interestRateResponse, len(rates))
+	for i, rate := range rates {
+		rsp[i] = newInterestRateResponse(rate)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type setInterestRateRequest struct {
+	AccountType string `json:"account_type" binding:"required,account_type"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	// decimal number, e.g. "0.015" is 1.5 % per year, zero stops the interest
+	AnnualRate string `json:"annual_rate" binding:"required"`
+	DayCount   string `json:"day_count" binding:"required,day_count"`
+}
+
+// setInterestRate sets the annual interest rate of the account type in the currency, only bankers can do it.
+// The new rate is used from the next accrued day, the interest already accrued is not changed.
+func (server *Server) setInterestRate(ctx *gin.Context) {
+	var req setInterestRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	annualRate, err := interest.NormalizeRate(req.AnnualRate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, err := server.store.UpsertInterestRate(ctx, db.UpsertInterestRateParams{
+		AccountType: req.AccountType,
+		Currency:    req.Currency,
+		AnnualRate:  annualRate,
+		DayCount:    req.DayCount,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newInterestRateResponse(rate))
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListInterestRatesAPI(t *testing.T) {
+	rates := []db.InterestRate{
+		{AccountType: util.AccountSavings, Currency: util.EUR, AnnualRate: "0.01000000", DayCount: util.DayCount30360},
+		{AccountType: util.AccountSavings, Currency: util.USD, AnnualRate: "0.01500000", DayCount: util.DayCountActual365},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListInterestRates(gomock.Any()).Times(1).Return(rates, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRates []interestRateResponse
+				err := json.NewDecoder(recorder.Body).Decode(&gotRates)
+				require.NoError(t, err)
+				require.Len(t, gotRates, len(rates))
+				for i, rate := range rates {
+					require.Equal(t, rate.Currency, gotRates[i].Currency)
+					require.Equal(t, rate.AnnualRate, gotRates[i].AnnualRate)
+					require.Equal(t, rate.DayCount, gotRates[i].DayCount)
+				}
+			},
+		},
+		{
+			name: "DepositorRole",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListInterestRates(gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListInterestRates(gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/interest_rates", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSetInterestRateAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"account_type": util.AccountSavings,
+				"currency":     util.USD,
+				"annual_rate":  "0.0175",
+				"day_count":    util.DayCount30360,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertInterestRateParams{
+					AccountType: util.AccountSavings,
+					Currency:    util.USD,
+					AnnualRate:  "0.01750000",
+					DayCount:    util.DayCount30360,
+				}
+				store.EXPECT().
+					UpsertInterestRate(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.InterestRate{
+						AccountType: arg.AccountType,
+						Currency:    arg.Currency,
+						AnnualRate:  arg.AnnualRate,
+						DayCount:    arg.DayCount,
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRate interestRateResponse
+				err := json.NewDecoder(recorder.Body).Decode(&gotRate)
+				require.NoError(t, err)
+				require.Equal(t, "0.01750000", gotRate.AnnualRate)
+				require.Equal(t, util.DayCount30360, gotRate.DayCount)
+			},
+		},
+		{
+			name: "ZeroRate",
+			body: gin.H{
+				"account_type": util.AccountChecking,
+				"currency":     util.EUR,
+				"annual_rate":  "0",
+				"day_count":    util.DayCountActual365,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertInterestRateParams{
+					AccountType: util.AccountChecking,
+					Currency:    util.EUR,
+					AnnualRate:  "0.00000000",
+					DayCount:    util.DayCountActual365,
+				}
+				store.EXPECT().
+					UpsertInterestRate(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.InterestRate{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "DepositorRole",
+			body: gin.H{
+				"account_type": util.AccountSavings,
+				"currency":     util.USD,
+				"annual_rate":  "0.0175",
+				"day_count":    util.DayCount30360,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDayCount",
+			body: gin.H{
+				"account_type": util.AccountSavings,
+				"currency":     util.USD,
+				"annual_rate":  "0.0175",
+				"day_count":    "act/360",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeRate",
+			body: gin.H{
+				"account_type": util.AccountSavings,
+				"currency":     util.USD,
+				"annual_rate":  "-0.01",
+				"day_count":    util.DayCountActual365,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAccountType",
+			body: gin.H{
+				"account_type": util.AccountInterestExpense,
+				"currency":     util.USD,
+				"annual_rate":  "0.01",
+				"day_count":    util.DayCountActual365,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"account_type": util.AccountSavings,
+				"currency":     util.USD,
+				"annual_rate":  "0.0175",
+				"day_count":    util.DayCountActual365,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertInterestRate(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.InterestRate{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/admin/interest_rates", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
 	db "github.com/karlib/simple_bank/db/sqlc"
 	"github.com/karlib/simple_bank/util"
 	"github.com/stretchr/testify/require"
@@ -23,8 +25,22 @@ func TestMain(m *testing.M) {
 
 func newTestServer(t *testing.T, store db.Store ) *Server{
 	config := util.Config{
-		TokenSymetricKey: util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymetricKey:     util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		IdempotencyKeyTTL:    time.Hour,
+		FXQuoteDuration:      time.Minute,
+		HoldDuration:         time.Hour,
+		PaymentRequestDuration: time.Hour,
+	}
+
+	// každý request přes authMiddleware se ptá store, jestli token nebyl revokován,
+	// testy které chtějí revokovaný token si nastaví vlastní očekávání ještě před vytvořením serveru
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			IsTokenRevoked(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
 	}
 
 
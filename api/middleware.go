@@ -18,8 +18,10 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
+var errRevokedToken = errors.New("token has been revoked")
+
 // this is not middleware it is just higher order function which will returns middleware
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, revocations *revocationStore) gin.HandlerFunc {
 	// this anonymous function inside is actualy the middleware
 	return func(ctx *gin.Context) {
 		// vytáhne z header req položku s názvem "authorization"
@@ -57,6 +59,24 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// refresh token je podepsaný stejně, ale k přístupu k routám se použít nesmí
+		if err := payload.CheckType(token.TokenTypeAccess); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		// validní token mohl být zneplatněn (logout, únik tokenu), takže je nutné
+		// ověřit jeho ID v seznamu revokovaných tokenů
+		revoked, err := revocations.IsRevoked(ctx, payload)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+			return
+		}
+
 		// uložení payloadu z verifikovaného tokenu do contextu jako key value pair
 		ctx.Set(authorizationPayloadKey, payload)
 
@@ -64,3 +84,21 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// authorizeRoles allows the request only for users with one of the allowed roles,
+// it must be used after authMiddleware because it reads the payload stored in the context
+func authorizeRoles(allowedRoles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range allowedRoles {
+			if role == authPayload.Role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("the role %s is not allowed to access this route", authPayload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
@@ -1,14 +1,19 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
 	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,10 +24,12 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, duration)
+	token, payload, err := tokenMaker.CreateToken(username, role, token.TokenTypeAccess, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, payload)
 
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
@@ -40,6 +47,8 @@ func TestAuthMiddleware(t *testing.T) {
 		// this field is used to setup authorization header for testing
 		// request
 		setupAuth func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		// optional stubs for the revocation lookup, by default the token is not revoked
+		buildStubs func(store *mockdb.MockStore)
 		// The checkResponse is field which will use for set up checkResponse function for
 		// each subtest
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
@@ -47,7 +56,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -65,7 +74,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				// type authorizace je nastven na unsupported string což neodpovídá podporovanému typu na serveru
-				addAuthorization(t, request, tokenMaker, "unsupported", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -75,7 +84,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				// The client doesn't provided authorization type prefix, so authorization type is empty
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -85,12 +94,54 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "Expired Token",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				// The Expired Token is case wehen the authorization type is correct but token is alrady too old.
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			// refresh token je podepsaný stejným klíčem, ale jako bearer token neprojde
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken("user", util.DepositorRole, token.TokenTypeRefresh, time.Hour)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevocationLookupError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					IsTokenRevoked(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -99,13 +150,21 @@ func TestAuthMiddleware(t *testing.T) {
 		// The Run() function will start sub test in each for loop iteration
 		// and it also contains whole content for the subtest
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
+			server := newTestServer(t, store)
 
 			authPath := "/auth"
 
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.revocations),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -126,3 +185,104 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+// TestRouteRoles zkouší každou roli proti každé chráněné route. Requesty mají záměrně neplatné
+// parametry, takže povolená role skončí na validaci (400) a nepovolená už v authorizeRoles (403),
+// store se tak v žádném případě nevolá
+func TestRouteRoles(t *testing.T) {
+	routes := []struct {
+		method      string
+		url         string
+		body        string
+		bankersOnly bool
+	}{
+		{method: http.MethodPost, url: "/users/logout", body: "{"},
+		{method: http.MethodPost, url: "/accounts", body: "{"},
+		{method: http.MethodGet, url: "/accounts/0"},
+		{method: http.MethodGet, url: "/accounts"},
+		{method: http.MethodPost, url: "/transfers", body: "{"},
+		{method: http.MethodGet, url: "/transfers/0"},
+		{method: http.MethodPost, url: "/transfers/0/reverse"},
+		{method: http.MethodGet, url: "/accounts/0/entries"},
+		{method: http.MethodGet, url: "/accounts/0/transfers"},
+		{method: http.MethodGet, url: "/accounts/0/statement"},
+		{method: http.MethodGet, url: "/accounts/0/limits"},
+		{method: http.MethodPost, url: "/transfers/0/cancel"},
+		{method: http.MethodPost, url: "/transfers/0/authorize"},
+		{method: http.MethodPost, url: "/transfers/0/decline"},
+		{method: http.MethodGet, url: "/accounts/0/holds"},
+		{method: http.MethodPost, url: "/accounts/0/close"},
+		{method: http.MethodGet, url: "/accounts/0/members"},
+		{method: http.MethodPost, url: "/accounts/0/members", body: "{"},
+		{method: http.MethodDelete, url: "/accounts/0/members/invalid-user"},
+		{method: http.MethodPost, url: "/fx/quotes", body: "{"},
+		{method: http.MethodGet, url: "/recipients/lookup"},
+		{method: http.MethodPost, url: "/accounts/0/withdrawals", body: "{"},
+		{method: http.MethodPost, url: "/scheduled-transfers", body: "{"},
+		{method: http.MethodGet, url: "/scheduled-transfers"},
+		{method: http.MethodGet, url: "/scheduled-transfers/0"},
+		{method: http.MethodPatch, url: "/scheduled-transfers/0", body: "{"},
+		{method: http.MethodDelete, url: "/scheduled-transfers/0"},
+		{method: http.MethodGet, url: "/scheduled-transfers/0/executions"},
+		{method: http.MethodPost, url: "/transfer-batches", body: "{"},
+		{method: http.MethodGet, url: "/transfer-batches"},
+		{method: http.MethodGet, url: "/transfer-batches/0"},
+		{method: http.MethodGet, url: "/transfer-batches/0/items"},
+		{method: http.MethodPost, url: "/payment-requests", body: "{"},
+		{method: http.MethodGet, url: "/payment-requests"},
+		{method: http.MethodGet, url: "/payment-requests/0"},
+		{method: http.MethodPost, url: "/payment-requests/0/accept", body: "{"},
+		{method: http.MethodPost, url: "/payment-requests/0/decline"},
+		{method: http.MethodPost, url: "/payment-requests/0/cancel"},
+		{method: http.MethodPost, url: "/accounts/0/freeze", bankersOnly: true},
+		{method: http.MethodPost, url: "/accounts/0/unfreeze", bankersOnly: true},
+		{method: http.MethodGet, url: "/transfers", bankersOnly: true},
+		{method: http.MethodPost, url: "/accounts/0/deposits", body: "{", bankersOnly: true},
+		{method: http.MethodPost, url: "/admin/users/invalid-user/revoke_tokens", bankersOnly: true},
+		{method: http.MethodPut, url: "/accounts/0/limits", body: "{", bankersOnly: true},
+		{method: http.MethodPut, url: "/accounts/0/overdraft", body: "{", bankersOnly: true},
+		{method: http.MethodPost, url: "/transfers/0/approve", bankersOnly: true},
+		{method: http.MethodPost, url: "/transfers/0/reject", bankersOnly: true},
+		{method: http.MethodPost, url: "/accounts/0/holds", body: "{", bankersOnly: true},
+		{method: http.MethodPost, url: "/holds/0/capture", body: "{", bankersOnly: true},
+		{method: http.MethodPost, url: "/holds/0/release", bankersOnly: true},
+		{method: http.MethodPut, url: "/admin/users/invalid-user/limits", body: "{", bankersOnly: true},
+		{method: http.MethodGet, url: "/admin/reconciliation_runs", bankersOnly: true},
+		{method: http.MethodGet, url: "/admin/reconciliation_runs/0", bankersOnly: true},
+		{method: http.MethodPut, url: "/admin/interest_rates", body: "{", bankersOnly: true},
+		{method: http.MethodPut, url: "/admin/fee_schedules", body: "{", bankersOnly: true},
+		{method: http.MethodDelete, url: "/admin/fee_schedules/invalid/USD", bankersOnly: true},
+		{method: http.MethodGet, url: "/admin/users/invalid-user/fee_waivers", bankersOnly: true},
+		{method: http.MethodPut, url: "/admin/users/invalid-user/fee_waivers/transfer", bankersOnly: true},
+		{method: http.MethodDelete, url: "/admin/users/invalid-user/fee_waivers/transfer", bankersOnly: true},
+	}
+
+	roles := []string{util.DepositorRole, util.BankerRole}
+
+	for _, route := range routes {
+		for _, role := range roles {
+			route, role := route, role
+
+			t.Run(fmt.Sprintf("%s %s as %s", route.method, route.url, role), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				server := newTestServer(t, store)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(route.method, route.url, strings.NewReader(route.body))
+				require.NoError(t, err)
+
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", role, time.Minute)
+				server.router.ServeHTTP(recorder, request)
+
+				if route.bankersOnly && role != util.BankerRole {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+					return
+				}
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			})
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+// errPayerNotFound is returned for every payer which can't be asked for the money, so the response
+// doesn't tell if the user exists
+var errPayerNotFound = errors.New("payer not found")
+
+// payment request directions in the list of the payment requests of the authenticated user
+const (
+	paymentRequestIncoming = "incoming"
+	paymentRequestOutgoing = "outgoing"
+)
+
+// paymentRequestResponse hides the account of the requester, the payer sees only who asks for the money
+type paymentRequestResponse struct {
+	ID          int64      `json:"id"`
+	Requester   string     `json:"requester"`
+	Payer       string     `json:"payer"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	TransferID  *int64     `json:"transfer_id,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newPaymentRequestResponse(request db.PaymentRequest) paymentRequestResponse {
+	rsp := paymentRequestResponse{
+		ID:          request.ID,
+		Requester:   request.Requester,
+		Payer:       request.Payer,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Description: request.Description,
+		Status:      request.Status,
+		ExpiresAt:   request.ExpiresAt,
+		CreatedAt:   request.CreatedAt,
+	}
+	// otevřená žádost po expiraci už nejde zaplatit, i když ji ještě nikdo neoznačil jako expirovanou
+	if request.Status == util.PaymentRequestOpen && !request.ExpiresAt.After(time.Now()) {
+		rsp.Status = util.PaymentRequestExpired
+	}
+	if request.TransferID.Valid {
+		rsp.TransferID = &request.TransferID.Int64
+	}
+	if request.DecidedAt.Valid {
+		rsp.DecidedAt = &request.DecidedAt.Time
+	}
+	return rsp
+}
+
+type createPaymentRequestRequest struct {
+	// exactly one of PayerUsername and PayerEmail is required
+	PayerUsername string `json:"payer_username" binding:"omitempty,alphanum"`
+	PayerEmail    string `json:"payer_email" binding:"omitempty,email"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Description   string `json:"description" binding:"max=200"`
+	// optional, requests without it expire after PAYMENT_REQUEST_DURATION
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// createPaymentRequest asks the payer addressed by the username or email for the money, the money is paid
+// to the account of the authenticated user in the currency of the request
+func (server *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if (req.PayerUsername == "") == (req.PayerEmail == "") {
+		err := errors.New("exactly one of payer_username and payer_email is required")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(server.config.PaymentRequestDuration)
+	}
+	if !expiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// platba jde vždy na běžný účet žadatele, stejně jako převod adresovaný jménem nebo emailem
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	toAccount, err := server.store.GetAccountByOwnerCurrencyAndType(ctx, db.GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    authPayload.Username,
+		Currency: req.Currency,
+		Type:     util.AccountChecking,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("the authenticated user has no %s checking account", req.Currency)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if toAccount.Status != util.AccountActive {
+		err := fmt.Errorf("account [%d] is %s", toAccount.ID, toAccount.Status)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	payer, valid := server.resolveUser(ctx, req.PayerUsername, req.PayerEmail, errPayerNotFound)
+	if !valid {
+		return
+	}
+	if payer.Username == authPayload.Username {
+		err := errors.New("payer can't be the authenticated user")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, err := server.store.CreatePaymentRequest(ctx, db.CreatePaymentRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       payer.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(request))
+}
+
+type listPaymentRequestsRequest struct {
+	Direction string `form:"direction" binding:"required,oneof=incoming outgoing"`
+	Status    string `form:"status" binding:"omitempty,oneof=open pending paid declined cancelled expired"`
+	PageID    int32  `form:"page_id" binding:"required,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listPaymentRequests lists the payment requests addressed to the authenticated user (incoming)
+// or created by them (outgoing) from the newest one
+func (server *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	status := sql.NullString{String: req.Status, Valid: req.Status != ""}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var requests []db.PaymentRequest
+	var err error
+	if req.Direction == paymentRequestIncoming {
+		requests, err = server.store.ListIncomingPaymentRequests(ctx, db.ListIncomingPaymentRequestsParams{
+			Payer:  authPayload.Username,
+			Status: status,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		requests, err = server.store.ListOutgoingPaymentRequests(ctx, db.ListOutgoingPaymentRequestsParams{
+			Requester: authPayload.Username,
+			Status:    status,
+			Limit:     req.PageSize,
+			Offset:    (req.PageID - 1) * req.PageSize,
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]paymentRequestResponse, len(requests))
+	for i, request := range requests {
+		rsp[i] = newPaymentRequestResponse(request)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type paymentRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// authorizedPaymentRequest returns the payment request from the URI if the authenticated user is its requester
+// or its payer, bankers can view any payment request
+func (server *Server) authorizedPaymentRequest(ctx *gin.Context) (db.PaymentRequest, bool) {
+	var uri paymentRequestURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.PaymentRequest{}, false
+	}
+
+	request, err := server.store.GetPaymentRequest(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return request, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole && request.Requester != authPayload.Username && request.Payer != authPayload.Username {
+		err := errors.New("payment request doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return request, false
+	}
+
+	return request, true
+}
+
+// getPaymentRequest returns the payment request
+func (server *Server) getPaymentRequest(ctx *gin.Context) {
+	request, valid := server.authorizedPaymentRequest(ctx)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(request))
+}
+
+type acceptPaymentRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+// paidPaymentRequestResponse is the result of the accepted payment request, like the transfer to the recipient
+// addressed by the username it doesn't contain the account and the entry of the requester
+type paidPaymentRequestResponse struct {
+	PaymentRequest paymentRequestResponse `json:"payment_request"`
+	Transfer       db.Transfer            `json:"transfer"`
+	FromAccount    db.Account             `json:"from_account"`
+	FromEntry      db.Entry               `json:"from_entry"`
+}
+
+// acceptPaymentRequest pays the open payment request from an account which the payer can send transfers from,
+// only the payer can do it and the payment is a transfer with the same limits and approval as the transfers
+// created by the payer
+func (server *Server) acceptPaymentRequest(ctx *gin.Context) {
+	var req acceptPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	request, valid := server.payerPaymentRequest(ctx)
+	if !valid {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, request.Currency)
+	if !valid {
+		return
+	}
+
+	role, valid := server.authorizeMember(ctx, fromAccount, util.CanInitiateTransfers, "authenticated user can't send transfers from the account")
+	if !valid {
+		return
+	}
+
+	// účet žadatele mohl být od vytvoření žádosti zmrazen
+	if _, valid := server.activeAccount(ctx, request.ToAccountID); !valid {
+		return
+	}
+
+	result, err := server.store.AcceptPaymentRequestTx(ctx, db.AcceptPaymentRequestTxParams{
+		PaymentRequestID:  request.ID,
+		FromAccountID:     fromAccount.ID,
+		Limits:            server.transferLimits(),
+		ApprovalThreshold: server.config.TransferApprovalThreshold,
+		ChargeFee:         true,
+		// platba člena, který potřebuje schválení, čeká stejně jako jeho převody
+		RequireApproval: role == util.MemberNeedsApproval,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrPaymentRequestNotOpen):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrTransferLimitExceeded):
+			ctx.JSON(http.StatusForbidden, errorCodeResponse(transferLimitExceededCode, err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paidPaymentRequestResponse{
+		PaymentRequest: newPaymentRequestResponse(result.PaymentRequest),
+		Transfer:       result.Transfer,
+		FromAccount:    result.FromAccount,
+		FromEntry:      result.FromEntry,
+	})
+}
+
+// declinePaymentRequest declines the open payment request, only the payer can do it
+func (server *Server) declinePaymentRequest(ctx *gin.Context) {
+	request, valid := server.payerPaymentRequest(ctx)
+	if !valid {
+		return
+	}
+
+	server.closePaymentRequest(ctx, request, util.PaymentRequestDeclined)
+}
+
+// cancelPaymentRequest withdraws the open payment request, only the requester can do it
+func (server *Server) cancelPaymentRequest(ctx *gin.Context) {
+	request, valid := server.authorizedPaymentRequest(ctx)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Requester != authPayload.Username {
+		err := errors.New("only the requester can cancel the payment request")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	server.closePaymentRequest(ctx, request, util.PaymentRequestCancelled)
+}
+
+// payerPaymentRequest returns the payment request from the URI if the authenticated user is its payer
+func (server *Server) payerPaymentRequest(ctx *gin.Context) (db.PaymentRequest, bool) {
+	request, valid := server.authorizedPaymentRequest(ctx)
+	if !valid {
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Payer != authPayload.Username {
+		err := errors.New("only the payer can decide the payment request")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return request, false
+	}
+
+	return request, true
+}
+
+// closePaymentRequest closes the open payment request with the status without moving any money
+func (server *Server) closePaymentRequest(ctx *gin.Context, request db.PaymentRequest, status string) {
+	closed, err := server.store.ClosePaymentRequest(ctx, db.ClosePaymentRequestParams{
+		Status: status,
+		ID:     request.ID,
+	})
+	if err != nil {
+		// update mění jen otevřené žádosti před expirací, takže ji mezitím někdo rozhodl nebo vypršela
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("%w: payment request %d was decided or it expired", db.ErrPaymentRequestNotOpen, request.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPaymentRequestResponse(closed))
+}
+
+// runPaymentRequestExpiration marks the expired payment requests periodically until the context is cancelled,
+// expired requests can't be paid even before it, so the interval only affects their status
+func (server *Server) runPaymentRequestExpiration(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := server.store.ExpirePaymentRequests(ctx, time.Now()); err != nil {
+				log.Println("cannot expire payment requests:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,704 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPaymentRequest(requester string, payer string, toAccount db.Account) db.PaymentRequest {
+	return db.PaymentRequest{
+		ID:          util.RandomInt(1, 1000),
+		Requester:   requester,
+		Payer:       payer,
+		ToAccountID: toAccount.ID,
+		Amount:      util.RandomInt(1, 1000),
+		Currency:    toAccount.Currency,
+		Description: "dinner",
+		Status:      util.PaymentRequestOpen,
+		ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+	}
+}
+
+func TestCreatePaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	toAccount := randomAccount(requester.Username)
+	request := randomPaymentRequest(requester.Username, payer.Username, toAccount)
+
+	accountArg := db.GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    requester.Username,
+		Currency: toAccount.Currency,
+		Type:     util.AccountChecking,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"payer_username": payer.Username,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+				"description":    "dinner",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(payer.Username)).Times(1).Return(payer, nil)
+				store.EXPECT().
+					CreatePaymentRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+						require.Equal(t, requester.Username, arg.Requester)
+						require.Equal(t, payer.Username, arg.Payer)
+						require.Equal(t, toAccount.ID, arg.ToAccountID)
+						require.Equal(t, request.Amount, arg.Amount)
+						require.Equal(t, toAccount.Currency, arg.Currency)
+						// žádost bez expirace vyprší po PAYMENT_REQUEST_DURATION z konfigurace
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+						return request, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.PaymentRequestOpen, rsp["status"])
+				require.NotContains(t, rsp, "to_account_id")
+			},
+		},
+		{
+			name: "OKByEmail",
+			body: gin.H{
+				"payer_email": payer.Email,
+				"amount":      request.Amount,
+				"currency":    toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(payer.Email)).Times(1).Return(payer, nil)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(1).Return(request, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BothPayers",
+			body: gin.H{
+				"payer_username": payer.Username,
+				"payer_email":    payer.Email,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoAccountInCurrency",
+			body: gin.H{
+				"payer_username": payer.Username,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "PayerNotFound",
+			body: gin.H{
+				"payer_username": payer.Username,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(payer.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "SystemPayer",
+			body: gin.H{
+				"payer_username": util.SystemUser,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(util.SystemUser)).Times(1).Return(db.User{Username: util.SystemUser}, nil)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "PayerIsRequester",
+			body: gin.H{
+				"payer_username": requester.Username,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(accountArg)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(requester.Username)).Times(1).Return(requester, nil)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredExpiration",
+			body: gin.H{
+				"payer_username": payer.Username,
+				"amount":         request.Amount,
+				"currency":       toAccount.Currency,
+				"expires_at":     time.Now().Add(-time.Minute),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/payment-requests", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, requester.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListPaymentRequestsAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	request := randomPaymentRequest(requester.Username, payer.Username, randomAccount(requester.Username))
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Incoming",
+			query:    "direction=incoming&status=open&page_id=1&page_size=5",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListIncomingPaymentRequestsParams{
+					Payer:  payer.Username,
+					Status: sql.NullString{String: util.PaymentRequestOpen, Valid: true},
+					Limit:  5,
+					Offset: 0,
+				}
+				store.EXPECT().ListIncomingPaymentRequests(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.PaymentRequest{request}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []paymentRequestResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp, 1)
+				require.Equal(t, request.ID, rsp[0].ID)
+			},
+		},
+		{
+			name:     "Outgoing",
+			query:    "direction=outgoing&page_id=2&page_size=5",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListOutgoingPaymentRequestsParams{
+					Requester: requester.Username,
+					Limit:     5,
+					Offset:    5,
+				}
+				store.EXPECT().ListOutgoingPaymentRequests(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.PaymentRequest{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidDirection",
+			query:    "direction=all&page_id=1&page_size=5",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListIncomingPaymentRequests(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListOutgoingPaymentRequests(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/payment-requests?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetPaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	other, _ := randomUser(t)
+	expired := randomPaymentRequest(requester.Username, payer.Username, randomAccount(requester.Username))
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name          string
+		username      string
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Payer",
+			username: payer.Username,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// otevřená žádost po expiraci se ukazuje jako expirovaná ještě před označením
+				var rsp paymentRequestResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.PaymentRequestExpired, rsp.Status)
+			},
+		},
+		{
+			name:     "Requester",
+			username: requester.Username,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: other.Username,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(expired.ID)).Times(1).Return(expired, nil)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/payment-requests/%d", expired.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAcceptPaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+	fromAccount := randomAccount(payer.Username)
+	fromAccount.Currency = toAccount.Currency
+	request := randomPaymentRequest(requester.Username, payer.Username, toAccount)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.AcceptPaymentRequestTxParams{
+					PaymentRequestID: request.ID,
+					FromAccountID:    fromAccount.ID,
+					Limits:           db.NewDefaultTransferLimits(util.Config{}),
+					ChargeFee:        true,
+				}
+				paid := request
+				paid.Status = util.PaymentRequestPaid
+				paid.TransferID = sql.NullInt64{Int64: 1, Valid: true}
+				result := db.AcceptPaymentRequestTxResult{
+					PaymentRequest: paid,
+					TransferTxResult: db.TransferTxResult{
+						Transfer:  db.Transfer{ID: 1},
+						ToAccount: toAccount,
+					},
+				}
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// plátce nevidí účet žadatele
+				var rsp map[string]interface{}
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.NotContains(t, rsp, "to_account")
+				require.Equal(t, util.PaymentRequestPaid, rsp["payment_request"].(map[string]interface{})["status"])
+			},
+		},
+		{
+			name: "RequesterCantAccept",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "FromAccountOfAnotherUser",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				otherAccount := fromAccount
+				otherAccount.Owner = requester.Username
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(otherAccount, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NeedsApprovalMember",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				sharedAccount := fromAccount
+				sharedAccount.Owner = requester.Username
+
+				arg := db.AcceptPaymentRequestTxParams{
+					PaymentRequestID: request.ID,
+					FromAccountID:    fromAccount.ID,
+					Limits:           db.NewDefaultTransferLimits(util.Config{}),
+					ChargeFee:        true,
+					RequireApproval:  true,
+				}
+				pending := request
+				pending.Status = util.PaymentRequestPending
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(sharedAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: fromAccount.ID, Username: payer.Username})).
+					Times(1).
+					Return(db.AccountMember{AccountID: fromAccount.ID, Username: payer.Username, Role: util.MemberNeedsApproval}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{PaymentRequest: pending}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				otherCurrency := fromAccount
+				otherCurrency.Currency = "XXX"
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(otherCurrency, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOpen",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrPaymentRequestNotOpen)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+			},
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/payment-requests/%d/accept", request.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestClosePaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	request := randomPaymentRequest(requester.Username, payer.Username, randomAccount(requester.Username))
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Decline",
+			action:   "decline",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ClosePaymentRequestParams{
+					Status: util.PaymentRequestDeclined,
+					ID:     request.ID,
+				}
+				declined := request
+				declined.Status = util.PaymentRequestDeclined
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().ClosePaymentRequest(gomock.Any(), gomock.Eq(arg)).Times(1).Return(declined, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp paymentRequestResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.PaymentRequestDeclined, rsp.Status)
+			},
+		},
+		{
+			name:     "RequesterCantDecline",
+			action:   "decline",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().ClosePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "DeclineNotOpen",
+			action:   "decline",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().ClosePaymentRequest(gomock.Any(), gomock.Any()).Times(1).Return(db.PaymentRequest{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "Cancel",
+			action:   "cancel",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ClosePaymentRequestParams{
+					Status: util.PaymentRequestCancelled,
+					ID:     request.ID,
+				}
+
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().ClosePaymentRequest(gomock.Any(), gomock.Eq(arg)).Times(1).Return(request, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "PayerCantCancel",
+			action:   "cancel",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().ClosePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/payment-requests/%d/%s", request.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
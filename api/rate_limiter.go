@@ -0,0 +1,66 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// rateWindow counts the events of one key since the start of its window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter allows at most limit events of every key in a fixed window. The counters are kept in process,
+// so every replica of the server limits the keys on its own, which is good enough against enumeration,
+// where the attacker needs much more requests than the limit. Zero limit allows everything.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]rateWindow
+	// windows older than this are deleted by the next call of allow
+	pruneAt time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]rateWindow),
+	}
+}
+
+// allow records the event of the key and returns true if the key didn't reach the limit yet,
+// otherwise it returns false and the time after which the key is allowed again
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// skončená okna se mažou nejvýš jednou za okno, aby mapa nerostla s každým uživatelem
+	if now.After(l.pruneAt) {
+		for k, w := range l.windows {
+			if !now.Before(w.start.Add(l.window)) {
+				delete(l.windows, k)
+			}
+		}
+		l.pruneAt = now.Add(l.window)
+	}
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.start.Add(l.window)) {
+		w = rateWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+
+	w.count++
+	l.windows[key] = w
+	return true, 0
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.allow("user1", now)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter := limiter.allow("user1", now.Add(20*time.Second))
+	require.False(t, allowed)
+	require.Equal(t, 40*time.Second, retryAfter)
+
+	// každý klíč má vlastní okno
+	allowed, _ = limiter.allow("user2", now)
+	require.True(t, allowed)
+
+	// nové okno začíná po skončení předchozího, skončené okno druhého klíče se smaže
+	allowed, _ = limiter.allow("user1", now.Add(time.Minute+time.Second))
+	require.True(t, allowed)
+
+	require.Len(t, limiter.windows, 1)
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter := newRateLimiter(0, time.Minute)
+
+	for i := 0; i < 100; i++ {
+		allowed, _ := limiter.allow("user", time.Now())
+		require.True(t, allowed)
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+// errRecipientNotFound is returned for every recipient which can't receive the money, so the response
+// doesn't tell if the user exists and only has no account in the currency
+var errRecipientNotFound = errors.New("recipient not found")
+
+type recipientLookupRequest struct {
+	// exactly one of Username and Email is required
+	Username string `form:"username" binding:"omitempty,alphanum"`
+	Email    string `form:"email" binding:"omitempty,email"`
+	Currency string `form:"currency" binding:"required,currency"`
+}
+
+// recipientResponse confirms the recipient to the sender without revealing its personal data or its account
+type recipientResponse struct {
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Currency string `json:"currency"`
+}
+
+func newRecipientResponse(user db.User, account db.Account) recipientResponse {
+	return recipientResponse{
+		FullName: maskFullName(user.FullName),
+		Email:    maskEmail(user.Email),
+		Currency: account.Currency,
+	}
+}
+
+// lookupRecipient previews the recipient of the transfer addressed by the username or email
+func (server *Server) lookupRecipient(ctx *gin.Context) {
+	var req recipientLookupRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if (req.Username == "") == (req.Email == "") {
+		err := errors.New("exactly one of username and email is required")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, user, valid := server.resolveRecipient(ctx, req.Username, req.Email, req.Currency)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newRecipientResponse(user, account))
+}
+
+// resolveRecipient returns the account of the user with the username or email in the currency. The user can have
+// one account of each type in the currency, transfers addressed by the username or email always go to the checking
+// account, the other accounts of the user must be addressed by their ID.
+func (server *Server) resolveRecipient(ctx *gin.Context, username string, email string, currency string) (db.Account, db.User, bool) {
+	user, valid := server.resolveUser(ctx, username, email, errRecipientNotFound)
+	if !valid {
+		return db.Account{}, user, false
+	}
+
+	account, err := server.store.GetAccountByOwnerCurrencyAndType(ctx, db.GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    user.Username,
+		Currency: currency,
+		Type:     util.AccountChecking,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+			return account, user, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, user, false
+	}
+
+	// zmrazený účet peníze nepřijme, odpověď se ale nesmí lišit od neexistujícího účtu
+	if account.Status != util.AccountActive {
+		ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+		return account, user, false
+	}
+
+	return account, user, true
+}
+
+// resolveUser returns the user with the username or email, the system user is not found like a missing user.
+// Every resolution counts against the rate limit of the authenticated user, so the users can't be enumerated
+// by the lookups, the transfers nor the payment requests.
+func (server *Server) resolveUser(ctx *gin.Context, username string, email string, errNotFound error) (db.User, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if allowed, retryAfter := server.recipientLookups.allow(authPayload.Username, time.Now()); !allowed {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		err := fmt.Errorf("too many recipient lookups, try again in %s", retryAfter.Round(time.Second))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return db.User{}, false
+	}
+
+	var user db.User
+	var err error
+	if username != "" {
+		user, err = server.store.GetUser(ctx, username)
+	} else {
+		user, err = server.store.GetUserByEmail(ctx, email)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errNotFound))
+			return user, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, false
+	}
+
+	if user.Username == util.SystemUser {
+		ctx.JSON(http.StatusNotFound, errorResponse(errNotFound))
+		return user, false
+	}
+
+	return user, true
+}
+
+// recipientTransferResponse is the result of the transfer to the recipient addressed by the username or email,
+// instead of the account and the entry of the recipient it contains only the masked recipient
+type recipientTransferResponse struct {
+	Transfer    db.Transfer       `json:"transfer"`
+	FromAccount db.Account        `json:"from_account"`
+	FromEntry   db.Entry          `json:"from_entry"`
+	Recipient   recipientResponse `json:"recipient"`
+}
+
+func newRecipientTransferResponse(result db.TransferTxResult, recipient recipientResponse) recipientTransferResponse {
+	return recipientTransferResponse{
+		Transfer:    result.Transfer,
+		FromAccount: result.FromAccount,
+		FromEntry:   result.FromEntry,
+		Recipient:   recipient,
+	}
+}
+
+// maskFullName keeps only the first letter of every part of the name, e.g. "J*** D***"
+func maskFullName(fullName string) string {
+	parts := strings.Fields(fullName)
+	for i, part := range parts {
+		parts[i] = maskPrefix(part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// maskEmail keeps the first letter of the local part and the domain, e.g. "j***@example.com"
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return maskPrefix(email)
+	}
+	return maskPrefix(email[:at]) + email[at:]
+}
+
+// maskPrefix keeps the first letter and hides the length of the rest
+func maskPrefix(s string) string {
+	for _, r := range s {
+		return string(r) + "***"
+	}
+	return ""
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fx"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupRecipientAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	recipient.FullName = "John Doe"
+	recipient.Email = "john.doe@example.com"
+
+	account := randomAccount(recipient.Username)
+	account.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupServer   func(server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"username": {recipient.Username}, "currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetAccountByOwnerCurrencyAndTypeParams{
+					Owner:    recipient.Username,
+					Currency: util.USD,
+					Type:     util.AccountChecking,
+				}
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp recipientResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, recipientResponse{
+					FullName: "J*** D***",
+					Email:    "j***@example.com",
+					Currency: util.USD,
+				}, rsp)
+			},
+		},
+		{
+			name:  "Email",
+			query: url.Values{"email": {recipient.Email}, "currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(recipient.Email)).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "UserNotFound",
+			query: url.Values{"username": {recipient.Username}, "currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRecipientNotFound(t, recorder.Body)
+			},
+		},
+		{
+			// odpověď musí být stejná jako pro neexistujícího uživatele
+			name:  "AccountNotFound",
+			query: url.Values{"username": {recipient.Username}, "currency": {util.EUR}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRecipientNotFound(t, recorder.Body)
+			},
+		},
+		{
+			name:  "FrozenAccount",
+			query: url.Values{"username": {recipient.Username}, "currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAccount := account
+				frozenAccount.Status = util.AccountFrozen
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(1).Return(frozenAccount, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRecipientNotFound(t, recorder.Body)
+			},
+		},
+		{
+			name:  "UsernameAndEmail",
+			query: url.Values{"username": {recipient.Username}, "email": {recipient.Email}, "currency": {util.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingCurrency",
+			query: url.Values{"username": {recipient.Username}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "TooManyRequests",
+			query: url.Values{"username": {recipient.Username}, "currency": {util.USD}},
+			setupServer: func(server *Server) {
+				server.recipientLookups = newRateLimiter(1, time.Minute)
+				server.recipientLookups.allow(sender.Username, time.Now())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			if tc.setupServer != nil {
+				tc.setupServer(server)
+			}
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/recipients/lookup?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireRecipientNotFound(t *testing.T, body *bytes.Buffer) {
+	var rsp gin.H
+	err := json.NewDecoder(body).Decode(&rsp)
+	require.NoError(t, err)
+	require.Equal(t, errRecipientNotFound.Error(), rsp["error"])
+}
+
+func TestTransferToRecipientAPI(t *testing.T) {
+	amount := int64(10)
+
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(recipient.Username)
+	fromAccount.Currency = util.USD
+	toAccount.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_username":     recipient.Username,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+				store.EXPECT().
+					GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Eq(db.GetAccountByOwnerCurrencyAndTypeParams{
+						Owner:    recipient.Username,
+						Currency: util.USD,
+						Type:     util.AccountChecking,
+					})).
+					Times(1).
+					Return(toAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: fromAccount.ID,
+					ToAccountID:   toAccount.ID,
+					Amount:        amount,
+					ToAmount:      amount,
+					ExchangeRate:  fx.UnitRate,
+					Limits:        db.NewDefaultTransferLimits(util.Config{}),
+					ChargeFee:     true,
+					InitiatedBy:   fromAccount.Owner,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:  db.Transfer{ID: 1, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount},
+						ToAccount: toAccount,
+						ToEntry:   db.Entry{ID: 2, AccountID: toAccount.ID, Amount: amount},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp gin.H
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				// zůstatek příjemce se odesílateli nevrací
+				require.NotContains(t, rsp, "to_account")
+				require.NotContains(t, rsp, "to_entry")
+				require.Equal(t, util.USD, rsp["recipient"].(map[string]interface{})["currency"])
+			},
+		},
+		{
+			name: "Email",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_email":        recipient.Email,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(recipient.Email)).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientNotFound",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_username":     recipient.Username,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerCurrencyAndType(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "TwoRecipients",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"to_username":     recipient.Username,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoRecipient",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_email":        "not-an-email",
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestMaskRecipient(t *testing.T) {
+	require.Equal(t, "J*** D***", maskFullName("John  Doe"))
+	require.Equal(t, "Ž***", maskFullName("Žofie"))
+	require.Equal(t, "", maskFullName(""))
+	require.Equal(t, "j***@example.com", maskEmail("john.doe@example.com"))
+	require.Equal(t, "a***", maskEmail("alice"))
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+type reconciliationRunResponse struct {
+	ID               int64      `json:"id"`
+	Status           string     `json:"status"`
+	AccountsChecked  int64      `json:"accounts_checked"`
+	TransfersChecked int64      `json:"transfers_checked"`
+	IssuesFound      int64      `json:"issues_found"`
+	Error            string     `json:"error,omitempty"`
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+}
+
+func newReconciliationRunResponse(run db.ReconciliationRun) reconciliationRunResponse {
+	rsp := reconciliationRunResponse{
+		ID:               run.ID,
+		Status:           run.Status,
+		AccountsChecked:  run.AccountsChecked,
+		TransfersChecked: run.TransfersChecked,
+		IssuesFound:      run.IssuesFound,
+		Error:            run.Error,
+		StartedAt:        run.StartedAt,
+	}
+	if run.FinishedAt.Valid {
+		rsp.FinishedAt = &run.FinishedAt.Time
+	}
+	return rsp
+}
+
+type reconciliationIssueResponse struct {
+	ID         int64     `json:"id"`
+	Kind       string    `json:"kind"`
+	AccountID  *int64    `json:"account_id,omitempty"`
+	TransferID *int64    `json:"transfer_id,omitempty"`
+	Expected   int64     `json:"expected"`
+	Actual     int64     `json:"actual"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newReconciliationIssueResponse(issue db.ReconciliationIssue) reconciliationIssueResponse {
+	rsp := reconciliationIssueResponse{
+		ID:        issue.ID,
+		Kind:      issue.Kind,
+		Expected:  issue.Expected,
+		Actual:    issue.Actual,
+		Details:   issue.Details,
+		CreatedAt: issue.CreatedAt,
+	}
+	if issue.AccountID.Valid {
+		rsp.AccountID = &issue.AccountID.Int64
+	}
+	if issue.TransferID.Valid {
+		rsp.TransferID = &issue.TransferID.Int64
+	}
+	return rsp
+}
+
+type listReconciliationRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listReconciliationRuns lists the reconciliation runs from the newest one, only bankers can do it
+func (server *Server) listReconciliationRuns(ctx *gin.Context) {
+	var req listReconciliationRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	runs, err := server.store.ListReconciliationRuns(ctx, db.ListReconciliationRunsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]reconciliationRunResponse, len(runs))
+	for i, run := range runs {
+		rsp[i] = newReconciliationRunResponse(run)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getReconciliationRunURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// the issues of the run are paginated, one run can report every account
+type getReconciliationRunQuery struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+type getReconciliationRunResponse struct {
+	Run    reconciliationRunResponse     `json:"run"`
+	Issues []reconciliationIssueResponse `json:"issues"`
+}
+
+// getReconciliationRun returns the reconciliation run with a page of its issues, only bankers can do it
+func (server *Server) getReconciliationRun(ctx *gin.Context) {
+	var uri getReconciliationRunURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query getReconciliationRunQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	run, err := server.store.GetReconciliationRun(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	issues, err := server.store.ListReconciliationIssues(ctx, db.ListReconciliationIssuesParams{
+		RunID:  run.ID,
+		Limit:  query.PageSize,
+		Offset: (query.PageID - 1) * query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := getReconciliationRunResponse{
+		Run:    newReconciliationRunResponse(run),
+		Issues: make([]reconciliationIssueResponse, len(issues)),
+	}
+	for i, issue := range issues {
+		rsp.Issues[i] = newReconciliationIssueResponse(issue)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
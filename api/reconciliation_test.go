@@ -0,0 +1,255 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/reconcile"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomReconciliationRun() db.ReconciliationRun {
+	startedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	return db.ReconciliationRun{
+		ID:               util.RandomInt(1, 1000),
+		Status:           reconcile.StatusDrift,
+		AccountsChecked:  util.RandomInt(1, 1000),
+		TransfersChecked: util.RandomInt(1, 1000),
+		IssuesFound:      1,
+		StartedAt:        startedAt,
+		FinishedAt:       sql.NullTime{Time: startedAt.Add(time.Second), Valid: true},
+	}
+}
+
+func TestListReconciliationRunsAPI(t *testing.T) {
+	runs := []db.ReconciliationRun{randomReconciliationRun(), randomReconciliationRun()}
+	// běh, který ještě neskončil
+	runs[1].Status = reconcile.StatusRunning
+	runs[1].FinishedAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListReconciliationRunsParams{Limit: 5, Offset: 0}
+				store.EXPECT().ListReconciliationRuns(gomock.Any(), gomock.Eq(arg)).Times(1).Return(runs, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRuns []reconciliationRunResponse
+				err := json.NewDecoder(recorder.Body).Decode(&gotRuns)
+				require.NoError(t, err)
+				require.Len(t, gotRuns, len(runs))
+
+				require.Equal(t, runs[0].ID, gotRuns[0].ID)
+				require.Equal(t, runs[0].IssuesFound, gotRuns[0].IssuesFound)
+				require.NotNil(t, gotRuns[0].FinishedAt)
+				require.True(t, runs[0].FinishedAt.Time.Equal(*gotRuns[0].FinishedAt))
+
+				require.Equal(t, reconcile.StatusRunning, gotRuns[1].Status)
+				require.Nil(t, gotRuns[1].FinishedAt)
+			},
+		},
+		{
+			name:  "DepositorRole",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListReconciliationRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: url.Values{"page_id": {"1"}, "page_size": {"100"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListReconciliationRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListReconciliationRuns(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/admin/reconciliation_runs?" + tc.query.Encode()
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetReconciliationRunAPI(t *testing.T) {
+	run := randomReconciliationRun()
+	issues := []db.ReconciliationIssue{
+		{
+			ID:        1,
+			RunID:     run.ID,
+			Kind:      reconcile.KindBalanceDrift,
+			AccountID: sql.NullInt64{Int64: 5, Valid: true},
+			Expected:  100,
+			Actual:    150,
+			Details:   "balance of account 5 is 150, its entries sum to 100",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		runID         int64
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			runID: run.ID,
+			query: url.Values{"page_id": {"2"}, "page_size": {"50"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListReconciliationIssuesParams{RunID: run.ID, Limit: 50, Offset: 50}
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().ListReconciliationIssues(gomock.Any(), gomock.Eq(arg)).Times(1).Return(issues, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp getReconciliationRunResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, run.ID, rsp.Run.ID)
+				require.Len(t, rsp.Issues, 1)
+				require.Equal(t, reconcile.KindBalanceDrift, rsp.Issues[0].Kind)
+				require.Equal(t, int64(5), *rsp.Issues[0].AccountID)
+				require.Nil(t, rsp.Issues[0].TransferID)
+			},
+		},
+		{
+			name:  "NotFound",
+			runID: run.ID,
+			query: url.Values{"page_id": {"1"}, "page_size": {"50"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(db.ReconciliationRun{}, sql.ErrNoRows)
+				store.EXPECT().ListReconciliationIssues(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidID",
+			runID: 0,
+			query: url.Values{"page_id": {"1"}, "page_size": {"50"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			runID: run.ID,
+			query: url.Values{"page_id": {"1"}, "page_size": {"50"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().ListReconciliationIssues(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/reconciliation_runs/%d?%s", tc.runID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
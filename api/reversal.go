@@ -0,0 +1,104 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type reverseTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferRequest struct {
+	// transfer_id je z URI, v JSON je jen kvůli hashi idempotentního requestu
+	TransferID int64 `json:"transfer_id"`
+	// amount in the currency of the to account of the transfer, if it is missing the rest of the transfer is reversed
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// reverseTransfer returns the money of the transfer back to its from account, bankers can reverse any transfer
+// and depositors can refund the transfers received by the accounts which they can send transfers from
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri reverseTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req reverseTransferRequest
+	// tělo requestu je nepovinné, bez něj se vrací celý zbytek převodu
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.TransferID = uri.ID
+
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.TransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// peníze se vrací z účtu příjemce, takže vrácení může začít jen člen, který z něj smí posílat, nebo bankéř
+	toAccount, valid := server.activeAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole {
+		role, valid := server.authorizeMember(ctx, toAccount, util.CanInitiateTransfers, "authenticated user can't send transfers from the account which received the transfer")
+		if !valid {
+			return
+		}
+		// vrácení se provádí hned, takže nemůže čekat na schválení vlastníkem účtu
+		if role == util.MemberNeedsApproval {
+			err := errors.New("transfers of the authenticated user need approval, the owner of the account must reverse the transfer")
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+	}
+
+	if _, valid := server.activeAccount(ctx, transfer.FromAccountID); !valid {
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID:  req.TransferID,
+		Amount:      req.Amount,
+		Idempotency: idempotency,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrIdempotencyKeyUsed):
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+		case errors.Is(err, db.ErrTransferReversed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInvalidReversal), errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
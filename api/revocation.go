@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+)
+
+// revocationCacheEntry is the cached answer for one token ID
+type revocationCacheEntry struct {
+	username string
+	revoked  bool
+	// after this time the entry is not trusted anymore and must be looked up again
+	validUntil time.Time
+}
+
+// revocationStore decides if a verified token payload was revoked.
+// Revocations are persisted in Postgres so all server replicas see them,
+// the answers are cached in process to avoid a database query for each request.
+// Revoked IDs are cached until the token expires, because the answer can never change,
+// not revoked IDs are cached only for cacheTTL, so a revocation made by another replica
+// is picked up after at most cacheTTL.
+type revocationStore struct {
+	store    db.Store
+	cacheTTL time.Duration
+	// maxTokenLifetime is used to delete user revocations which can't match any living token
+	maxTokenLifetime time.Duration
+
+	mu    sync.RWMutex
+	cache map[uuid.UUID]revocationCacheEntry
+}
+
+func newRevocationStore(store db.Store, cacheTTL time.Duration, maxTokenLifetime time.Duration) *revocationStore {
+	return &revocationStore{
+		store:            store,
+		cacheTTL:         cacheTTL,
+		maxTokenLifetime: maxTokenLifetime,
+		cache:            make(map[uuid.UUID]revocationCacheEntry),
+	}
+}
+
+// IsRevoked returns true if the token with this payload was revoked on its own
+// or together with all other tokens of the user
+func (r *revocationStore) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	now := time.Now()
+
+	r.mu.RLock()
+	entry, ok := r.cache[payload.ID]
+	r.mu.RUnlock()
+	if ok && now.Before(entry.validUntil) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := r.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	validUntil := payload.ExpiredAt
+	if !revoked && now.Add(r.cacheTTL).Before(validUntil) {
+		validUntil = now.Add(r.cacheTTL)
+	}
+	r.remember(payload, revoked, validUntil)
+
+	return revoked, nil
+}
+
+// Revoke revokes one specific token, for example the one used to logout
+func (r *revocationStore) Revoke(ctx context.Context, payload *token.Payload) error {
+	err := r.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.remember(payload, true, payload.ExpiredAt)
+	return nil
+}
+
+// RevokeAll revokes every token of the user issued until now and blocks all the user sessions,
+// so the revoked refresh tokens can't be used to get a new access token
+func (r *revocationStore) RevokeAll(ctx context.Context, username string) error {
+	err := r.store.RevokeUserTokens(ctx, db.RevokeUserTokensParams{
+		Username:      username,
+		RevokedBefore: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.store.BlockUserSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	// the cached answers for this user are outdated now
+	r.mu.Lock()
+	for id, entry := range r.cache {
+		if entry.username == username && !entry.revoked {
+			delete(r.cache, id)
+		}
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *revocationStore) remember(payload *token.Payload, revoked bool, validUntil time.Time) {
+	r.mu.Lock()
+	r.cache[payload.ID] = revocationCacheEntry{
+		username:   payload.Username,
+		revoked:    revoked,
+		validUntil: validUntil,
+	}
+	r.mu.Unlock()
+}
+
+// collectGarbage removes revocations of already expired tokens from the database and from the cache,
+// an expired token is rejected by the token maker anyway, so there is no reason to keep them
+func (r *revocationStore) collectGarbage(ctx context.Context) error {
+	now := time.Now()
+
+	r.mu.Lock()
+	for id, entry := range r.cache {
+		if now.After(entry.validUntil) {
+			delete(r.cache, id)
+		}
+	}
+	r.mu.Unlock()
+
+	err := r.store.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.store.DeleteUserTokenRevocations(ctx, now.Add(-r.maxTokenLifetime))
+}
+
+// runGarbageCollector calls collectGarbage periodically until the context is cancelled
+func (r *revocationStore) runGarbageCollector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.collectGarbage(ctx); err != nil {
+				log.Println("cannot collect revoked tokens:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationStoreCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newRevocationStore(store, time.Minute, time.Hour)
+
+	payload, err := token.NewPayload(util.RandomOwner(), util.DepositorRole, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	// the first lookup goes to the database, the second one is answered from the cache
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+	for i := 0; i < 2; i++ {
+		revoked, err := revocations.IsRevoked(context.Background(), payload)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	}
+
+	// revoking the token updates the cache, so no more database lookups are needed
+	store.EXPECT().CreateRevokedToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	err = revocations.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	revoked, err := revocations.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevocationStoreRevokeAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newRevocationStore(store, time.Minute, time.Hour)
+
+	payload, err := token.NewPayload(util.RandomOwner(), util.DepositorRole, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	gomock.InOrder(
+		store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil),
+		store.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(1).Return(nil),
+		store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Eq(payload.Username)).Times(1).Return(nil),
+		store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil),
+	)
+
+	revoked, err := revocations.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// the cached "not revoked" answer must be dropped, so the next lookup goes to the database again
+	err = revocations.RevokeAll(context.Background(), payload.Username)
+	require.NoError(t, err)
+
+	revoked, err = revocations.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevocationStoreCollectGarbage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	revocations := newRevocationStore(store, time.Minute, time.Hour)
+
+	payload, err := token.NewPayload(util.RandomOwner(), util.DepositorRole, token.TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+	revocations.remember(payload, true, payload.ExpiredAt)
+
+	store.EXPECT().DeleteExpiredRevokedTokens(gomock.Any()).Times(1).Return(nil)
+	store.EXPECT().DeleteUserTokenRevocations(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+	err = revocations.collectGarbage(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, revocations.cache)
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+var errScheduledTransferNotActive = errors.New("scheduled transfer is not active")
+
+type scheduledTransferResponse struct {
+	ID            int64      `json:"id"`
+	Owner         string     `json:"owner"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        int64      `json:"amount"`
+	Recurrence    string     `json:"recurrence"`
+	StartAt       time.Time  `json:"start_at"`
+	EndAt         *time.Time `json:"end_at,omitempty"`
+	NextRunAt     time.Time  `json:"next_run_at"`
+	Runs          int32      `json:"runs"`
+	Attempts      int32      `json:"attempts"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newScheduledTransferResponse(scheduled db.ScheduledTransfer) scheduledTransferResponse {
+	rsp := scheduledTransferResponse{
+		ID:            scheduled.ID,
+		Owner:         scheduled.Owner,
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		Recurrence:    scheduled.Recurrence,
+		StartAt:       scheduled.StartAt,
+		NextRunAt:     scheduled.NextRunAt,
+		Runs:          scheduled.Runs,
+		Attempts:      scheduled.Attempts,
+		Status:        scheduled.Status,
+		CreatedAt:     scheduled.CreatedAt,
+	}
+	if scheduled.EndAt.Valid {
+		rsp.EndAt = &scheduled.EndAt.Time
+	}
+	return rsp
+}
+
+type scheduledTransferExecutionResponse struct {
+	ID           int64     `json:"id"`
+	OccurrenceAt time.Time `json:"occurrence_at"`
+	Attempt      int32     `json:"attempt"`
+	Status       string    `json:"status"`
+	TransferID   *int64    `json:"transfer_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newScheduledTransferExecutionResponse(execution db.ScheduledTransferExecution) scheduledTransferExecutionResponse {
+	rsp := scheduledTransferExecutionResponse{
+		ID:           execution.ID,
+		OccurrenceAt: execution.OccurrenceAt,
+		Attempt:      execution.Attempt,
+		Status:       execution.Status,
+		Error:        execution.Error,
+		CreatedAt:    execution.CreatedAt,
+	}
+	if execution.TransferID.Valid {
+		rsp.TransferID = &execution.TransferID.Int64
+	}
+	return rsp
+}
+
+// validEndAt checks the end of the scheduled transfer, one-off transfers can't have it
+func validEndAt(recurrence string, startAt time.Time, endAt *time.Time) error {
+	if endAt == nil {
+		return nil
+	}
+	if recurrence == util.RecurrenceOnce {
+		return errors.New("end_at can't be set for a one-off scheduled transfer")
+	}
+	if endAt.Before(startAt) {
+		return errors.New("end_at must not be before start_at")
+	}
+	return nil
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Recurrence    string `json:"recurrence" binding:"required,recurrence"`
+	// the first occurrence, the next ones are computed from it
+	StartAt time.Time  `json:"start_at" binding:"required"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+// createScheduledTransfer schedules a transfer from an account which the authenticated user can send transfers from,
+// both accounts must have the currency of the request
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.StartAt.After(time.Now()) {
+		err := errors.New("start_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := validEndAt(req.Recurrence, req.StartAt, req.EndAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	// role člena se kontroluje znovu při každém provedení, mezitím ji mohl vlastník účtu změnit
+	if _, valid := server.authorizeMember(ctx, fromAccount, util.CanInitiateTransfers, "authenticated user can't send transfers from the account"); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if _, valid := server.validAccount(ctx, req.ToAccountID, req.Currency); !valid {
+		return
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Recurrence:    req.Recurrence,
+		StartAt:       req.StartAt,
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listScheduledTransfers lists the scheduled transfers of the authenticated user
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduledTransfers, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]scheduledTransferResponse, len(scheduledTransfers))
+	for i, scheduled := range scheduledTransfers {
+		rsp[i] = newScheduledTransferResponse(scheduled)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type scheduledTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// authorizedScheduledTransfer returns the scheduled transfer from the URI if it belongs to the authenticated user,
+// bankers can work with any scheduled transfer
+func (server *Server) authorizedScheduledTransfer(ctx *gin.Context) (db.ScheduledTransfer, bool) {
+	var uri scheduledTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ScheduledTransfer{}, false
+	}
+
+	scheduled, err := server.store.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduled, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduled, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole && scheduled.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
+
+// getScheduledTransfer returns the scheduled transfer
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	scheduled, valid := server.authorizedScheduledTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type updateScheduledTransferRequest struct {
+	Amount int64      `json:"amount" binding:"omitempty,gt=0"`
+	EndAt  *time.Time `json:"end_at"`
+}
+
+// updateScheduledTransfer changes the amount or the end of the active scheduled transfer,
+// the change applies to the occurrences which are not executed yet
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Amount == 0 && req.EndAt == nil {
+		err := errors.New("amount or end_at is required")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.authorizedScheduledTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	if err := validEndAt(scheduled.Recurrence, scheduled.StartAt, req.EndAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID: scheduled.ID,
+	}
+	if req.Amount != 0 {
+		arg.Amount = sql.NullInt64{Int64: req.Amount, Valid: true}
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+
+	scheduled, err := server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		// query mění jen aktivní převody, mezitím mohl být dokončen nebo zrušen
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errScheduledTransferNotActive))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+// cancelScheduledTransfer cancels the active scheduled transfer, its executions are kept
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	scheduled, valid := server.authorizedScheduledTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	scheduled, err := server.store.CancelScheduledTransfer(ctx, scheduled.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errScheduledTransferNotActive))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransferExecutionsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listScheduledTransferExecutions lists the executions of the scheduled transfer from the newest one
+func (server *Server) listScheduledTransferExecutions(ctx *gin.Context) {
+	var req listScheduledTransferExecutionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduled, valid := server.authorizedScheduledTransfer(ctx)
+	if !valid {
+		return
+	}
+
+	executions, err := server.store.ListScheduledTransferExecutions(ctx, db.ListScheduledTransferExecutionsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]scheduledTransferExecutionResponse, len(executions))
+	for i, execution := range executions {
+		rsp[i] = newScheduledTransferExecutionResponse(execution)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
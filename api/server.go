@@ -1,35 +1,63 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fx"
 	"github.com/karlib/simple_bank/token"
 	"github.com/karlib/simple_bank/util"
 )
 
+// how often are the revocations of expired tokens deleted
+const revocationGCInterval = time.Hour
+
+// how often are the expired idempotency keys deleted
+const idempotencyKeyGCInterval = time.Hour
+
+// how often are the expired account holds marked as expired
+const holdExpirationInterval = time.Minute
+
+// how often are the expired payment requests marked as expired
+const paymentRequestExpirationInterval = time.Minute
+
 // Server servers all http requests for my bank service
 type Server struct {
-	config     util.Config
-	store      db.Store
-	router     *gin.Engine
-	tokenMaker token.Maker
+	config      util.Config
+	store       db.Store
+	router      *gin.Engine
+	tokenMaker  token.Maker
+	keyring     *token.Keyring
+	revocations *revocationStore
+	fxProvider  fx.Provider
+	// limits the recipient lookups of every user, see resolveRecipient
+	recipientLookups *rateLimiter
 }
 
 // NewServer creates a new HTTP server instance and setup routing
 func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPassetoMaker(config.TokenSymetricKey)
+	keyring, err := newTokenKeyring(config)
 	if err != nil {
 		// %w is used to wrap original error
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	fxProvider, err := newFXProvider(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create fx provider: %w", err)
+	}
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:           config,
+		store:            store,
+		tokenMaker:       keyring,
+		keyring:          keyring,
+		revocations:      newRevocationStore(store, config.RevocationCacheTTL, config.RefreshTokenDuration),
+		fxProvider:       fxProvider,
+		recipientLookups: newRateLimiter(config.RecipientLookupLimit, config.RecipientLookupWindow),
 	}
 
 	// Here i got access to the actual used validate engine for GIN framework
@@ -40,6 +68,11 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		// so if for struct field inside request will be used tag like this-> binding:"currency"
 		// then the GIN framework will use my custom currency validator
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("recurrence", validRecurrence)
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterValidation("day_count", validDayCount)
+		v.RegisterValidation("fee_kind", validFeeKind)
+		v.RegisterValidation("fee_method", validFeeMethod)
 	}
 
 	server.setupRouter()
@@ -47,28 +80,159 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	return server, nil
 }
 
+// keyringConfig returns the keyring config from the TOKEN_KEYRING_PATH file, if the path is not set
+// the keyring contains only one key without ID built from TOKEN_TYPE and the other TOKEN_* configs
+func keyringConfig(config util.Config) (token.KeyringConfig, error) {
+	if config.TokenKeyringPath != "" {
+		return token.LoadKeyringConfig(config.TokenKeyringPath)
+	}
+
+	tokenType := config.TokenType
+	// empty token type keeps the PASETO v2.local maker used before the token type was configurable
+	if tokenType == "" {
+		tokenType = token.TypePasetoLocal
+	}
+
+	return token.KeyringConfig{
+		ActiveKeyID: "",
+		Keys: []token.KeyConfig{
+			{
+				ID:             "",
+				Type:           tokenType,
+				SymmetricKey:   config.TokenSymetricKey,
+				PrivateKeyPath: config.TokenPrivateKeyPath,
+				PublicKeyPath:  config.TokenPublicKeyPath,
+			},
+		},
+	}, nil
+}
+
+// newTokenKeyring creates the keyring which is used as the token maker of the server
+func newTokenKeyring(config util.Config) (*token.Keyring, error) {
+	keyringConfig, err := keyringConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.NewKeyring(keyringConfig)
+}
+
+// newFXProvider returns the static provider with the rates from FX_RATES_PATH file,
+// if the path is not set the rates are read from the fx_rates table
+func newFXProvider(config util.Config, store db.Store) (fx.Provider, error) {
+	if config.FXRatesPath != "" {
+		return fx.LoadStaticProvider(config.FXRatesPath)
+	}
+
+	return fx.NewStoreProvider(store), nil
+}
+
+// ReloadTokenKeys replaces the token keys with the keys from the new config,
+// it is used to rotate the keys without restarting the server
+func (server *Server) ReloadTokenKeys(config util.Config) error {
+	keyringConfig, err := keyringConfig(config)
+	if err != nil {
+		return err
+	}
+
+	return server.keyring.Reload(keyringConfig)
+}
+
 func (server *Server) setupRouter() {
 	router := gin.Default()
 	// all registred routes
-	// the the first two routes must be public the rest of will be protected by authMiddleware
+	// the the first four routes must be public the rest of will be protected by authMiddleware
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations))
+	authRoutes.POST("/users/logout", server.logoutUser)
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccountByID)
 	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/transfers", server.listAccountTransfers)
+	authRoutes.GET("/accounts/:id/statement", server.exportStatement)
+	authRoutes.GET("/accounts/:id/limits", server.getAccountLimits)
+	authRoutes.GET("/accounts/:id/holds", server.listHolds)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
+	authRoutes.GET("/accounts/:id/members", server.listAccountMembers)
+	authRoutes.POST("/accounts/:id/members", server.addAccountMember)
+	authRoutes.DELETE("/accounts/:id/members/:username", server.removeAccountMember)
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/cancel", server.cancelTransfer)
+	authRoutes.POST("/transfers/:id/authorize", server.authorizeTransfer)
+	authRoutes.POST("/transfers/:id/decline", server.declineTransfer)
+	authRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+	authRoutes.POST("/fx/quotes", server.createFxQuote)
+	authRoutes.GET("/recipients/lookup", server.lookupRecipient)
+	authRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.PATCH("/scheduled-transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", server.cancelScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/executions", server.listScheduledTransferExecutions)
+	authRoutes.POST("/transfer-batches", server.createTransferBatch)
+	authRoutes.GET("/transfer-batches", server.listTransferBatches)
+	authRoutes.GET("/transfer-batches/:id", server.getTransferBatch)
+	authRoutes.GET("/transfer-batches/:id/items", server.listTransferBatchItems)
+	authRoutes.POST("/payment-requests", server.createPaymentRequest)
+	authRoutes.GET("/payment-requests", server.listPaymentRequests)
+	authRoutes.GET("/payment-requests/:id", server.getPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/accept", server.acceptPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/decline", server.declinePaymentRequest)
+	authRoutes.POST("/payment-requests/:id/cancel", server.cancelPaymentRequest)
+
+	// routes for the bank staff only
+	authRoutes.POST("/accounts/:id/freeze", authorizeRoles(util.BankerRole), server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", authorizeRoles(util.BankerRole), server.unfreezeAccount)
+	authRoutes.POST("/accounts/:id/deposits", authorizeRoles(util.BankerRole), server.createDeposit)
+	authRoutes.PUT("/accounts/:id/limits", authorizeRoles(util.BankerRole), server.setAccountLimits)
+	authRoutes.PUT("/accounts/:id/overdraft", authorizeRoles(util.BankerRole), server.setOverdraftLimit)
+	authRoutes.POST("/accounts/:id/holds", authorizeRoles(util.BankerRole), server.placeHold)
+	authRoutes.POST("/holds/:id/capture", authorizeRoles(util.BankerRole), server.captureHold)
+	authRoutes.POST("/holds/:id/release", authorizeRoles(util.BankerRole), server.releaseHold)
+	authRoutes.GET("/transfers", authorizeRoles(util.BankerRole), server.listTransfers)
+	authRoutes.POST("/transfers/:id/approve", authorizeRoles(util.BankerRole), server.approveTransfer)
+	authRoutes.POST("/transfers/:id/reject", authorizeRoles(util.BankerRole), server.rejectTransfer)
+	authRoutes.POST("/admin/users/:username/revoke_tokens", authorizeRoles(util.BankerRole), server.revokeUserTokens)
+	authRoutes.PUT("/admin/users/:username/limits", authorizeRoles(util.BankerRole), server.setUserLimits)
+	authRoutes.GET("/admin/reconciliation_runs", authorizeRoles(util.BankerRole), server.listReconciliationRuns)
+	authRoutes.GET("/admin/reconciliation_runs/:id", authorizeRoles(util.BankerRole), server.getReconciliationRun)
+	authRoutes.GET("/admin/interest_rates", authorizeRoles(util.BankerRole), server.listInterestRates)
+	authRoutes.PUT("/admin/interest_rates", authorizeRoles(util.BankerRole), server.setInterestRate)
+	authRoutes.GET("/admin/fee_schedules", authorizeRoles(util.BankerRole), server.listFeeSchedules)
+	authRoutes.PUT("/admin/fee_schedules", authorizeRoles(util.BankerRole), server.setFeeSchedule)
+	authRoutes.DELETE("/admin/fee_schedules/:kind/:currency", authorizeRoles(util.BankerRole), server.deleteFeeSchedule)
+	authRoutes.GET("/admin/users/:username/fee_waivers", authorizeRoles(util.BankerRole), server.listFeeWaivers)
+	authRoutes.PUT("/admin/users/:username/fee_waivers/:kind", authorizeRoles(util.BankerRole), server.waiveFee)
+	authRoutes.DELETE("/admin/users/:username/fee_waivers/:kind", authorizeRoles(util.BankerRole), server.deleteFeeWaiver)
 	//Add routes to router
 	server.router = router
 }
 
 // Start runs HTTP server on a specific address
+// together with the background cleanup of expired token revocations, idempotency keys, account holds
+// and payment requests
 func (server *Server) Start(address string) error {
+	go server.revocations.runGarbageCollector(context.Background(), revocationGCInterval)
+	go server.runIdempotencyKeyGarbageCollector(context.Background(), idempotencyKeyGCInterval)
+	go server.runHoldExpiration(context.Background(), holdExpirationInterval)
+	go server.runPaymentRequestExpiration(context.Background(), paymentRequestExpirationInterval)
+
 	return server.router.Run(address)
 }
 
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
+
+// errorCodeResponse adds the code to the error, so clients can tell the errors with the same HTTP status apart
+func errorCodeResponse(code string, err error) gin.H {
+	return gin.H{"error": err.Error(), "code": code}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/statement"
+)
+
+// direction filters of the statements, the empty direction lists both
+const (
+	directionIncoming = "incoming"
+	directionOutgoing = "outgoing"
+)
+
+type statementURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// from and to are RFC 3339 times, the range includes from and excludes to
+type statementQuery struct {
+	PageID    int32     `form:"page_id" binding:"required,min=1"`
+	PageSize  int32     `form:"page_size" binding:"required,min=1,max=10"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+}
+
+// bindStatementRequest binds the account ID and the filters of the statement
+// and checks that the authenticated user can view the account
+func (server *Server) bindStatementRequest(ctx *gin.Context) (statementURI, statementQuery, bool) {
+	var uri statementURI
+	var query statementQuery
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uri, query, false
+	}
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uri, query, false
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		err := errors.New("from must be before to")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uri, query, false
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID); !valid {
+		return uri, query, false
+	}
+
+	return uri, query, true
+}
+
+// nullTime converts the optional time filter, zero time means no filter
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// listAccountEntries returns the entries of the account with the balance after each entry
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	uri, query, valid := server.bindStatementRequest(ctx)
+	if !valid {
+		return
+	}
+
+	entries, err := server.store.ListEntries(ctx, db.ListEntriesParams{
+		AccountID: uri.ID,
+		FromTime:  nullTime(query.From),
+		ToTime:    nullTime(query.To),
+		Direction: query.Direction,
+		Limit:     query.PageSize,
+		Offset:    (query.PageID - 1) * query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// listAccountTransfers returns the incoming and outgoing transfers of the account
+// with the balance of the account after each transfer
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	uri, query, valid := server.bindStatementRequest(ctx)
+	if !valid {
+		return
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, db.ListTransfersParams{
+		AccountID: uri.ID,
+		FromTime:  nullTime(query.From),
+		ToTime:    nullTime(query.To),
+		Direction: query.Direction,
+		Limit:     query.PageSize,
+		Offset:    (query.PageID - 1) * query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+// from and to are optional, the statement starts when the account was created and ends now by default
+type exportStatementQuery struct {
+	Format string    `form:"format" binding:"required,oneof=csv ofx camt053"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+}
+
+// exportStatement writes the statement of the account for the accounting tools. The lines are streamed
+// from the database directly to the response, so the whole statement is never loaded into memory.
+func (server *Server) exportStatement(ctx *gin.Context) {
+	var uri statementURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var query exportStatementQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.authorizedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	now := time.Now()
+	info := statement.Info{
+		Account:   account,
+		From:      query.From,
+		To:        query.To,
+		CreatedAt: now,
+	}
+	if info.From.IsZero() {
+		info.From = account.CreatedAt
+	}
+	if info.To.IsZero() {
+		info.To = now
+	}
+	if !info.From.Before(info.To) {
+		err := errors.New("from must be before to")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// dokud není buffer plný, nic se klientovi neodešle a při chybě se ještě dá vrátit status 500
+	buf := bufio.NewWriter(ctx.Writer)
+	writer, err := statement.NewWriter(query.Format, buf, info)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Type", statement.ContentType(query.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, statement.FileName(query.Format, info)))
+	ctx.Status(http.StatusOK)
+
+	err = server.store.ExportStatement(ctx, db.StatementParams{
+		AccountID: account.ID,
+		FromTime:  info.From,
+		ToTime:    info.To,
+	}, writer)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		if !ctx.Writer.Written() {
+			ctx.Header("Content-Type", "")
+			ctx.Header("Content-Disposition", "")
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		// část výpisu už je odeslaná a status se změnit nedá, klient dostane neúplný dokument bez konce
+		log.Printf("cannot export the statement of account %d: %v", account.ID, err)
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name string
+		// buildSession gets the refresh token created by the test server token maker
+		// and returns the session row stored in database for this token
+		buildSession func(refreshToken string, payload *token.Payload) db.Session
+		getErr       error
+		// optional stubs called after the session is checked
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return newTestSession(refreshToken, payload)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp renewAccessTokenResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.True(t, rsp.AccessTokenExpiresAt.After(time.Now()))
+			},
+		},
+		{
+			name: "GetUserError",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return newTestSession(refreshToken, payload)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{}
+			},
+			getErr: sql.ErrNoRows,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				return db.Session{}
+			},
+			getErr: sql.ErrConnDone,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				session := newTestSession(refreshToken, payload)
+				session.IsBlocked = true
+				return session
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectSessionUser",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				session := newTestSession(refreshToken, payload)
+				session.Username = "someoneelse"
+				return session
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedSessionToken",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				session := newTestSession(refreshToken, payload)
+				session.RefreshToken = "other"
+				return session
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			buildSession: func(refreshToken string, payload *token.Payload) db.Session {
+				session := newTestSession(refreshToken, payload)
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				return session
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, token.TokenTypeRefresh, time.Hour)
+			require.NoError(t, err)
+
+			session := tc.buildSession(refreshToken, payload)
+			store.EXPECT().
+				GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+				Times(1).
+				Return(session, tc.getErr)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRenewAccessTokenInvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"refresh_token": "invalid"})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRenewAccessTokenWithAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	accessToken, _, err := server.tokenMaker.CreateToken("user", util.DepositorRole, token.TokenTypeAccess, time.Hour)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(gin.H{"refresh_token": accessToken})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func newTestSession(refreshToken string, payload *token.Payload) db.Session {
+	return db.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+	}
+}
+
+func TestGetJWKSAPI(t *testing.T) {
+	// the test server uses the symmetric PASETO key, which must never be published
+	server := newTestServer(t, nil)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var jwks token.JSONWebKeySet
+	err = json.Unmarshal(recorder.Body.Bytes(), &jwks)
+	require.NoError(t, err)
+	require.NotNil(t, jwks.Keys)
+	require.Empty(t, jwks.Keys)
+}
+
+func TestReloadTokenKeys(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	oldToken, _, err := server.tokenMaker.CreateToken("user", util.DepositorRole, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	config := server.config
+	config.TokenType = token.TypeJWTHS256
+	config.TokenSymetricKey = server.config.TokenSymetricKey + "new"
+
+	err = server.ReloadTokenKeys(config)
+	require.NoError(t, err)
+
+	// the single key config has no key ID, so the tokens of the replaced key are not valid anymore
+	_, err = server.tokenMaker.VerifyToken(oldToken)
+	require.Error(t, err)
+
+	newToken, _, err := server.tokenMaker.CreateToken("user", util.DepositorRole, token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	_, err = server.tokenMaker.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	// invalid config keeps the current key
+	config.TokenSymetricKey = "short"
+	err = server.ReloadTokenKeys(config)
+	require.Error(t, err)
+
+	_, err = server.tokenMaker.VerifyToken(newToken)
+	require.NoError(t, err)
+}
@@ -5,17 +5,61 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fx"
 	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	// recipient, exactly one of ToAccountID, ToUsername and ToEmail is required,
+	// the username or email is resolved to the account of the user in the to currency
+	ToAccountID int64  `json:"to_account_id" binding:"omitempty,min=1"`
+	ToUsername  string `json:"to_username" binding:"omitempty,alphanum"`
+	ToEmail     string `json:"to_email" binding:"omitempty,email"`
+	// amount in the currency of the from account
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+	// currency of both accounts, cross-currency transfers set FromCurrency and ToCurrency instead
+	Currency     string `json:"currency" binding:"omitempty,currency"`
+	FromCurrency string `json:"from_currency" binding:"omitempty,currency"`
+	ToCurrency   string `json:"to_currency" binding:"omitempty,currency"`
+	// optional quote with the locked rate, see POST /fx/quotes
+	QuoteID string `json:"quote_id" binding:"omitempty,uuid"`
+}
+
+// currencies returns the currencies of the from and to accounts
+func (req transferRequest) currencies() (string, string, error) {
+	switch {
+	case req.FromCurrency == "" && req.ToCurrency == "":
+		if req.Currency == "" {
+			return "", "", errors.New("currency or from_currency and to_currency are required")
+		}
+		return req.Currency, req.Currency, nil
+	case req.Currency != "":
+		return "", "", errors.New("currency can't be combined with from_currency and to_currency")
+	case req.FromCurrency == "" || req.ToCurrency == "":
+		return "", "", errors.New("both from_currency and to_currency are required")
+	}
+
+	return req.FromCurrency, req.ToCurrency, nil
+}
+
+// validateRecipient checks that the recipient is given exactly once
+func (req transferRequest) validateRecipient() error {
+	recipients := 0
+	for _, given := range []bool{req.ToAccountID != 0, req.ToUsername != "", req.ToEmail != ""} {
+		if given {
+			recipients++
+		}
+	}
+	if recipients != 1 {
+		return errors.New("exactly one of to_account_id, to_username and to_email is required")
+	}
+	return nil
 }
 
 // Create Transfer Handler
@@ -27,36 +71,118 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	fromCurrency, toCurrency, err := req.currencies()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := req.validateRecipient(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// opakovaný request se stejným Idempotency-Key vrátí uloženou odpověď a převod se neprovede znovu
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, fromCurrency)
 	if !valid {
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	role, valid := server.authorizeMember(ctx, fromAccount, util.CanInitiateTransfers, "authenticated user can't send transfers from the account")
+	if !valid {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccountID := req.ToAccountID
+	var recipient *recipientResponse
+	if toAccountID == 0 {
+		toAccount, user, valid := server.resolveRecipient(ctx, req.ToUsername, req.ToEmail, toCurrency)
+		if !valid {
+			return
+		}
+		toAccountID = toAccount.ID
+		rsp := newRecipientResponse(user, toAccount)
+		recipient = &rsp
+	}
+
+	_, valid = server.validAccount(ctx, toAccountID, toCurrency)
 
 	if !valid {
 		return
 	}
 
+	rate, valid := server.transferRate(ctx, authPayload, req.QuoteID, fromCurrency, toCurrency)
+	if !valid {
+		return
+	}
+
+	toAmount, err := fx.Convert(req.Amount, rate.Rate)
+	if err != nil {
+		if errors.Is(err, fx.ErrAmountTooSmall) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// příjemce zadaný jménem nebo emailem nesmí v odpovědi vidět účet příjemce, ani v uložené odpovědi
+	if recipient != nil && idempotency != nil {
+		idempotency.Response = func(result interface{}) interface{} {
+			return newRecipientTransferResponse(result.(db.TransferTxResult), *recipient)
+		}
+	}
+
 	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       toAccountID,
+		Amount:            req.Amount,
+		ToAmount:          toAmount,
+		ExchangeRate:      rate.Rate,
+		Idempotency:       idempotency,
+		Limits:            server.transferLimits(),
+		ApprovalThreshold: server.config.TransferApprovalThreshold,
+		// převody člena, který potřebuje schválení, čekají na vlastníka účtu bez ohledu na částku
+		RequireApproval: role == util.MemberNeedsApproval,
+		ChargeFee:       true,
+		// převod se počítá do limitů člena, který ho posílá, ne do limitů vlastníka účtu
+		InitiatedBy: authPayload.Username,
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyUsed) {
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+			return
+		}
+		// na účtu není dost peněz, request je v pořádku, ale nejde ho provést
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferLimitExceeded) {
+			ctx.JSON(http.StatusForbidden, errorCodeResponse(transferLimitExceededCode, err))
+			return
+		}
+		// účet mohl banker zmrazit nebo vlastník uzavřít až po kontrole ve validAccount
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
+	if recipient != nil {
+		ctx.JSON(http.StatusOK, newRecipientTransferResponse(result, *recipient))
+		return
+	}
 	ctx.JSON(http.StatusOK, result)
 }
 
@@ -64,6 +190,40 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 // is same.
 
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.activeAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// activeAccount returns the account if it exists and money can be moved from or to it by a transfer
+func (server *Server) activeAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, valid := server.activeLedgerAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	// hotovostní účty systému se mění jen vklady a výběry
+	if account.Owner == util.SystemUser {
+		err := fmt.Errorf("account [%d] is a system account", accountID)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// activeLedgerAccount returns the account if it exists and is active like activeAccount, but it accepts
+// the system accounts too, e.g. the cash account which receives a withdrawal
+func (server *Server) activeLedgerAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 
 	if err != nil {
@@ -76,11 +236,176 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		return account, false
 	}
 
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	if account.Status != util.AccountActive {
+		err := fmt.Errorf("account [%d] is %s", accountID, account.Status)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
 		return account, false
 	}
 
 	return account, true
 }
+
+// statuses of the posted transfers in the responses, they are derived from the reversed amount,
+// the other transfers have the status from the database
+const (
+	transferCompleted         = "completed"
+	transferPartiallyReversed = "partially_reversed"
+	transferReversed          = "reversed"
+)
+
+type transferResponse struct {
+	ID             int64     `json:"id"`
+	FromAccountID  int64     `json:"from_account_id"`
+	ToAccountID    int64     `json:"to_account_id"`
+	Amount         int64     `json:"amount"`
+	ToAmount       int64     `json:"to_amount"`
+	ExchangeRate   string    `json:"exchange_rate"`
+	CreatedAt      time.Time `json:"created_at"`
+	Status         string    `json:"status"`
+	ReversedAmount int64     `json:"reversed_amount"`
+	// ID of the reversed transfer if the transfer is a reversal
+	ReversalOf *int64 `json:"reversal_of,omitempty"`
+	// IDs of the reversals of the transfer
+	Reversals []int64 `json:"reversals"`
+	// who and when approved, rejected or cancelled the pending transfer
+	DecidedBy *string    `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	// the transfer of a member who needs approval and the owner or co-owner who authorized it
+	MemberApproval bool    `json:"member_approval"`
+	AuthorizedBy   *string `json:"authorized_by,omitempty"`
+}
+
+func newTransferResponse(transfer db.Transfer, reversals []db.Transfer) transferResponse {
+	rsp := transferResponse{
+		ID:             transfer.ID,
+		FromAccountID:  transfer.FromAccountID,
+		ToAccountID:    transfer.ToAccountID,
+		Amount:         transfer.Amount,
+		ToAmount:       transfer.ToAmount,
+		ExchangeRate:   transfer.ExchangeRate,
+		CreatedAt:      transfer.CreatedAt,
+		Status:         transferCompleted,
+		ReversedAmount: transfer.ReversedAmount,
+		Reversals:      make([]int64, len(reversals)),
+		MemberApproval: transfer.MemberApproval,
+	}
+
+	switch {
+	case transfer.Status != util.TransferPosted:
+		rsp.Status = transfer.Status
+	case transfer.ReversedAmount == transfer.ToAmount:
+		rsp.Status = transferReversed
+	case transfer.ReversedAmount > 0:
+		rsp.Status = transferPartiallyReversed
+	}
+	if transfer.ReversalOf.Valid {
+		rsp.ReversalOf = &transfer.ReversalOf.Int64
+	}
+	if transfer.DecidedBy.Valid {
+		rsp.DecidedBy = &transfer.DecidedBy.String
+	}
+	if transfer.DecidedAt.Valid {
+		rsp.DecidedAt = &transfer.DecidedAt.Time
+	}
+	if transfer.AuthorizedBy.Valid {
+		rsp.AuthorizedBy = &transfer.AuthorizedBy.String
+	}
+	for i, reversal := range reversals {
+		rsp.Reversals[i] = reversal.ID
+	}
+
+	return rsp
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer returns the transfer together with its reversals, bankers can get any transfer
+// and depositors only the transfers from or to the accounts they are members of
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole {
+		owns, err := server.ownsTransfer(ctx, authPayload.Username, transfer)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !owns {
+			err := errors.New("transfer doesn't belong to the authenticated user")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	}
+
+	reversals, err := server.store.ListTransferReversals(ctx, sql.NullInt64{Int64: transfer.ID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(transfer, reversals))
+}
+
+// ownsTransfer returns true if the user is a member of the from or the to account of the transfer
+func (server *Server) ownsTransfer(ctx *gin.Context, username string, transfer db.Transfer) (bool, error) {
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return false, err
+		}
+		role, err := server.accountMemberRole(ctx, account, username)
+		if err != nil {
+			return false, err
+		}
+		if util.CanViewAccount(role) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type listTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+	// optional, e.g. pending lists the transfers waiting for the approval
+	Status string `form:"status" binding:"omitempty,oneof=pending posted rejected cancelled"`
+}
+
+// listTransfers lists the transfers of all accounts, only bankers can do it
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfers, err := server.store.ListAllTransfers(ctx, db.ListAllTransfersParams{
+		Status: sql.NullString{String: req.Status, Valid: req.Status != ""},
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type pendingTransferURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// approveTransfer posts the entries of the pending transfer, only bankers can do it
+func (server *Server) approveTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.pendingTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	// účty mohly být od vytvoření převodu zmrazené
+	if _, valid := server.activeAccount(ctx, transfer.FromAccountID); !valid {
+		return
+	}
+	// čekající výběr člena jde na hotovostní účet systému
+	if _, valid := server.activeLedgerAccount(ctx, transfer.ToAccountID); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.ApproveTransferTx(ctx, db.ApproveTransferTxParams{
+		TransferID: transfer.ID,
+		ApprovedBy: authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTransferNotPending), errors.Is(err, db.ErrTransferNotAuthorized):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// rejectTransfer rejects the pending transfer and releases its money, only bankers can do it
+func (server *Server) rejectTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.pendingTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	server.decideTransfer(ctx, transfer, util.TransferRejected)
+}
+
+// authorizeTransfer authorizes the pending transfer of a member who needs approval, only the owner and co-owners
+// of the from account can do it. Transfers over the approval threshold then still wait for a banker.
+func (server *Server) authorizeTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.memberPendingTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.AuthorizeTransferTx(ctx, db.AuthorizeTransferTxParams{
+		TransferID:        transfer.ID,
+		AuthorizedBy:      authPayload.Username,
+		ApprovalThreshold: server.config.TransferApprovalThreshold,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTransferNotPending), errors.Is(err, db.ErrTransferNotAwaitingMember):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// declineTransfer rejects the pending transfer of a member who needs approval and releases its money,
+// only the owner and co-owners of the from account can do it
+func (server *Server) declineTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.memberPendingTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	server.decideTransfer(ctx, transfer, util.TransferRejected)
+}
+
+// cancelTransfer cancels the pending transfer and releases its money, bankers can cancel any pending transfer,
+// the owner and co-owners any transfer from their account and the other members only the transfers they sent
+func (server *Server) cancelTransfer(ctx *gin.Context) {
+	var uri pendingTransferURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.pendingTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole {
+		fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		// člen bez práva spravovat účet nesmí rušit převody ostatních členů
+		canCancel := func(role string) bool {
+			return util.CanManageAccount(role) ||
+				util.CanInitiateTransfers(role) && transfer.InitiatedBy.String == authPayload.Username
+		}
+		if _, valid := server.authorizeMember(ctx, fromAccount, canCancel, "authenticated user can't cancel the transfer"); !valid {
+			return
+		}
+	}
+
+	server.decideTransfer(ctx, transfer, util.TransferCancelled)
+}
+
+// pendingTransfer returns the transfer if it exists and is still pending
+func (server *Server) pendingTransfer(ctx *gin.Context, transferID int64) (db.Transfer, bool) {
+	transfer, err := server.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return transfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+
+	if transfer.Status != util.TransferPending {
+		err := fmt.Errorf("%w: transfer %d is %s", db.ErrTransferNotPending, transfer.ID, transfer.Status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return transfer, false
+	}
+
+	return transfer, true
+}
+
+// memberPendingTransfer returns the pending transfer if it waits for the authorization of the account owner
+// and the authenticated user can authorize it, i.e. it is the owner or a co-owner of the from account
+func (server *Server) memberPendingTransfer(ctx *gin.Context, transferID int64) (db.Transfer, bool) {
+	transfer, valid := server.pendingTransfer(ctx, transferID)
+	if !valid {
+		return transfer, false
+	}
+
+	fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+	if _, valid := server.authorizeMember(ctx, fromAccount, util.CanManageAccount, "authenticated user can't authorize transfers from the account"); !valid {
+		return transfer, false
+	}
+
+	// ostatní čekající převody schvaluje jen bankéř
+	if !transfer.MemberApproval || transfer.AuthorizedBy.Valid {
+		err := fmt.Errorf("%w: transfer %d", db.ErrTransferNotAwaitingMember, transfer.ID)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return transfer, false
+	}
+
+	return transfer, true
+}
+
+// decideTransfer moves the pending transfer to the final status without posting its entries
+func (server *Server) decideTransfer(ctx *gin.Context, transfer db.Transfer, status string) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	decided, err := server.store.DecideTransferTx(ctx, db.DecideTransferParams{
+		Status:    status,
+		DecidedBy: sql.NullString{String: authPayload.Username, Valid: true},
+		ID:        transfer.ID,
+	})
+	if err != nil {
+		// update mění jen čekající převody, takže ho mezitím rozhodl někdo jiný
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("%w: transfer %d was decided concurrently", db.ErrTransferNotPending, transfer.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(decided, nil))
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+type transferBatchItemResponse struct {
+	Position    int32  `json:"position"`
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Status      string `json:"status"`
+	TransferID  *int64 `json:"transfer_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func newTransferBatchItemResponse(item db.TransferBatchItem) transferBatchItemResponse {
+	rsp := transferBatchItemResponse{
+		Position:    item.Position,
+		ToAccountID: item.ToAccountID,
+		Amount:      item.Amount,
+		Status:      item.Status,
+		Error:       item.Error,
+	}
+	if item.TransferID.Valid {
+		rsp.TransferID = &item.TransferID.Int64
+	}
+	return rsp
+}
+
+// transferBatchResponse is the result of the executed batch, it contains the results of all items
+type transferBatchResponse struct {
+	Batch       db.TransferBatch            `json:"batch"`
+	FromAccount db.Account                  `json:"from_account"`
+	Items       []transferBatchItemResponse `json:"items"`
+}
+
+func newTransferBatchResponse(result db.TransferBatchTxResult) transferBatchResponse {
+	rsp := transferBatchResponse{
+		Batch:       result.Batch,
+		FromAccount: result.FromAccount,
+		Items:       make([]transferBatchItemResponse, len(result.Items)),
+	}
+	for i, item := range result.Items {
+		rsp.Items[i] = newTransferBatchItemResponse(item)
+	}
+	return rsp
+}
+
+type transferBatchItemRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type createTransferBatchRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Mode          string `json:"mode" binding:"required,oneof=all_or_nothing best_effort"`
+	// dávka se provede v jedné transakci, větší výplaty je potřeba rozdělit
+	Items []transferBatchItemRequest `json:"items" binding:"required,min=1,max=10000,dive"`
+}
+
+// createTransferBatch executes many transfers from an account which the authenticated user can send transfers from
+// at once, e.g. a payroll.
+// Every item is checked like a separate transfer, the failed items are reported in the response.
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	var req createTransferBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	idempotency, ok := server.idempotentRequest(ctx, req)
+	if !ok {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	role, valid := server.authorizeMember(ctx, fromAccount, util.CanInitiateTransfers, "authenticated user can't send transfers from the account")
+	if !valid {
+		return
+	}
+
+	// uložená odpověď musí být stejná jako ta, kterou handler vrací
+	if idempotency != nil {
+		idempotency.Response = func(result interface{}) interface{} {
+			return newTransferBatchResponse(result.(db.TransferBatchTxResult))
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.TransferBatchTxParams{
+		Owner:             authPayload.Username,
+		FromAccountID:     fromAccount.ID,
+		Mode:              req.Mode,
+		Items:             make([]db.TransferBatchItemParams, len(req.Items)),
+		Idempotency:       idempotency,
+		Limits:            server.transferLimits(),
+		ApprovalThreshold: server.config.TransferApprovalThreshold,
+		ChargeFee:         true,
+		// všechny položky člena, který potřebuje schválení, čekají na vlastníka účtu
+		RequireApproval: role == util.MemberNeedsApproval,
+	}
+	for i, item := range req.Items {
+		arg.Items[i] = db.TransferBatchItemParams{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+		}
+	}
+
+	result, err := server.store.TransferBatchTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyUsed) {
+			server.handleIdempotencyKeyUsed(ctx, idempotency)
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferBatchResponse(result))
+}
+
+type listTransferBatchesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+}
+
+// listTransferBatches lists the batches of the authenticated user from the newest one
+func (server *Server) listTransferBatches(ctx *gin.Context) {
+	var req listTransferBatchesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	batches, err := server.store.ListTransferBatches(ctx, db.ListTransferBatchesParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, batches)
+}
+
+type transferBatchURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// authorizedTransferBatch returns the batch from the URI if it belongs to the authenticated user,
+// bankers can view any batch
+func (server *Server) authorizedTransferBatch(ctx *gin.Context) (db.TransferBatch, bool) {
+	var uri transferBatchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.TransferBatch{}, false
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return batch, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return batch, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole && batch.Owner != authPayload.Username {
+		err := errors.New("transfer batch doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return batch, false
+	}
+
+	return batch, true
+}
+
+// getTransferBatch returns the status and the counts of the batch without its items
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	batch, valid := server.authorizedTransferBatch(ctx)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, batch)
+}
+
+type listTransferBatchItemsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=posted pending failed skipped"`
+	PageID int32  `form:"page_id" binding:"required,min=1"`
+	// dávka může mít tisíce položek, proto je stránka větší než u ostatních seznamů
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// listTransferBatchItems lists the results of the items of the batch in their order,
+// e.g. only the failed items to fix and send them again
+func (server *Server) listTransferBatchItems(ctx *gin.Context) {
+	var req listTransferBatchItemsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, valid := server.authorizedTransferBatch(ctx)
+	if !valid {
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, db.ListTransferBatchItemsParams{
+		BatchID: batch.ID,
+		Status:  sql.NullString{String: req.Status, Valid: req.Status != ""},
+		Limit:   req.PageSize,
+		Offset:  (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferBatchItemResponse, len(items))
+	for i, item := range items {
+		rsp[i] = newTransferBatchItemResponse(item)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
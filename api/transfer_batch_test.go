@@ -0,0 +1,382 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	employee1, _ := randomUser(t)
+	employee2, _ := randomUser(t)
+	member, _ := randomUser(t)
+
+	fromAccount := randomAccount(user.Username)
+	toAccount1 := randomAccount(employee1.Username)
+	toAccount2 := randomAccount(employee2.Username)
+
+	items := []gin.H{
+		{"to_account_id": toAccount1.ID, "amount": 100},
+		{"to_account_id": toAccount2.ID, "amount": 200},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchBestEffort,
+				"items":           items,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.TransferBatchTxResult{
+					Batch: db.TransferBatch{
+						ID:             1,
+						Owner:          user.Username,
+						FromAccountID:  fromAccount.ID,
+						Mode:           util.BatchBestEffort,
+						Status:         util.BatchPartiallyCompleted,
+						ItemCount:      2,
+						SucceededCount: 1,
+						FailedCount:    1,
+						TotalAmount:    100,
+					},
+					FromAccount: fromAccount,
+					Items: []db.TransferBatchItem{
+						{Position: 0, ToAccountID: toAccount1.ID, Amount: 100, Status: util.TransferPosted, TransferID: sql.NullInt64{Int64: 7, Valid: true}},
+						{Position: 1, ToAccountID: toAccount2.ID, Amount: 200, Status: util.BatchItemFailed, Error: db.ErrInsufficientFunds.Error()},
+					},
+				}
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().
+					TransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.TransferBatchTxParams) (db.TransferBatchTxResult, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, fromAccount.ID, arg.FromAccountID)
+						require.Equal(t, util.BatchBestEffort, arg.Mode)
+						require.Equal(t, []db.TransferBatchItemParams{
+							{ToAccountID: toAccount1.ID, Amount: 100},
+							{ToAccountID: toAccount2.ID, Amount: 200},
+						}, arg.Items)
+						require.Equal(t, db.NewDefaultTransferLimits(util.Config{}), arg.Limits)
+						return result, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.BatchPartiallyCompleted, rsp.Batch.Status)
+				require.Len(t, rsp.Items, 2)
+				require.Equal(t, int64(7), *rsp.Items[0].TransferID)
+				require.Nil(t, rsp.Items[1].TransferID)
+				require.Equal(t, db.ErrInsufficientFunds.Error(), rsp.Items[1].Error)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           items,
+			},
+			username: employee1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NeedsApprovalMember",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           items,
+			},
+			username: member.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: fromAccount.ID, Username: member.Username})).
+					Times(1).
+					Return(db.AccountMember{AccountID: fromAccount.ID, Username: member.Username, Role: util.MemberNeedsApproval}, nil)
+				store.EXPECT().
+					TransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.TransferBatchTxParams) (db.TransferBatchTxResult, error) {
+						require.Equal(t, member.Username, arg.Owner)
+						require.True(t, arg.RequireApproval)
+						return db.TransferBatchTxResult{Batch: db.TransferBatch{ID: 1, Owner: member.Username}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "FrozenFromAccount",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           items,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				frozenAccount := fromAccount
+				frozenAccount.Status = util.AccountFrozen
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMode",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            "sometimes",
+				"items":           items,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoItems",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           []gin.H{},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidItemAmount",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           []gin.H{{"to_account_id": toAccount1.ID, "amount": -1}},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        fromAccount.Currency,
+				"mode":            util.BatchAllOrNothing,
+				"items":           items,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferBatchTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer-batches", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListTransferBatchItemsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	batch := db.TransferBatch{
+		ID:    util.RandomInt(1, 1000),
+		Owner: user.Username,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OnlyFailed",
+			query:    "status=failed&page_id=2&page_size=100",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListTransferBatchItemsParams{
+					BatchID: batch.ID,
+					Status:  sql.NullString{String: util.BatchItemFailed, Valid: true},
+					Limit:   100,
+					Offset:  100,
+				}
+				items := []db.TransferBatchItem{
+					{BatchID: batch.ID, Position: 150, Status: util.BatchItemFailed, Error: "account [1] is frozen"},
+				}
+
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(arg)).Times(1).Return(items, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []transferBatchItemResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp, 1)
+				require.Equal(t, int32(150), rsp[0].Position)
+			},
+		},
+		{
+			name:     "Banker",
+			query:    "page_id=1&page_size=10",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferBatchItem{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			query:    "page_id=1&page_size=10",
+			username: other.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			query:    "page_id=1&page_size=10",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "PageTooLarge",
+			query:    "page_id=1&page_size=101",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer-batches/%d/items?%s", batch.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
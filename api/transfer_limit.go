@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
+	"github.com/karlib/simple_bank/util"
+)
+
+// error code returned together with the error when the transfer doesn't fit into the transfer limits
+const transferLimitExceededCode = "transfer_limit_exceeded"
+
+// transferLimits returns the default transfer limits of the users in every currency from the config
+func (server *Server) transferLimits() db.DefaultTransferLimits {
+	return db.NewDefaultTransferLimits(server.config)
+}
+
+type getAccountLimitsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type limitResponse struct {
+	// zero is unlimited
+	AccountLimit int64 `json:"account_limit"`
+	UserLimit    int64 `json:"user_limit"`
+	AccountUsed  int64 `json:"account_used"`
+	UserUsed     int64 `json:"user_used"`
+	// null if neither the account nor the user has the limit
+	Remaining *int64 `json:"remaining"`
+}
+
+func newLimitResponse(usage db.LimitUsage) limitResponse {
+	rsp := limitResponse{
+		AccountLimit: usage.AccountLimit,
+		UserLimit:    usage.UserLimit,
+		AccountUsed:  usage.AccountUsed,
+		UserUsed:     usage.UserUsed,
+	}
+	if remaining, limited := usage.Remaining(); limited {
+		rsp.Remaining = &remaining
+	}
+	return rsp
+}
+
+type accountLimitsResponse struct {
+	AccountID      int64         `json:"account_id"`
+	Currency       string        `json:"currency"`
+	PerTransaction limitResponse `json:"per_transaction"`
+	Daily          limitResponse `json:"daily"`
+	Monthly        limitResponse `json:"monthly"`
+	// the largest amount which can be transferred from the account now, null if it is unlimited
+	Remaining *int64 `json:"remaining"`
+}
+
+// getAccountLimits returns the transfer limits of the account and how much the authenticated member
+// can still transfer from it
+func (server *Server) getAccountLimits(ctx *gin.Context) {
+	var req getAccountLimitsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.authorizedAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	// limity uživatele patří členovi, který převody posílá, bankéř a prohlížející členové vidí limity vlastníka
+	username := account.Owner
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	role, err := server.accountMemberRole(ctx, account, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if util.CanInitiateTransfers(role) {
+		username = authPayload.Username
+	}
+
+	allowance, err := server.store.GetTransferAllowance(ctx, account, username, server.transferLimits())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := accountLimitsResponse{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		PerTransaction: newLimitResponse(allowance.PerTransaction),
+		Daily:          newLimitResponse(allowance.Daily),
+		Monthly:        newLimitResponse(allowance.Monthly),
+	}
+	for _, limit := range []limitResponse{rsp.PerTransaction, rsp.Daily, rsp.Monthly} {
+		if limit.Remaining != nil && (rsp.Remaining == nil || *limit.Remaining < *rsp.Remaining) {
+			rsp.Remaining = limit.Remaining
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transferLimitResponse struct {
+	Owner          string    `json:"owner,omitempty"`
+	AccountID      int64     `json:"account_id,omitempty"`
+	Currency       string    `json:"currency"`
+	PerTransaction int64     `json:"per_transaction"`
+	Daily          int64     `json:"daily"`
+	Monthly        int64     `json:"monthly"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func newTransferLimitResponse(limit db.TransferLimit) transferLimitResponse {
+	return transferLimitResponse{
+		Owner:          limit.Owner.String,
+		AccountID:      limit.AccountID.Int64,
+		Currency:       limit.Currency,
+		PerTransaction: limit.PerTransaction,
+		Daily:          limit.Daily,
+		Monthly:        limit.Monthly,
+		UpdatedAt:      limit.UpdatedAt,
+	}
+}
+
+type setAccountLimitsURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// limity jsou v nejmenších jednotkách měny, nula znamená bez limitu
+type setAccountLimitsRequest struct {
+	PerTransaction int64 `json:"per_transaction" binding:"min=0"`
+	Daily          int64 `json:"daily" binding:"min=0"`
+	Monthly        int64 `json:"monthly" binding:"min=0"`
+}
+
+// setAccountLimits sets the transfer limits of the account, only bankers can do it
+func (server *Server) setAccountLimits(ctx *gin.Context) {
+	var uri setAccountLimitsURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setAccountLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.UpsertAccountTransferLimit(ctx, db.UpsertAccountTransferLimitParams{
+		AccountID:      sql.NullInt64{Int64: account.ID, Valid: true},
+		Currency:       account.Currency,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferLimitResponse(limit))
+}
+
+type setUserLimitsURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type setUserLimitsRequest struct {
+	Currency       string `json:"currency" binding:"required,currency"`
+	PerTransaction int64  `json:"per_transaction" binding:"min=0"`
+	Daily          int64  `json:"daily" binding:"min=0"`
+	Monthly        int64  `json:"monthly" binding:"min=0"`
+}
+
+// setUserLimits sets the transfer limits of all accounts of the user in one currency, they replace
+// the default limits from the config, only bankers can do it
+func (server *Server) setUserLimits(ctx *gin.Context) {
+	var uri setUserLimitsURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setUserLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetUser(ctx, uri.Username); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.UpsertUserTransferLimit(ctx, db.UpsertUserTransferLimitParams{
+		Owner:          sql.NullString{String: uri.Username, Valid: true},
+		Currency:       req.Currency,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferLimitResponse(limit))
+}
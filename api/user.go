@@ -2,11 +2,15 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/token"
 	"github.com/karlib/simple_bank/util"
 	"github.com/lib/pq"
 )
@@ -22,6 +26,7 @@ type createUserRequest struct {
 
 type userResponse struct {
 	Username          string    `json:"username"`
+	Role              string    `json:"role"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
@@ -32,6 +37,7 @@ type userResponse struct {
 func newUserResponse(user db.User) userResponse{
 	return userResponse{
 		Username:          user.Username,
+		Role:              user.Role,
 		FullName:          user.FullName,
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
@@ -88,8 +94,12 @@ type loginUserRequest struct {
 }
 
 type loginUserResponse struct {
-	AccessToken string `json:"access_token`
-	User userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
@@ -123,21 +133,145 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	}
 
 	// So now i already know that the provided password is correct so i can screate assessToken for user
-	accsessToken, err := server.tokenMaker.CreateToken(
+	accsessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
 		server.config.AccessTokenDuration,
 	)
 	// If the unexpected error occurs than the server will send response with StatusInternalServerError
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
+	// The refresh token lives much longer than the access token, it is used only
+	// to get a new access token through /tokens/renew_access
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeRefresh,
+		server.config.RefreshTokenDuration,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The ID of the refresh token payload is used as the session ID, so the session
+	// can be found again when the client wants to renew the access token
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
 	rsp := loginUserResponse{
-		AccessToken: accsessToken,
-		User: newUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accsessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
 	}
 
 	// response is sended to the client
 	ctx.JSON(http.StatusOK, rsp)
-}
\ No newline at end of file
+}
+
+type logoutUserRequest struct {
+	// optional, if it is provided the session of this refresh token is blocked as well, otherwise the refresh token
+	// can still renew the access token until its session expires, but it can never be used as the access token
+	RefreshToken string `json:"refresh_token"`
+}
+
+// logoutUser revokes the access token used for this request
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	// tělo requestu je nepovinné, takže prázdné tělo (io.EOF) není chyba
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.RefreshToken != "" {
+		refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if err := refreshPayload.CheckType(token.TokenTypeRefresh); err != nil {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if refreshPayload.Username != authPayload.Username {
+			err := errors.New("refresh token doesn't belong to the authenticated user")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		err = server.revocations.Revoke(ctx, refreshPayload)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// the session ID is the same as the ID of the refresh token payload
+		err = server.store.BlockSession(ctx, refreshPayload.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	err := server.revocations.Revoke(ctx, authPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+type revokeUserTokensRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// revokeUserTokens is a banker endpoint which revokes every token issued to the user until now,
+// it is used when the user credentials are leaked
+func (server *Server) revokeUserTokens(ctx *gin.Context) {
+	var req revokeUserTokensRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.revocations.RevokeAll(ctx, req.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
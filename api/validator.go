@@ -13,4 +13,48 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
+// validRecurrence is registred as the recurrence tag of the scheduled transfers
+var validRecurrence validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if recurrence, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedRecurrence(recurrence)
+	}
+
+	return false
+}
+
+// validAccountType is registred as the account_type tag of the new accounts
+var validAccountType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if accountType, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedAccountType(accountType)
+	}
+
+	return false
+}
+
+// validDayCount is registred as the day_count tag of the interest rates
+var validDayCount validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if dayCount, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedDayCount(dayCount)
+	}
+
+	return false
+}
+
+// validFeeKind is registred as the fee_kind tag of the fee schedules and waivers
+var validFeeKind validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if kind, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedFeeKind(kind)
+	}
+
+	return false
+}
+
+// validFeeMethod is registred as the fee_method tag of the fee schedules
+var validFeeMethod validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if method, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedFeeMethod(method)
+	}
+
+	return false
+}
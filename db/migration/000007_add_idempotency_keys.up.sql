@@ -0,0 +1,20 @@
+CREATE TABLE "idempotency_keys" (
+  "username" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_status" int NOT NULL,
+  "response_body" json NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL,
+  PRIMARY KEY ("username", "key")
+);
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
+
+COMMENT ON COLUMN "idempotency_keys"."key" IS 'the value of the Idempotency-Key header, unique per user';
+
+COMMENT ON COLUMN "idempotency_keys"."request_hash" IS 'sha256 of the route and the request body';
+
+COMMENT ON COLUMN "idempotency_keys"."response_body" IS 'json keeps the stored response byte for byte, jsonb would reorder it';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,26 @@
+-- vklady a výběry jsou zaúčtované proti systémovým účtům, jejich smazáním by zůstaly zůstatky klientů bez protistrany,
+-- takže se migrace odmítne dřív, než cokoliv změní
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM "entries"
+    WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system')
+  ) OR EXISTS (
+    SELECT 1 FROM "transfers"
+    WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system')
+       OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system')
+  ) THEN
+    RAISE EXCEPTION 'cannot remove the system accounts: deposits or withdrawals were already posted to them';
+  END IF;
+END
+$$;
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_non_negative";
+
+COMMENT ON COLUMN "accounts"."balance" IS NULL;
+
+DELETE FROM "accounts" WHERE "owner" = 'system';
+
+DELETE FROM "users" WHERE "username" = 'system';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_non_negative" CHECK ("balance" >= 0);
@@ -0,0 +1,12 @@
+-- systémový uživatel se nemůže přihlásit, prázdný hash neodpovídá žádnému heslu
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('system', '', 'Simple Bank system ledger', 'system@simple-bank.invalid');
+
+INSERT INTO "accounts" ("owner", "balance", "currency")
+VALUES ('system', 0, 'USD'), ('system', 0, 'EUR'), ('system', 0, 'CAD');
+
+ALTER TABLE "accounts" DROP CONSTRAINT "balance_non_negative";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_non_negative" CHECK ("balance" >= 0 OR "owner" = 'system');
+
+COMMENT ON COLUMN "accounts"."balance" IS 'the cash accounts of the system user are negative by the money deposited to the bank';
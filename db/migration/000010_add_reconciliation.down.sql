@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "reconciliation_issues";
+
+DROP TABLE IF EXISTS "reconciliation_runs";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "transfer_id";
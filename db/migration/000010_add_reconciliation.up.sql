@@ -0,0 +1,49 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+-- převod i jeho záznamy vznikly v jedné transakci, takže mají stejné created_at (now() je čas začátku transakce)
+UPDATE "entries"
+SET "transfer_id" = "transfers"."id"
+FROM "transfers"
+WHERE "entries"."transfer_id" IS NULL
+  AND "entries"."created_at" = "transfers"."created_at"
+  AND (
+    ("entries"."account_id" = "transfers"."from_account_id" AND "entries"."amount" = -"transfers"."amount")
+    OR ("entries"."account_id" = "transfers"."to_account_id" AND "entries"."amount" = "transfers"."to_amount")
+  );
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer which created the entry, entries without it are reported by the reconciliation';
+
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "status" varchar NOT NULL DEFAULT 'running',
+  "accounts_checked" bigint NOT NULL DEFAULT 0,
+  "transfers_checked" bigint NOT NULL DEFAULT 0,
+  "issues_found" bigint NOT NULL DEFAULT 0,
+  "error" varchar NOT NULL DEFAULT '',
+  "started_at" timestamptz NOT NULL DEFAULT (now()),
+  "finished_at" timestamptz
+);
+
+CREATE TABLE "reconciliation_issues" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "details" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "reconciliation_issues" ("run_id");
+
+COMMENT ON COLUMN "reconciliation_runs"."status" IS 'running, ok, drift or failed';
+
+COMMENT ON COLUMN "reconciliation_issues"."kind" IS 'balance_drift, orphan_entries or unbalanced_transfer';
+
+ALTER TABLE "reconciliation_issues" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id") ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "reversed_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "reversed_amount_within_amount" CHECK ("reversed_amount" >= 0 AND "reversed_amount" <= "to_amount");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversal_of");
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'the reversed transfer, reversals go from its to_account back to its from_account';
+
+COMMENT ON COLUMN "transfers"."reversed_amount" IS 'sum of the reversals of the transfer, in the currency of to_account';
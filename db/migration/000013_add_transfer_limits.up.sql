@@ -0,0 +1,33 @@
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar,
+  "account_id" bigint,
+  "currency" varchar NOT NULL,
+  "per_transaction" bigint NOT NULL DEFAULT 0 CHECK ("per_transaction" >= 0),
+  "daily" bigint NOT NULL DEFAULT 0 CHECK ("daily" >= 0),
+  "monthly" bigint NOT NULL DEFAULT 0 CHECK ("monthly" >= 0),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_limit_scope" CHECK (("owner" IS NULL) <> ("account_id" IS NULL))
+);
+
+-- limit uživatele platí pro všechny jeho účty v jedné měně, limit účtu jen pro ten účet
+CREATE UNIQUE INDEX ON "transfer_limits" ("owner", "currency") WHERE "owner" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+-- limity se počítají ze součtu odchozích převodů od začátku dne a měsíce
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'set for the limits of the user, the limits of an account have it null';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'set for the limits of the account, the limits of a user have it null';
+
+COMMENT ON COLUMN "transfer_limits"."per_transaction" IS 'in minor units of the currency, zero is unlimited';
+
+COMMENT ON COLUMN "transfer_limits"."daily" IS 'sum of the outgoing transfers since the start of the UTC day, zero is unlimited';
+
+COMMENT ON COLUMN "transfer_limits"."monthly" IS 'sum of the outgoing transfers since the start of the UTC month, zero is unlimited';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "transfers_from_account_id_idx1";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "decided_at";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "decided_by";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "status";
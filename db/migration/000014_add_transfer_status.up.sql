@@ -0,0 +1,14 @@
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'posted';
+
+ALTER TABLE "transfers" ADD COLUMN "decided_by" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "decided_at" timestamptz;
+
+-- čekající převody blokují peníze na účtu, při každém převodu se sčítají
+CREATE INDEX ON "transfers" ("from_account_id") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "transfers"."status" IS 'pending, posted, rejected or cancelled, only posted transfers have entries';
+
+COMMENT ON COLUMN "transfers"."decided_by" IS 'the user who approved, rejected or cancelled the pending transfer';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("decided_by") REFERENCES "users" ("username");
@@ -0,0 +1,32 @@
+CREATE TABLE "account_holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "description" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'active',
+  "expires_at" timestamptz NOT NULL,
+  "created_by" varchar NOT NULL,
+  "transfer_id" bigint,
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "closed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- aktivní blokace se sčítají při každém převodu z účtu
+CREATE INDEX ON "account_holds" ("account_id") WHERE "status" = 'active';
+
+CREATE INDEX ON "account_holds" ("expires_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "account_holds"."status" IS 'active, captured, released or expired, only active holds reduce the available balance';
+
+COMMENT ON COLUMN "account_holds"."expires_at" IS 'the hold stops reducing the available balance at this time even before it is marked as expired';
+
+COMMENT ON COLUMN "account_holds"."transfer_id" IS 'the transfer which captured the hold';
+
+COMMENT ON COLUMN "account_holds"."captured_amount" IS 'the captured part of the amount, the rest is released';
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "account_holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,47 @@
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL,
+  "item_count" integer NOT NULL,
+  "succeeded_count" integer NOT NULL,
+  "failed_count" integer NOT NULL,
+  "total_amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "id" bigserial PRIMARY KEY,
+  "batch_id" bigint NOT NULL,
+  "position" integer NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT ''
+);
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+CREATE UNIQUE INDEX ON "transfer_batch_items" ("batch_id", "position");
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'all_or_nothing or best_effort';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'completed, partially_completed or failed';
+
+COMMENT ON COLUMN "transfer_batches"."total_amount" IS 'sum of the amounts of the succeeded items';
+
+COMMENT ON COLUMN "transfer_batch_items"."position" IS 'index of the item in the request, starting from 0';
+
+COMMENT ON COLUMN "transfer_batch_items"."to_account_id" IS 'not a foreign key, the item with a missing account is only failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'posted, pending, failed or skipped';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
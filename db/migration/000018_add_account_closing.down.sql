@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "owner_currency_key";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+COMMENT ON COLUMN "accounts"."status" IS 'active or frozen';
@@ -0,0 +1,6 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+-- uzavřený účet nebrání založení nového účtu ve stejné měně
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed, only active accounts can send and receive money';
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_non_negative";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "balance_non_negative" CHECK ("balance" >= 0 OR "owner" = 'system');
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "overdraft_checking_only";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "max_monthly_transfers";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "overdraft_limit";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "type";
+
+COMMENT ON COLUMN "accounts"."balance" IS 'the cash accounts of the system user are negative by the money deposited to the bank';
@@ -0,0 +1,24 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0 CHECK ("overdraft_limit" >= 0);
+
+ALTER TABLE "accounts" ADD COLUMN "max_monthly_transfers" integer NOT NULL DEFAULT 0 CHECK ("max_monthly_transfers" >= 0);
+
+-- do mínusu smí jít jen běžné účty, a to nejvýš o svůj povolený debet
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_checking_only" CHECK ("overdraft_limit" = 0 OR "type" = 'checking');
+
+ALTER TABLE "accounts" DROP CONSTRAINT "balance_non_negative";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_non_negative" CHECK ("balance" >= -"overdraft_limit" OR "owner" = 'system');
+
+DROP INDEX "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency", "type") WHERE "status" <> 'closed';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business';
+
+COMMENT ON COLUMN "accounts"."balance" IS 'the cash accounts of the system user are negative by the money deposited to the bank, checking accounts can be negative down to their overdraft limit';
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how much the balance of the checking account can go below zero, in minor units of the currency';
+
+COMMENT ON COLUMN "accounts"."max_monthly_transfers" IS 'maximum number of outgoing transfers since the start of the UTC month, zero is unlimited';
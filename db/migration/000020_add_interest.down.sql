@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "interest_postings";
+
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "interest_accrual_runs";
+
+DROP TABLE IF EXISTS "interest_rates";
+
+DELETE FROM "accounts" WHERE "owner" = 'system' AND "type" = 'interest_expense';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business';
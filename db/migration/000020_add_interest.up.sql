@@ -0,0 +1,77 @@
+CREATE TABLE "interest_rates" (
+  "account_type" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "annual_rate" numeric(18,8) NOT NULL CHECK ("annual_rate" >= 0),
+  "day_count" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_type", "currency")
+);
+
+CREATE TABLE "interest_accrual_runs" (
+  "accrual_date" date PRIMARY KEY,
+  "account_count" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_accruals" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate" numeric(18,8) NOT NULL,
+  "day_count" varchar NOT NULL,
+  "amount" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "month" date NOT NULL,
+  "accrued" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "carry" varchar NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+
+CREATE INDEX ON "interest_accruals" ("accrual_date");
+
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "month");
+
+-- úroky se vyplácí ze samostatného systémového účtu, aby šly odlišit od hotovosti
+INSERT INTO "accounts" ("owner", "balance", "currency", "type")
+VALUES ('system', 0, 'USD', 'interest_expense'), ('system', 0, 'EUR', 'interest_expense'), ('system', 0, 'CAD', 'interest_expense');
+
+INSERT INTO "interest_rates" ("account_type", "currency", "annual_rate", "day_count")
+VALUES ('savings', 'USD', 0.015, 'act/365'), ('savings', 'EUR', 0.01, '30/360'), ('savings', 'CAD', 0.0125, 'act/365');
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business, the system user has also interest_expense accounts';
+
+COMMENT ON COLUMN "interest_rates"."annual_rate" IS 'e.g. 0.015 is 1.5 % per year';
+
+COMMENT ON COLUMN "interest_rates"."day_count" IS 'act/365 or 30/360';
+
+COMMENT ON TABLE "interest_accrual_runs" IS 'the dates whose interest was already accrued for all accounts';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'balance at the end of the UTC day';
+
+COMMENT ON COLUMN "interest_accruals"."amount" IS 'interest of the day in minor units as an exact fraction, e.g. 123/73';
+
+COMMENT ON COLUMN "interest_postings"."month" IS 'first day of the month';
+
+COMMENT ON COLUMN "interest_postings"."accrued" IS 'interest accrued in the month together with the carry of the previous posting, exact fraction of minor units';
+
+COMMENT ON COLUMN "interest_postings"."amount" IS 'whole minor units paid to the account';
+
+COMMENT ON COLUMN "interest_postings"."carry" IS 'fraction of the accrued interest left for the next posting';
+
+COMMENT ON COLUMN "interest_postings"."transfer_id" IS 'transfer from the interest_expense account, null when nothing was paid';
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS "maintenance_fees";
+
+DROP TABLE IF EXISTS "fee_waivers";
+
+DROP TABLE IF EXISTS "fee_schedules";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee_of";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee_kind";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
+
+DELETE FROM "accounts" WHERE "owner" = 'system' AND "type" = 'fee_revenue';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business, the system user has also interest_expense accounts';
@@ -0,0 +1,81 @@
+CREATE TABLE "fee_schedules" (
+  "kind" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "flat_amount" bigint NOT NULL DEFAULT 0 CHECK ("flat_amount" >= 0),
+  "rate" numeric(18,8) NOT NULL DEFAULT 0 CHECK ("rate" >= 0),
+  "min_fee" bigint NOT NULL DEFAULT 0 CHECK ("min_fee" >= 0),
+  "max_fee" bigint NOT NULL DEFAULT 0 CHECK ("max_fee" >= 0),
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("kind", "currency")
+);
+
+CREATE TABLE "fee_waivers" (
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "waived_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "kind")
+);
+
+CREATE TABLE "maintenance_fees" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "month" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "waived" boolean NOT NULL DEFAULT false,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0 CHECK ("fee" >= 0);
+
+ALTER TABLE "transfers" ADD COLUMN "fee_kind" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "fee_of" bigint;
+
+CREATE UNIQUE INDEX ON "maintenance_fees" ("account_id", "month");
+
+CREATE INDEX ON "transfers" ("fee_of");
+
+-- poplatky se připisují na samostatný systémový účet, aby šly odlišit od hotovosti a úroků
+INSERT INTO "accounts" ("owner", "balance", "currency", "type")
+VALUES ('system', 0, 'USD', 'fee_revenue'), ('system', 0, 'EUR', 'fee_revenue'), ('system', 0, 'CAD', 'fee_revenue');
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business, the system user has also interest_expense and fee_revenue accounts';
+
+COMMENT ON COLUMN "fee_schedules"."kind" IS 'transfer or maintenance';
+
+COMMENT ON COLUMN "fee_schedules"."method" IS 'flat or percentage, maintenance fees are always flat';
+
+COMMENT ON COLUMN "fee_schedules"."rate" IS 'part of the transferred amount charged by the percentage method, e.g. 0.005 is 0.5 %';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'zero is unlimited';
+
+COMMENT ON COLUMN "fee_schedules"."min_balance" IS 'the maintenance fee is charged to the accounts with a lower balance at the end of the month';
+
+COMMENT ON COLUMN "fee_waivers"."kind" IS 'the user pays no fees of this kind';
+
+COMMENT ON COLUMN "maintenance_fees"."month" IS 'first day of the charged month';
+
+COMMENT ON COLUMN "maintenance_fees"."balance" IS 'balance at the end of the month';
+
+COMMENT ON COLUMN "maintenance_fees"."amount" IS 'charged amount, lower than the fee when the account had not enough money';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'transfer fee paid by from_account, it is posted as a separate fee transfer together with the transfer';
+
+COMMENT ON COLUMN "transfers"."fee_kind" IS 'transfer or maintenance for the fee transfers, they don''t count into the transfer limits';
+
+COMMENT ON COLUMN "transfers"."fee_of" IS 'the transfer whose fee is paid by this fee transfer';
+
+ALTER TABLE "fee_waivers" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "fee_waivers" ADD FOREIGN KEY ("waived_by") REFERENCES "users" ("username");
+
+ALTER TABLE "maintenance_fees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "maintenance_fees" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_of") REFERENCES "transfers" ("id");
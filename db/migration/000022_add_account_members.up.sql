@@ -0,0 +1,25 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "added_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE INDEX ON "account_members" ("username");
+
+COMMENT ON TABLE "account_members" IS 'users who share the account, the owner of the account is always its member with the owner role';
+
+COMMENT ON COLUMN "account_members"."role" IS 'owner, co-owner, viewer, can-initiate or needs-approval';
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("added_by") REFERENCES "users" ("username");
+
+-- dosavadní vlastníci se stanou členy svých účtů, systémové účty žádné členy nemají
+INSERT INTO "account_members" ("account_id", "username", "role", "added_by")
+SELECT "id", "owner", 'owner', "owner" FROM "accounts"
+WHERE "owner" <> 'system';
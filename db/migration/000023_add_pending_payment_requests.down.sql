@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "payment_requests_transfer_id_idx";
+
+UPDATE "payment_requests" SET "status" = 'paid' WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'the transfer which paid the request';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'open, paid, declined, cancelled or expired';
@@ -0,0 +1,17 @@
+CREATE INDEX ON "payment_requests" ("transfer_id") WHERE "status" = 'pending';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'open, pending, paid, declined, cancelled or expired';
+
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'the transfer which paid the request or the pending transfer which will pay it';
+
+UPDATE "payment_requests" SET "status" = 'pending'
+FROM "transfers"
+WHERE "transfers"."id" = "payment_requests"."transfer_id"
+  AND "payment_requests"."status" = 'paid'
+  AND "transfers"."status" = 'pending';
+
+UPDATE "payment_requests" SET "status" = 'open', "transfer_id" = NULL, "decided_at" = NULL
+FROM "transfers"
+WHERE "transfers"."id" = "payment_requests"."transfer_id"
+  AND "payment_requests"."status" = 'paid'
+  AND "transfers"."status" IN ('rejected', 'cancelled');
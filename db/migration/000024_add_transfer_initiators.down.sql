@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "transfers_initiated_by_created_at_idx";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "initiated_by";
@@ -0,0 +1,15 @@
+ALTER TABLE "transfers" ADD COLUMN "initiated_by" varchar;
+
+-- dosavadní převody se počítaly do limitů vlastníka účtu, ze kterého odešly
+UPDATE "transfers" SET "initiated_by" = "accounts"."owner"
+FROM "accounts"
+WHERE "accounts"."id" = "transfers"."from_account_id"
+  AND "accounts"."owner" <> 'system'
+  AND "transfers"."fee_kind" IS NULL;
+
+-- limity uživatele sčítají jeho převody ze všech účtů, jejichž je členem
+CREATE INDEX ON "transfers" ("initiated_by", "created_at") WHERE "initiated_by" IS NOT NULL;
+
+COMMENT ON COLUMN "transfers"."initiated_by" IS 'the account member who sent the transfer and whose transfer limits it counts into, null for the system and fee transfers';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("initiated_by") REFERENCES "users" ("username");
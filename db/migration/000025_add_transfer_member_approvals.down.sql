@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "authorized_by";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "member_approval";
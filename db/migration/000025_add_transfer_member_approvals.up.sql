@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "member_approval" boolean NOT NULL DEFAULT false;
+ALTER TABLE "transfers" ADD COLUMN "authorized_by" varchar;
+
+COMMENT ON COLUMN "transfers"."member_approval" IS 'the transfer was sent by a member who needs approval, so the owner or a co-owner of the account must authorize it';
+COMMENT ON COLUMN "transfers"."authorized_by" IS 'the owner or co-owner who authorized the transfer of the member, null until it is authorized';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("authorized_by") REFERENCES "users" ("username");
@@ -0,0 +1,3 @@
+UPDATE "accounts" SET "type" = 'checking' WHERE "owner" = 'system' AND "type" = 'cash';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business, the system user has also interest_expense and fee_revenue accounts';
@@ -0,0 +1,4 @@
+-- hotovostní účty systému měly typ checking z výchozí hodnoty sloupce, vklady a výběry je hledají podle vlastního typu
+UPDATE "accounts" SET "type" = 'cash' WHERE "owner" = 'system' AND "type" = 'checking';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking, savings or business, the system user has cash, interest_expense and fee_revenue accounts';
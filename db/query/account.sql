@@ -2,24 +2,33 @@
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  overdraft_limit,
+  max_monthly_transfers
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts 
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByOwnerCurrencyAndType :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 AND type = $3 AND status <> 'closed'
+LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts 
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE; 
 
 -- name: ListAccounts :many
-SELECT * FROM accounts
-WHERE owner = $1
-ORDER BY id
+SELECT accounts.* FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE account_members.username = $1
+ORDER BY accounts.id
 LIMIT $2
 OFFSET $3;
 
@@ -38,3 +47,32 @@ RETURNING *;
 -- name: DeleteAccount :exec
 DELETE FROM accounts 
 WHERE id = $1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1 AND status <> 'closed'
+RETURNING *;
+
+-- name: GetAccountsForUpdate :many
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE;
+
+-- name: AddAccountBalances :exec
+UPDATE accounts
+SET balance = balance + delta.amount
+FROM unnest(sqlc.arg(ids)::bigint[], sqlc.arg(amounts)::bigint[]) AS delta(id, amount)
+WHERE accounts.id = delta.id;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1 AND status <> 'closed'
+RETURNING *;
+
+-- name: GetSystemAccount :one
+SELECT * FROM accounts
+WHERE owner = 'system' AND currency = $1 AND type = $2 AND status <> 'closed'
+LIMIT 1;
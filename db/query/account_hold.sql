@@ -0,0 +1,54 @@
+-- name: CreateAccountHold :one
+INSERT INTO account_holds (
+  account_id,
+  amount,
+  description,
+  expires_at,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetAccountHold :one
+SELECT * FROM account_holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetAccountHoldForUpdate :one
+SELECT * FROM account_holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListAccountHolds :many
+SELECT * FROM account_holds
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CloseAccountHold :one
+UPDATE account_holds
+SET status = sqlc.arg(status),
+  captured_amount = sqlc.arg(captured_amount),
+  closed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'active'
+RETURNING *;
+
+-- name: SetAccountHoldTransfer :one
+UPDATE account_holds
+SET transfer_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpireAccountHolds :execrows
+UPDATE account_holds
+SET status = 'expired',
+  closed_at = now()
+WHERE status = 'active' AND expires_at <= sqlc.arg(now);
+
+-- name: GetAccountReservedAmount :one
+SELECT (
+  (SELECT COALESCE(SUM(amount + fee), 0) FROM transfers
+  WHERE from_account_id = sqlc.arg(account_id) AND status = 'pending') +
+  (SELECT COALESCE(SUM(amount), 0) FROM account_holds
+  WHERE account_id = sqlc.arg(account_id) AND status = 'active' AND expires_at > now())
+)::bigint AS reserved_amount;
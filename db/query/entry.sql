@@ -1,9 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -11,8 +12,36 @@ SELECT * FROM entries
 WHERE id = $1 LIMIT 1;
 
 -- name: ListEntries :many
-SELECT * FROM entries
-WHERE account_id = $1
+SELECT id, account_id, amount, created_at, running_balance FROM (
+  SELECT
+    entries.id,
+    entries.account_id,
+    entries.amount,
+    entries.created_at,
+    (accounts.balance - SUM(entries.amount) OVER (ORDER BY entries.id DESC) + entries.amount)::bigint AS running_balance
+  FROM entries
+  JOIN accounts ON accounts.id = entries.account_id
+  WHERE entries.account_id = sqlc.arg(account_id)
+) AS statement
+WHERE (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (
+    sqlc.arg(direction)::varchar = ''
+    OR (sqlc.arg(direction) = 'incoming' AND amount > 0)
+    OR (sqlc.arg(direction) = 'outgoing' AND amount < 0)
+  )
 ORDER BY id
-LIMIT $2
-OFFSET $3;
\ No newline at end of file
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: CreateTransferEntries :exec
+INSERT INTO entries (
+  account_id,
+  amount,
+  transfer_id
+)
+SELECT from_account_id, -amount, id FROM transfers
+WHERE id = ANY(sqlc.arg(transfer_ids)::bigint[])
+UNION ALL
+SELECT to_account_id, to_amount, id FROM transfers
+WHERE id = ANY(sqlc.arg(transfer_ids)::bigint[]);
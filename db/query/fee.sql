@@ -0,0 +1,96 @@
+-- name: GetFeeSchedule :one
+SELECT * FROM fee_schedules
+WHERE kind = $1 AND currency = $2
+LIMIT 1;
+
+-- name: ListFeeSchedules :many
+SELECT * FROM fee_schedules
+ORDER BY kind, currency;
+
+-- name: UpsertFeeSchedule :one
+INSERT INTO fee_schedules (
+  kind,
+  currency,
+  method,
+  flat_amount,
+  rate,
+  min_fee,
+  max_fee,
+  min_balance
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (kind, currency) DO UPDATE
+SET method = EXCLUDED.method,
+    flat_amount = EXCLUDED.flat_amount,
+    rate = EXCLUDED.rate,
+    min_fee = EXCLUDED.min_fee,
+    max_fee = EXCLUDED.max_fee,
+    min_balance = EXCLUDED.min_balance,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteFeeSchedule :one
+DELETE FROM fee_schedules
+WHERE kind = $1 AND currency = $2
+RETURNING *;
+
+-- name: GetFeeWaiver :one
+SELECT * FROM fee_waivers
+WHERE username = $1 AND kind = $2
+LIMIT 1;
+
+-- name: ListFeeWaivers :many
+SELECT * FROM fee_waivers
+WHERE username = $1
+ORDER BY kind;
+
+-- name: UpsertFeeWaiver :one
+INSERT INTO fee_waivers (
+  username,
+  kind,
+  waived_by
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (username, kind) DO UPDATE
+SET waived_by = EXCLUDED.waived_by,
+    created_at = now()
+RETURNING *;
+
+-- name: DeleteFeeWaiver :one
+DELETE FROM fee_waivers
+WHERE username = $1 AND kind = $2
+RETURNING *;
+
+-- name: ListMaintenanceFeeAccounts :many
+SELECT account_id, balance FROM (
+  SELECT accounts.id AS account_id,
+    fee_schedules.min_balance,
+    (accounts.balance - COALESCE((
+      SELECT SUM(entries.amount) FROM entries
+      WHERE entries.account_id = accounts.id AND entries.created_at >= sqlc.arg(month_end)
+    ), 0))::bigint AS balance
+  FROM accounts
+  JOIN fee_schedules ON fee_schedules.kind = 'maintenance' AND fee_schedules.currency = accounts.currency
+  WHERE accounts.owner <> 'system'
+    AND accounts.status = 'active'
+    AND accounts.created_at < sqlc.arg(month_end)
+    AND NOT EXISTS (
+      SELECT 1 FROM maintenance_fees
+      WHERE maintenance_fees.account_id = accounts.id AND maintenance_fees.month = sqlc.arg(month)
+    )
+) AS month_end_balances
+WHERE balance < min_balance
+ORDER BY account_id;
+
+-- name: CreateMaintenanceFee :one
+INSERT INTO maintenance_fees (
+  account_id,
+  month,
+  balance,
+  amount,
+  waived,
+  transfer_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) ON CONFLICT (account_id, month) DO NOTHING
+RETURNING *;
@@ -0,0 +1,32 @@
+-- name: GetFxRate :one
+SELECT * FROM fx_rates
+WHERE from_currency = $1 AND to_currency = $2
+LIMIT 1;
+
+-- name: UpsertFxRate :one
+INSERT INTO fx_rates (
+  from_currency,
+  to_currency,
+  rate
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (from_currency, to_currency) DO UPDATE
+SET rate = EXCLUDED.rate,
+    updated_at = now()
+RETURNING *;
+
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (
+  id,
+  username,
+  from_currency,
+  to_currency,
+  rate,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1;
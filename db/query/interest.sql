@@ -0,0 +1,93 @@
+-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+ORDER BY account_type, currency;
+
+-- name: UpsertInterestRate :one
+INSERT INTO interest_rates (
+  account_type,
+  currency,
+  annual_rate,
+  day_count
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (account_type, currency) DO UPDATE
+SET annual_rate = EXCLUDED.annual_rate,
+    day_count = EXCLUDED.day_count,
+    updated_at = now()
+RETURNING *;
+
+-- name: GetLastInterestAccrualRun :one
+SELECT * FROM interest_accrual_runs
+ORDER BY accrual_date DESC
+LIMIT 1;
+
+-- name: CreateInterestAccrualRun :one
+INSERT INTO interest_accrual_runs (
+  accrual_date,
+  account_count
+) VALUES (
+  $1, $2
+) ON CONFLICT (accrual_date) DO NOTHING
+RETURNING *;
+
+-- name: ListInterestBearingBalances :many
+SELECT accounts.id AS account_id,
+  interest_rates.annual_rate,
+  interest_rates.day_count,
+  (accounts.balance - COALESCE((
+    SELECT SUM(entries.amount) FROM entries
+    WHERE entries.account_id = accounts.id AND entries.created_at >= sqlc.arg(day_end)
+  ), 0))::bigint AS balance
+FROM accounts
+JOIN interest_rates ON interest_rates.account_type = accounts.type AND interest_rates.currency = accounts.currency
+WHERE accounts.owner <> 'system' AND accounts.status <> 'closed' AND accounts.created_at < sqlc.arg(day_end)
+ORDER BY accounts.id;
+
+-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (
+  account_id,
+  accrual_date,
+  balance,
+  annual_rate,
+  day_count,
+  amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) ON CONFLICT (account_id, accrual_date) DO NOTHING;
+
+-- name: ListInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND accrual_date >= sqlc.arg(from_date)
+  AND accrual_date < sqlc.arg(to_date)
+ORDER BY accrual_date;
+
+-- name: ListUnpostedInterestMonths :many
+SELECT DISTINCT account_id, date_trunc('month', accrual_date)::date AS month
+FROM interest_accruals
+WHERE accrual_date < sqlc.arg(before)
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings
+    WHERE interest_postings.account_id = interest_accruals.account_id
+      AND interest_postings.month = date_trunc('month', interest_accruals.accrual_date)::date
+  )
+ORDER BY month, account_id;
+
+-- name: GetLastInterestPosting :one
+SELECT * FROM interest_postings
+WHERE account_id = $1 AND month < $2
+ORDER BY month DESC
+LIMIT 1;
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id,
+  month,
+  accrued,
+  amount,
+  carry,
+  transfer_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) ON CONFLICT (account_id, month) DO NOTHING
+RETURNING *;
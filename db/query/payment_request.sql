@@ -0,0 +1,77 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  requester,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  description,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListIncomingPaymentRequests :many
+SELECT * FROM payment_requests
+WHERE payer = sqlc.arg(payer)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: ListOutgoingPaymentRequests :many
+SELECT * FROM payment_requests
+WHERE requester = sqlc.arg(requester)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: PayPaymentRequest :one
+UPDATE payment_requests
+SET status = sqlc.arg(status),
+  transfer_id = sqlc.arg(transfer_id),
+  decided_at = now()
+WHERE id = sqlc.arg(id) AND status = 'open'
+RETURNING *;
+
+-- name: PayPendingPaymentRequest :exec
+UPDATE payment_requests
+SET status = 'paid',
+  decided_at = now()
+WHERE transfer_id = $1 AND status = 'pending';
+
+-- name: ReopenPaymentRequest :exec
+UPDATE payment_requests
+SET status = CASE WHEN expires_at <= now() THEN 'expired' ELSE 'open' END,
+  transfer_id = NULL,
+  decided_at = CASE WHEN expires_at <= now() THEN now() ELSE NULL END
+WHERE transfer_id = $1 AND status = 'pending';
+
+-- name: ClosePaymentRequest :one
+UPDATE payment_requests
+SET status = sqlc.arg(status),
+  decided_at = now()
+WHERE id = sqlc.arg(id) AND status = 'open' AND expires_at > now()
+RETURNING *;
+
+-- name: ExpirePaymentRequests :execrows
+UPDATE payment_requests
+SET status = 'expired',
+  decided_at = now()
+WHERE status = 'open' AND expires_at <= sqlc.arg(now);
+
+-- name: CancelAccountPaymentRequests :exec
+UPDATE payment_requests
+SET status = 'cancelled',
+  decided_at = now()
+WHERE to_account_id = $1 AND status = 'open';
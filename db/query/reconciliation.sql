@@ -0,0 +1,79 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+  status
+) VALUES (
+  'running'
+) RETURNING *;
+
+-- name: FinishReconciliationRun :one
+UPDATE reconciliation_runs
+SET
+  status = $2,
+  accounts_checked = $3,
+  transfers_checked = $4,
+  issues_found = $5,
+  error = $6,
+  finished_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: CreateReconciliationIssue :one
+INSERT INTO reconciliation_issues (
+  run_id,
+  kind,
+  account_id,
+  transfer_id,
+  expected,
+  actual,
+  details
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListReconciliationIssues :many
+SELECT * FROM reconciliation_issues
+WHERE run_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ReconcileAccounts :many
+SELECT
+  accounts.id,
+  accounts.balance,
+  COALESCE(SUM(entries.amount), 0)::bigint AS entries_sum,
+  COUNT(entries.id) FILTER (WHERE entries.transfer_id IS NULL) AS orphan_entries
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+WHERE accounts.id > sqlc.arg(after_id)
+GROUP BY accounts.id
+ORDER BY accounts.id
+LIMIT sqlc.arg(limit);
+
+-- name: ReconcileTransfers :many
+SELECT
+  transfers.id,
+  transfers.from_account_id,
+  transfers.to_account_id,
+  transfers.amount,
+  transfers.to_amount,
+  transfers.status,
+  COUNT(entries.id) AS entries_count,
+  COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.from_account_id), 0)::bigint AS from_entries_sum,
+  COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.to_account_id), 0)::bigint AS to_entries_sum
+FROM transfers
+LEFT JOIN entries ON entries.transfer_id = transfers.id
+WHERE transfers.id > sqlc.arg(after_id)
+GROUP BY transfers.id
+ORDER BY transfers.id
+LIMIT sqlc.arg(limit);
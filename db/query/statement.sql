@@ -0,0 +1,16 @@
+-- name: GetStatementBalances :one
+SELECT
+  (accounts.balance - COALESCE(SUM(changes.change) FILTER (WHERE changes.created_at >= sqlc.arg(from_time)), 0))::bigint AS opening_balance,
+  (accounts.balance - COALESCE(SUM(changes.change) FILTER (WHERE changes.created_at >= sqlc.arg(to_time)), 0))::bigint AS closing_balance
+FROM accounts
+LEFT JOIN (
+  SELECT
+    transfers.created_at,
+    (CASE WHEN transfers.to_account_id = sqlc.arg(account_id) THEN transfers.to_amount ELSE 0 END)
+      - (CASE WHEN transfers.from_account_id = sqlc.arg(account_id) THEN transfers.amount ELSE 0 END) AS change
+  FROM transfers
+  WHERE (transfers.from_account_id = sqlc.arg(account_id) OR transfers.to_account_id = sqlc.arg(account_id))
+    AND transfers.status = 'posted'
+) AS changes ON true
+WHERE accounts.id = sqlc.arg(account_id)
+GROUP BY accounts.balance;
@@ -2,20 +2,141 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  status,
+  fee,
+  fee_kind,
+  fee_of,
+  initiated_by,
+  member_approval
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: AddTransferReversedAmount :one
+UPDATE transfers
+SET reversed_amount = reversed_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DecideTransfer :one
+UPDATE transfers
+SET status = sqlc.arg(status),
+  decided_by = sqlc.arg(decided_by),
+  decided_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: AuthorizeTransfer :one
+UPDATE transfers
+SET authorized_by = sqlc.arg(authorized_by)
+WHERE id = sqlc.arg(id) AND status = 'pending' AND member_approval AND authorized_by IS NULL
+RETURNING *;
+
+-- name: ListTransferReversals :many
+SELECT * FROM transfers
+WHERE reversal_of = $1
+ORDER BY id;
+
 -- name: ListTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, running_balance FROM (
+  SELECT
+    account_transfers.id,
+    account_transfers.from_account_id,
+    account_transfers.to_account_id,
+    account_transfers.amount,
+    account_transfers.created_at,
+    account_transfers.to_amount,
+    account_transfers.exchange_rate,
+    account_transfers.status,
+    (account_transfers.balance - SUM(account_transfers.change) OVER (ORDER BY account_transfers.id DESC) + account_transfers.change)::bigint AS running_balance
+  FROM (
+    SELECT
+      transfers.id,
+      transfers.from_account_id,
+      transfers.to_account_id,
+      transfers.amount,
+      transfers.created_at,
+      transfers.to_amount,
+      transfers.exchange_rate,
+      transfers.status,
+      accounts.balance,
+      -- jen zaúčtované převody mění zůstatek
+      (CASE WHEN transfers.status <> 'posted' THEN 0
+        ELSE (CASE WHEN transfers.to_account_id = accounts.id THEN transfers.to_amount ELSE 0 END)
+          - (CASE WHEN transfers.from_account_id = accounts.id THEN transfers.amount ELSE 0 END)
+      END) AS change
+    FROM transfers
+    JOIN accounts ON accounts.id = sqlc.arg(account_id)
+    WHERE transfers.from_account_id = sqlc.arg(account_id) OR transfers.to_account_id = sqlc.arg(account_id)
+  ) AS account_transfers
+) AS statement
+WHERE (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (
+    sqlc.arg(direction)::varchar = ''
+    OR (sqlc.arg(direction) = 'incoming' AND to_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(direction) = 'outgoing' AND from_account_id = sqlc.arg(account_id))
+  )
+ORDER BY id
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: ListAllTransfers :many
 SELECT * FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+WHERE sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)
 ORDER BY id
-LIMIT $3
-OFFSET $4;
\ No newline at end of file
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: CreateBatchTransfers :many
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  status,
+  fee,
+  initiated_by,
+  member_approval
+)
+SELECT sqlc.arg(from_account_id)::bigint, item.to_account_id, item.amount, item.amount, '1', item.status, item.fee, sqlc.arg(initiated_by)::varchar, sqlc.arg(member_approval)::boolean
+FROM unnest(
+  sqlc.arg(to_account_ids)::bigint[],
+  sqlc.arg(amounts)::bigint[],
+  sqlc.arg(statuses)::varchar[],
+  sqlc.arg(fees)::bigint[]
+) WITH ORDINALITY AS item(to_account_id, amount, status, fee, position)
+ORDER BY item.position
+RETURNING id;
+
+-- name: CreateFeeTransfers :many
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  status,
+  fee_kind,
+  fee_of
+)
+SELECT sqlc.arg(from_account_id)::bigint, sqlc.arg(to_account_id)::bigint, fee.amount, fee.amount, '1', 'posted', 'transfer', fee.fee_of
+FROM unnest(
+  sqlc.arg(fee_of)::bigint[],
+  sqlc.arg(amounts)::bigint[]
+) AS fee(fee_of, amount)
+RETURNING id;
@@ -0,0 +1,53 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+  owner,
+  from_account_id,
+  mode,
+  status,
+  item_count,
+  succeeded_count,
+  failed_count,
+  total_amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: ListTransferBatches :many
+SELECT * FROM transfer_batches
+WHERE owner = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CreateTransferBatchItems :many
+INSERT INTO transfer_batch_items (
+  batch_id,
+  position,
+  to_account_id,
+  amount,
+  status,
+  transfer_id,
+  error
+)
+SELECT sqlc.arg(batch_id)::bigint, item.position, item.to_account_id, item.amount, item.status, NULLIF(item.transfer_id, 0), item.error
+FROM unnest(
+  sqlc.arg(positions)::integer[],
+  sqlc.arg(to_account_ids)::bigint[],
+  sqlc.arg(amounts)::bigint[],
+  sqlc.arg(statuses)::varchar[],
+  sqlc.arg(transfer_ids)::bigint[],
+  sqlc.arg(errors)::varchar[]
+) AS item(position, to_account_id, amount, status, transfer_id, error)
+RETURNING *;
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = sqlc.arg(batch_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY position
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
@@ -0,0 +1,66 @@
+-- name: GetAccountTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE account_id = $1 LIMIT 1;
+
+-- name: GetUserTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
+-- name: UpsertAccountTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id,
+  currency,
+  per_transaction,
+  daily,
+  monthly
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id) WHERE account_id IS NOT NULL DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+  daily = EXCLUDED.daily,
+  monthly = EXCLUDED.monthly,
+  updated_at = now()
+RETURNING *;
+
+-- name: UpsertUserTransferLimit :one
+INSERT INTO transfer_limits (
+  owner,
+  currency,
+  per_transaction,
+  daily,
+  monthly
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (owner, currency) WHERE owner IS NOT NULL DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+  daily = EXCLUDED.daily,
+  monthly = EXCLUDED.monthly,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetAccountTransferUsage :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+  COALESCE(SUM(amount), 0)::bigint AS monthly,
+  COUNT(*) AS monthly_count
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+  AND status IN ('pending', 'posted')
+  AND fee_kind IS NULL
+  AND reversal_of IS NULL
+  AND created_at >= sqlc.arg(month_start);
+
+-- name: GetUserTransferUsage :one
+SELECT
+  COALESCE(SUM(transfers.amount) FILTER (WHERE transfers.created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+  COALESCE(SUM(transfers.amount), 0)::bigint AS monthly
+FROM transfers
+JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE transfers.initiated_by = sqlc.arg(username)
+  AND accounts.currency = sqlc.arg(currency)
+  AND transfers.status IN ('pending', 'posted')
+  AND transfers.fee_kind IS NULL
+  AND transfers.reversal_of IS NULL
+  AND transfers.created_at >= sqlc.arg(month_start);
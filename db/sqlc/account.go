@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// CloseAccountTxParams contains the input parameters of the account closing
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// optional, another active account of the owner which receives the remaining balance,
+	// without it only an account with zero balance can be closed
+	SweepAccountID int64 `json:"sweep_account_id"`
+	// rate from the currency of the account to the currency of the sweep account
+	ExchangeRate string `json:"exchange_rate"`
+	// optional, converts the swept balance with the exchange rate, the balance is known only
+	// inside the transaction, without it the sweep account must have the same currency
+	Convert func(amount int64) (int64, error) `json:"-"`
+}
+
+// CloseAccountTxResult contains the closed account and the transfer which swept its balance
+type CloseAccountTxResult struct {
+	Account Account `json:"account"`
+	// nil if the account had zero balance
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// CloseAccountTx closes the account for good. The remaining balance is moved by a transfer to the sweep account,
+// the account and the sweep account are locked like by the transfers, so no money can arrive after the sweep.
+// Active scheduled transfers of the account and open payment requests to it are cancelled. It returns
+// ErrAccountNotEmpty if there is a balance without a sweep account or if a part of the balance is reserved,
+// and ErrAccountNotActive if one of the accounts is frozen or already closed.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var account Account
+		var err error
+		if arg.SweepAccountID != 0 {
+			account, err = lockAccounts(ctx, q, arg.AccountID, arg.SweepAccountID)
+		} else {
+			account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+			if err == nil {
+				err = checkAccountActive(account)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		// čekající převody a držené částky by po uzavření nešlo dokončit
+		reservedAmount, err := q.GetAccountReservedAmount(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if reservedAmount > 0 {
+			return fmt.Errorf("%w: %d is reserved by pending transfers and holds", ErrAccountNotEmpty, reservedAmount)
+		}
+
+		if account.Balance < 0 {
+			return fmt.Errorf("%w: account is overdrawn by %d", ErrAccountNotEmpty, -account.Balance)
+		}
+		if account.Balance > 0 {
+			if arg.SweepAccountID == 0 {
+				return fmt.Errorf("%w: balance is %d", ErrAccountNotEmpty, account.Balance)
+			}
+
+			sweepArg := TransferTxParams{
+				FromAccountID: account.ID,
+				ToAccountID:   arg.SweepAccountID,
+				Amount:        account.Balance,
+			}
+			if arg.Convert != nil {
+				sweepArg.ToAmount, err = arg.Convert(account.Balance)
+				if err != nil {
+					return err
+				}
+				sweepArg.ExchangeRate = arg.ExchangeRate
+			}
+
+			sweep, err := transfer(ctx, q, sweepArg, sql.NullInt64{})
+			if err != nil {
+				return err
+			}
+			result.Sweep = &sweep
+		}
+
+		if err := q.CancelAccountScheduledTransfers(ctx, account.ID); err != nil {
+			return err
+		}
+		if err := q.CancelAccountPaymentRequests(ctx, account.ID); err != nil {
+			return err
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: util.AccountClosed,
+		})
+		return err
+	})
+
+	return result, err
+}
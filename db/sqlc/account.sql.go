@@ -5,13 +5,15 @@ package db
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers
 `
 
 type AddAccountBalanceParams struct {
@@ -28,28 +30,62 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
 	)
 	return i, err
 }
 
+const addAccountBalances = `-- name: AddAccountBalances :exec
+UPDATE accounts
+SET balance = balance + delta.amount
+FROM unnest($1::bigint[], $2::bigint[]) AS delta(id, amount)
+WHERE accounts.id = delta.id
+`
+
+type AddAccountBalancesParams struct {
+	Ids     []int64 `json:"ids"`
+	Amounts []int64 `json:"amounts"`
+}
+
+func (q *Queries) AddAccountBalances(ctx context.Context, arg AddAccountBalancesParams) error {
+	_, err := q.db.ExecContext(ctx, addAccountBalances, pq.Array(arg.Ids), pq.Array(arg.Amounts))
+	return err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  overdraft_limit,
+  max_monthly_transfers
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner               string `json:"owner"`
+	Balance             int64  `json:"balance"`
+	Currency            string `json:"currency"`
+	Type                string `json:"type"`
+	OverdraftLimit      int64  `json:"overdraft_limit"`
+	MaxMonthlyTransfers int32  `json:"max_monthly_transfers"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Type,
+		arg.OverdraftLimit,
+		arg.MaxMonthlyTransfers,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -57,6 +93,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
 	)
 	return i, err
 }
@@ -72,7 +112,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts 
+SELECT id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers FROM accounts 
 WHERE id = $1 LIMIT 1
 `
 
@@ -85,12 +125,45 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
+	)
+	return i, err
+}
+
+const getAccountByOwnerCurrencyAndType = `-- name: GetAccountByOwnerCurrencyAndType :one
+SELECT id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers FROM accounts
+WHERE owner = $1 AND currency = $2 AND type = $3 AND status <> 'closed'
+LIMIT 1
+`
+
+type GetAccountByOwnerCurrencyAndTypeParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+func (q *Queries) GetAccountByOwnerCurrencyAndType(ctx context.Context, arg GetAccountByOwnerCurrencyAndTypeParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerCurrencyAndType, arg.Owner, arg.Currency, arg.Type)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts 
+SELECT id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers FROM accounts 
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -104,26 +177,99 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
 	)
 	return i, err
 }
 
-const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
+const getAccountsForUpdate = `-- name: GetAccountsForUpdate :many
+SELECT id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers FROM accounts
+WHERE id = ANY($1::bigint[])
 ORDER BY id
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountsForUpdate, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Type,
+			&i.OverdraftLimit,
+			&i.MaxMonthlyTransfers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers FROM accounts
+WHERE owner = 'system' AND currency = $1 AND type = $2 AND status <> 'closed'
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getSystemAccount, arg.Currency, arg.Type)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
+	)
+	return i, err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT accounts.id, accounts.owner, accounts.balance, accounts.currency, accounts.created_at, accounts.status, accounts.type, accounts.overdraft_limit, accounts.max_monthly_transfers FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE account_members.username = $1
+ORDER BY accounts.id
 LIMIT $2
 OFFSET $3
 `
 
 type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Username, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +283,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.Type,
+			&i.OverdraftLimit,
+			&i.MaxMonthlyTransfers,
 		); err != nil {
 			return nil, err
 		}
@@ -155,7 +305,7 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers
 `
 
 type UpdateAccountParams struct {
@@ -172,6 +322,68 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1 AND status <> 'closed'
+RETURNING id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1 AND status <> 'closed'
+RETURNING id, owner, balance, currency, created_at, status, type, overdraft_limit, max_monthly_transfers
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Type,
+		&i.OverdraftLimit,
+		&i.MaxMonthlyTransfers,
 	)
 	return i, err
 }
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// PlaceHoldTxParams contains the input parameters of the hold placement
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	Amount      int64     `json:"amount"`
+	Description string    `json:"description"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedBy   string    `json:"created_by"`
+}
+
+// PlaceHoldTx reserves the amount on the account until the hold is captured, released or it expires.
+// The account is locked like by the transfers, so the hold and a concurrent transfer can't spend
+// the same money. It returns ErrInsufficientFunds if the available balance is lower than the amount.
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (AccountHold, error) {
+	var hold AccountHold
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkAccountActive(account); err != nil {
+			return err
+		}
+
+		if err := checkAvailableFunds(ctx, q, account, arg.Amount, 0); err != nil {
+			return err
+		}
+
+		hold, err = q.CreateAccountHold(ctx, CreateAccountHoldParams{
+			AccountID:   arg.AccountID,
+			Amount:      arg.Amount,
+			Description: arg.Description,
+			ExpiresAt:   arg.ExpiresAt,
+			CreatedBy:   arg.CreatedBy,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// CaptureHoldTxParams contains the input parameters of the hold capture
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// account which receives the captured money, it must have the same currency as the held account
+	ToAccountID int64 `json:"to_account_id"`
+	// captured amount, zero captures the whole hold
+	Amount int64 `json:"amount"`
+}
+
+// CaptureHoldTxResult contains the captured hold and the transfer which moved its money
+type CaptureHoldTxResult struct {
+	Hold AccountHold `json:"hold"`
+	TransferTxResult
+}
+
+// CaptureHoldTx converts the active hold into a posted transfer from the held account. The hold can be captured
+// only once, a smaller amount than the hold captures only its part and releases the rest. The hold is locked before
+// the accounts, like the transfer in ApproveTransferTx, and it is closed before the transfer, so its own amount is
+// no longer reserved when the transfer checks the available balance. The authorized money is captured without
+// the transfer limits and the approval. It returns ErrHoldNotActive if the hold was already closed or it expired.
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetAccountHoldForUpdate(ctx, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != util.HoldActive {
+			return fmt.Errorf("%w: hold %d is %s", ErrHoldNotActive, hold.ID, hold.Status)
+		}
+		// blokace přestává platit expirací, i když ji ještě nikdo neoznačil jako expirovanou
+		if !hold.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: hold %d expired at %s", ErrHoldNotActive, hold.ID, hold.ExpiresAt)
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount < 0 || amount > hold.Amount {
+			return fmt.Errorf("%w: amount %d is not between 1 and %d held", ErrInvalidCapture, amount, hold.Amount)
+		}
+
+		_, err = q.CloseAccountHold(ctx, CloseAccountHoldParams{
+			Status:         util.HoldCaptured,
+			CapturedAmount: amount,
+			ID:             hold.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        amount,
+		}, sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.SetAccountHoldTransfer(ctx, SetAccountHoldTransferParams{
+			ID:         hold.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	hold, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID: account1.ID,
+		Amount:    600,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedBy: account1.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.HoldActive, hold.Status)
+
+	reserved, err := store.GetAccountReservedAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(600), reserved)
+
+	// blokované peníze nejde převést ani znovu zablokovat
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        500,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID: account1.ID,
+		Amount:    500,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID:      hold.ID,
+		ToAccountID: account2.ID,
+		Amount:      601,
+	})
+	require.ErrorIs(t, err, ErrInvalidCapture)
+
+	// zachycení části blokace uvolní zbytek
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID:      hold.ID,
+		ToAccountID: account2.ID,
+		Amount:      400,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.HoldCaptured, result.Hold.Status)
+	require.Equal(t, int64(400), result.Hold.CapturedAmount)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.Hold.TransferID)
+	require.True(t, result.Hold.ClosedAt.Valid)
+	require.Equal(t, util.TransferPosted, result.Transfer.Status)
+	require.Equal(t, int64(600), result.FromAccount.Balance)
+	require.Equal(t, int64(400), result.ToAccount.Balance)
+
+	reserved, err = store.GetAccountReservedAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, reserved)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID:      hold.ID,
+		ToAccountID: account2.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestAccountHoldRelease(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountWithBalance(t, 1000)
+
+	hold, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID: account.ID,
+		Amount:    1000,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedBy: account.Owner,
+	})
+	require.NoError(t, err)
+
+	released, err := store.CloseAccountHold(context.Background(), CloseAccountHoldParams{
+		Status: util.HoldReleased,
+		ID:     hold.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.HoldReleased, released.Status)
+	require.Zero(t, released.CapturedAmount)
+
+	// uzavřenou blokaci už nejde uvolnit ani zachytit
+	_, err = store.CloseAccountHold(context.Background(), CloseAccountHoldParams{
+		Status: util.HoldReleased,
+		ID:     hold.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	reserved, err := store.GetAccountReservedAmount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, reserved)
+}
+
+func TestAccountHoldExpiration(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	hold, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID: account1.ID,
+		Amount:    1000,
+		ExpiresAt: time.Now().Add(time.Second),
+		CreatedBy: account1.Owner,
+	})
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	// vypršelá blokace peníze neblokuje, i když ještě není označená jako expirovaná
+	reserved, err := store.GetAccountReservedAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, reserved)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID:      hold.ID,
+		ToAccountID: account2.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	expired, err := store.ExpireAccountHolds(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	hold, err = store.GetAccountHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.HoldExpired, hold.Status)
+	require.True(t, hold.ClosedAt.Valid)
+}
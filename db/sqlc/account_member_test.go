@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func addAccountMember(t *testing.T, account Account, username string, role string) AccountMember {
+	arg := CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+		Role:      role,
+		AddedBy:   account.Owner,
+	}
+
+	member, err := testQueries.CreateAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, member.AccountID)
+	require.Equal(t, arg.Username, member.Username)
+	require.Equal(t, arg.Role, member.Role)
+	require.Equal(t, arg.AddedBy, member.AddedBy)
+	require.NotZero(t, member.CreatedAt)
+
+	return member
+}
+
+func TestCreateAccountTxOwnerMember(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+			Type:     util.AccountChecking,
+		},
+	})
+	require.NoError(t, err)
+
+	member, err := store.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.MemberOwner, member.Role)
+}
+
+func TestCreateAccountTxCoOwners(t *testing.T) {
+	store := NewStore(testDB)
+	owner := createRandomUser(t)
+	coOwner1 := createRandomUser(t)
+	coOwner2 := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    owner.Username,
+			Currency: util.RandomCurrency(),
+			Type:     util.AccountBusiness,
+		},
+		CoOwners: []string{coOwner1.Username, coOwner2.Username},
+	})
+	require.NoError(t, err)
+
+	members, err := store.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+
+	roles := make(map[string]string)
+	for _, member := range members {
+		roles[member.Username] = member.Role
+		require.Equal(t, owner.Username, member.AddedBy)
+	}
+	require.Equal(t, util.MemberOwner, roles[owner.Username])
+	require.Equal(t, util.MemberCoOwner, roles[coOwner1.Username])
+	require.Equal(t, util.MemberCoOwner, roles[coOwner2.Username])
+
+	// neexistující spoluvlastník zruší vytvoření celého účtu
+	otherOwner := createRandomUser(t)
+	_, err = store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    otherOwner.Username,
+			Currency: account.Currency,
+			Type:     util.AccountBusiness,
+		},
+		CoOwners: []string{util.RandomOwner()},
+	})
+	require.Error(t, err)
+
+	accounts, err := store.ListAccounts(context.Background(), ListAccountsParams{
+		Username: otherOwner.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
+
+func TestListAccountsSharedAccount(t *testing.T) {
+	account := createRandomAccount(t)
+	addAccountMember(t, account, account.Owner, util.MemberOwner)
+
+	// společný účet se vypíše i druhému členovi, i když ho nevlastní
+	user := createRandomUser(t)
+	addAccountMember(t, account, user.Username, util.MemberViewer)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+	require.Equal(t, account.Owner, accounts[0].Owner)
+
+	members, err := testQueries.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	member, err := testQueries.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.MemberViewer, member.Role)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	_, err = testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferTxRequireApproval(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:   fromAccount.ID,
+		ToAccountID:     toAccount.ID,
+		Amount:          10,
+		RequireApproval: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, result.Transfer.Status)
+	require.Equal(t, fromAccount.Balance, result.FromAccount.Balance)
+}
@@ -11,11 +11,18 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithBalance(t, util.RandomMoney())
+}
+
+// createRandomAccountWithBalance creates an account with the given balance, it is used by
+// the transfer tests which need to know that the source account has enough money
+func createRandomAccountWithBalance(t *testing.T, balance int64) Account {
 	user := createRandomUser(t)
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
+		Balance:  balance,
 		Currency: util.RandomCurrency(),
+		Type:     util.AccountChecking,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -90,12 +97,13 @@ func TestListAccounts(t *testing.T) {
 	var lastAccount Account
 	for i := 0; i < 10; i++ {
 		lastAccount = createRandomAccount(t)
+		addAccountMember(t, lastAccount, lastAccount.Owner, util.MemberOwner)
 	}
 
 	arg := ListAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Username: lastAccount.Owner,
+		Limit:    5,
+		Offset:   0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -107,3 +115,262 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 500)
+	sweepAccount := createRandomAccountInCurrency(t, account.Currency)
+
+	scheduled := createRandomScheduledTransfer(t, account, sweepAccount, util.RecurrenceOnce, time.Now().Add(time.Hour), sql.NullTime{})
+	request := createRandomPaymentRequest(t, sweepAccount.Owner, account, 100, time.Now().Add(time.Hour))
+
+	// zůstatek bez účtu pro jeho převod brání uzavření
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, int64(500), result.Sweep.Transfer.Amount)
+	require.Equal(t, int64(500), result.Sweep.ToAccount.Balance)
+
+	scheduled, err = store.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ScheduledTransferCancelled, scheduled.Status)
+
+	request, err = store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestCancelled, request.Status)
+
+	// uzavřený účet nemůže přijímat peníze ani být znovu uzavřen nebo zmrazen
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: sweepAccount.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: util.AccountFrozen,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// uzavřený účet nebrání založení nového účtu ve stejné měně
+	newAccount, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Balance:  0,
+		Currency: account.Currency,
+		Type:     util.AccountChecking,
+	})
+	require.NoError(t, err)
+
+	found, err := store.GetAccountByOwnerCurrencyAndType(context.Background(), GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
+		Type:     util.AccountChecking,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newAccount.ID, found.ID)
+}
+
+func TestCloseAccountTxReserved(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 500)
+	sweepAccount := createRandomAccountInCurrency(t, account.Currency)
+
+	_, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedBy: "banker",
+	})
+	require.NoError(t, err)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	account, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.AccountActive, account.Status)
+	require.Equal(t, int64(500), account.Balance)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 500)
+	account2 := createRandomAccountWithBalance(t, 500)
+
+	_, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: util.AccountFrozen,
+	})
+	require.NoError(t, err)
+
+	// zmrazený účet nesmí peníze posílat ani přijímat
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: util.AccountActive,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(490), result.FromAccount.Balance)
+}
+
+func TestTransferTxOverdraft(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:          user.Username,
+		Balance:        100,
+		Currency:       util.USD,
+		Type:           util.AccountChecking,
+		OverdraftLimit: 500,
+	})
+	require.NoError(t, err)
+	toAccount := createRandomAccountInCurrency(t, util.USD)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        600,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-500), result.FromAccount.Balance)
+
+	// debet je vyčerpaný
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// debet nejde snížit pod současný mínus
+	_, err = store.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account.ID,
+		OverdraftLimit: 100,
+	})
+	require.Error(t, err)
+
+	// přečerpaný účet nejde uzavřít
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+}
+
+func TestTransferTxSavingsMonthlyTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:               user.Username,
+		Balance:             1000,
+		Currency:            util.USD,
+		Type:                util.AccountSavings,
+		MaxMonthlyTransfers: 2,
+	})
+	require.NoError(t, err)
+	toAccount := createRandomAccountInCurrency(t, util.USD)
+
+	arg := TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Limits:        DefaultTransferLimits{},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	allowance, err := store.GetTransferAllowance(context.Background(), account, account.Owner, DefaultTransferLimits{})
+	require.NoError(t, err)
+	require.Equal(t, TransferCountUsage{Limit: 2, Used: 2}, allowance.MonthlyTransfers)
+
+	// savings účet nemá debet
+	_, err = store.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account.ID,
+		OverdraftLimit: 100,
+	})
+	require.Error(t, err)
+}
+
+func TestGetAccountByOwnerCurrencyAndType(t *testing.T) {
+	user := createRandomUser(t)
+
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.AccountSavings,
+	})
+	require.NoError(t, err)
+
+	// uživatel bez běžného účtu nemá účet, na který by šly převody adresované jménem
+	_, err = testQueries.GetAccountByOwnerCurrencyAndType(context.Background(), GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.AccountChecking,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	checking, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.AccountChecking,
+	})
+	require.NoError(t, err)
+
+	found, err := testQueries.GetAccountByOwnerCurrencyAndType(context.Background(), GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.AccountChecking,
+	})
+	require.NoError(t, err)
+	require.Equal(t, checking.ID, found.ID)
+
+	found, err = testQueries.GetAccountByOwnerCurrencyAndType(context.Background(), GetAccountByOwnerCurrencyAndTypeParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     util.AccountSavings,
+	})
+	require.NoError(t, err)
+	require.Equal(t, savings.ID, found.ID)
+}
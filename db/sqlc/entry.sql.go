@@ -5,36 +5,61 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id
 ) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+  $1, $2, $3
+) RETURNING id, account_id, amount, created_at, transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.TransferID)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
+const createTransferEntries = `-- name: CreateTransferEntries :exec
+INSERT INTO entries (
+  account_id,
+  amount,
+  transfer_id
+)
+SELECT from_account_id, -amount, id FROM transfers
+WHERE id = ANY($1::bigint[])
+UNION ALL
+SELECT to_account_id, to_amount, id FROM transfers
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) CreateTransferEntries(ctx context.Context, transferIds []int64) error {
+	_, err := q.db.ExecContext(ctx, createTransferEntries, pq.Array(transferIds))
+	return err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -46,38 +71,74 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
-WHERE account_id = $1
+SELECT id, account_id, amount, created_at, running_balance FROM (
+  SELECT
+    entries.id,
+    entries.account_id,
+    entries.amount,
+    entries.created_at,
+    (accounts.balance - SUM(entries.amount) OVER (ORDER BY entries.id DESC) + entries.amount)::bigint AS running_balance
+  FROM entries
+  JOIN accounts ON accounts.id = entries.account_id
+  WHERE entries.account_id = $1
+) AS statement
+WHERE ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+  AND (
+    $4::varchar = ''
+    OR ($4 = 'incoming' AND amount > 0)
+    OR ($4 = 'outgoing' AND amount < 0)
+  )
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $5
+OFFSET $6
 `
 
 type ListEntriesParams struct {
-	AccountID int64 `json:"account_id"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID int64        `json:"account_id"`
+	FromTime  sql.NullTime `json:"from_time"`
+	ToTime    sql.NullTime `json:"to_time"`
+	Direction string       `json:"direction"`
+	Limit     int32        `json:"limit"`
+	Offset    int32        `json:"offset"`
 }
 
-func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+type ListEntriesRow struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]ListEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Direction,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Entry{}
+	items := []ListEntriesRow{}
 	for rows.Next() {
-		var i Entry
+		var i ListEntriesRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.RunningBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEntries(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	results := createTransfers(t, account1, account2)
+
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, len(results))
+
+	for i, entry := range entries {
+		require.Equal(t, account1.ID, entry.AccountID)
+
+		if results[i].FromEntry.AccountID == account1.ID {
+			require.Equal(t, results[i].FromEntry.ID, entry.ID)
+			require.Equal(t, results[i].FromAccount.Balance, entry.RunningBalance)
+		} else {
+			require.Equal(t, results[i].ToEntry.ID, entry.ID)
+			require.Equal(t, results[i].ToAccount.Balance, entry.RunningBalance)
+		}
+	}
+
+	// stránkování a filtr směru nesmí změnit průběžný zůstatek jednotlivých řádků
+	outgoing, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account1.ID,
+		Direction: "outgoing",
+		Limit:     2,
+		Offset:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 2)
+	require.Equal(t, entries[2], outgoing[0])
+	require.Equal(t, entries[4], outgoing[1])
+}
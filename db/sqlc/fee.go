@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// ErrFeeCharged is returned by ChargeMaintenanceFeeTx when the maintenance fee of the month is already charged
+var ErrFeeCharged = errors.New("fee is already charged")
+
+// transferFee returns the transfer fee of the amount sent from the account
+func transferFee(ctx context.Context, q *Queries, account Account, amount int64) (int64, error) {
+	schedule, err := transferFeeSchedule(ctx, q, account)
+	if err != nil || schedule == nil {
+		return 0, err
+	}
+
+	return computeFee(*schedule, amount)
+}
+
+// transferFeeSchedule returns the transfer fee schedule of the currency of the account, it is nil when there is
+// no schedule for the currency or when the owner of the account has the transfer fees waived
+func transferFeeSchedule(ctx context.Context, q *Queries, account Account) (*FeeSchedule, error) {
+	schedule, err := q.GetFeeSchedule(ctx, GetFeeScheduleParams{
+		Kind:     util.FeeKindTransfer,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	waived, err := feeWaived(ctx, q, account.Owner, util.FeeKindTransfer)
+	if err != nil || waived {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// feeWaived returns true if a banker waived the fees of the kind for the user
+func feeWaived(ctx context.Context, q *Queries, username string, kind string) (bool, error) {
+	_, err := q.GetFeeWaiver(ctx, GetFeeWaiverParams{
+		Username: username,
+		Kind:     kind,
+	})
+	switch {
+	case err == nil:
+		return true, nil
+	case err == sql.ErrNoRows:
+		return false, nil
+	}
+	return false, err
+}
+
+// computeFee returns the fee of the amount by the schedule. The percentage is rounded down to whole minor units,
+// then both methods are raised to the minimum fee and lowered to the maximum fee, zero maximum is unlimited.
+func computeFee(schedule FeeSchedule, amount int64) (int64, error) {
+	var fee int64
+	switch schedule.Method {
+	case util.FeeFlat:
+		fee = schedule.FlatAmount
+	case util.FeePercentage:
+		rate, ok := new(big.Rat).SetString(schedule.Rate)
+		if !ok {
+			return 0, fmt.Errorf("invalid fee rate %q", schedule.Rate)
+		}
+		r := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+		fee = new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+	default:
+		return 0, fmt.Errorf("unsupported fee method %q", schedule.Method)
+	}
+
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee > 0 && fee > schedule.MaxFee {
+		fee = schedule.MaxFee
+	}
+	return fee, nil
+}
+
+// chargeFee moves the fee from the locked account to the fee_revenue account of its currency as a separate posted
+// transfer, feeOf links a transfer fee to the charged transfer. The fee_revenue account is never a source of the
+// transfers initiated by users, so it is always locked as the last account and can't cause a deadlock.
+func chargeFee(ctx context.Context, q *Queries, account Account, amount int64, kind string, feeOf sql.NullInt64) (TransferTxResult, error) {
+	revenueAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Currency: account.Currency,
+		Type:     util.AccountFeeRevenue,
+	})
+	if err != nil {
+		return TransferTxResult{}, fmt.Errorf("cannot get the %s fee revenue account: %w", account.Currency, err)
+	}
+
+	feeTransfer, err := q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: account.ID,
+		ToAccountID:   revenueAccount.ID,
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  sameCurrencyRate,
+		Status:        util.TransferPosted,
+		FeeKind:       sql.NullString{String: kind, Valid: true},
+		FeeOf:         feeOf,
+	})
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return postTransfer(ctx, q, feeTransfer)
+}
+
+// ChargeMaintenanceFeeTxParams contains the account with a month-end balance below the minimum balance
+type ChargeMaintenanceFeeTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// first day of the charged month
+	Month time.Time `json:"month"`
+	// balance at the end of the month
+	Balance int64 `json:"balance"`
+}
+
+// ChargeMaintenanceFeeTxResult contains the recorded maintenance fee and the transfer which paid it
+type ChargeMaintenanceFeeTxResult struct {
+	Fee MaintenanceFee `json:"fee"`
+	// nil if nothing was charged
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// ChargeMaintenanceFeeTx charges the maintenance fee of the month to the account. The fee never overdraws
+// the account, an account without enough money pays only its available balance without the overdraft limit.
+// Owners with the maintenance fee waived are recorded with a zero amount, so they are not checked again.
+// The fee is unique per account and month, a concurrent transaction charging the same month fails
+// with ErrFeeCharged and its transfer is rolled back.
+func (store *SQLStore) ChargeMaintenanceFeeTx(ctx context.Context, arg ChargeMaintenanceFeeTxParams) (ChargeMaintenanceFeeTxResult, error) {
+	var result ChargeMaintenanceFeeTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkAccountActive(account); err != nil {
+			return err
+		}
+
+		schedule, err := q.GetFeeSchedule(ctx, GetFeeScheduleParams{
+			Kind:     util.FeeKindMaintenance,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		waived, err := feeWaived(ctx, q, account.Owner, util.FeeKindMaintenance)
+		if err != nil {
+			return err
+		}
+
+		var amount int64
+		if !waived {
+			amount = schedule.FlatAmount
+
+			reservedAmount, err := q.GetAccountReservedAmount(ctx, account.ID)
+			if err != nil {
+				return err
+			}
+			// poplatek účet nepřetáhne do debetu, strhne se jen to, co na něm zbývá
+			if available := account.Balance - reservedAmount; available < amount {
+				amount = available
+			}
+			if amount < 0 {
+				amount = 0
+			}
+		}
+
+		var transferID sql.NullInt64
+		if amount > 0 {
+			transferResult, err := chargeFee(ctx, q, account, amount, util.FeeKindMaintenance, sql.NullInt64{})
+			if err != nil {
+				return err
+			}
+			result.Transfer = &transferResult
+			transferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+		}
+
+		result.Fee, err = q.CreateMaintenanceFee(ctx, CreateMaintenanceFeeParams{
+			AccountID:  account.ID,
+			Month:      arg.Month,
+			Balance:    arg.Balance,
+			Amount:     amount,
+			Waived:     waived,
+			TransferID: transferID,
+		})
+		if err == sql.ErrNoRows {
+			return ErrFeeCharged
+		}
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,328 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFee(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "Flat",
+			schedule: FeeSchedule{Method: util.FeeFlat, FlatAmount: 25},
+			amount:   100000,
+			fee:      25,
+		},
+		{
+			name:     "Percentage",
+			schedule: FeeSchedule{Method: util.FeePercentage, Rate: "0.00500000"},
+			amount:   12345,
+			// 61.725 se zaokrouhlí dolů
+			fee: 61,
+		},
+		{
+			name:     "MinFee",
+			schedule: FeeSchedule{Method: util.FeePercentage, Rate: "0.00500000", MinFee: 100},
+			amount:   12345,
+			fee:      100,
+		},
+		{
+			name:     "MaxFee",
+			schedule: FeeSchedule{Method: util.FeePercentage, Rate: "0.00500000", MaxFee: 50},
+			amount:   12345,
+			fee:      50,
+		},
+		{
+			name:     "UnlimitedMaxFee",
+			schedule: FeeSchedule{Method: util.FeePercentage, Rate: "0.01000000", MinFee: 10},
+			amount:   100000000,
+			fee:      1000000,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := computeFee(tc.schedule, tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+
+	_, err := computeFee(FeeSchedule{Method: "tiered"}, 100)
+	require.Error(t, err)
+}
+
+// setFeeSchedule sets the fee schedule for the test and removes it when the test finishes, the schedules
+// are shared by all accounts of the currency
+func setFeeSchedule(t *testing.T, arg UpsertFeeScheduleParams) {
+	if arg.Rate == "" {
+		arg.Rate = "0"
+	}
+
+	_, err := testQueries.UpsertFeeSchedule(context.Background(), arg)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteFeeSchedule(context.Background(), DeleteFeeScheduleParams{
+			Kind:     arg.Kind,
+			Currency: arg.Currency,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 10000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:     util.FeeKindTransfer,
+		Currency: fromAccount.Currency,
+		Method:   util.FeePercentage,
+		Rate:     "0.01000000",
+		MinFee:   20,
+	})
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        5000,
+		ChargeFee:     true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(50), result.Transfer.Fee)
+	require.Equal(t, int64(10000-5000-50), result.FromAccount.Balance)
+	require.Equal(t, int64(5000), result.ToAccount.Balance)
+
+	require.NotNil(t, result.FeeTransfer)
+	require.Equal(t, int64(50), result.FeeTransfer.Amount)
+	require.Equal(t, result.Transfer.ID, result.FeeTransfer.FeeOf.Int64)
+	require.Equal(t, util.FeeKindTransfer, result.FeeTransfer.FeeKind.String)
+
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, fromAccount.ID, result.FeeEntry.AccountID)
+	require.Equal(t, int64(-50), result.FeeEntry.Amount)
+	require.Equal(t, result.FeeTransfer.ID, result.FeeEntry.TransferID.Int64)
+
+	revenueAccount, err := store.GetAccount(context.Background(), result.FeeTransfer.ToAccountID)
+	require.NoError(t, err)
+	require.Equal(t, util.SystemUser, revenueAccount.Owner)
+	require.Equal(t, util.AccountFeeRevenue, revenueAccount.Type)
+
+	// bez poplatku se převádí i s plánem poplatků, pokud o něj volající nežádá
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.Fee)
+	require.Nil(t, result.FeeTransfer)
+	require.Nil(t, result.FeeEntry)
+
+	// poplatek se počítá do dostupných peněz
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        4850,
+		ChargeFee:     true,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxFeeWaived(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+	banker := createRandomUser(t)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:       util.FeeKindTransfer,
+		Currency:   fromAccount.Currency,
+		Method:     util.FeeFlat,
+		FlatAmount: 30,
+	})
+
+	_, err := store.UpsertFeeWaiver(context.Background(), UpsertFeeWaiverParams{
+		Username: fromAccount.Owner,
+		Kind:     util.FeeKindTransfer,
+		WaivedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		ChargeFee:     true,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.Fee)
+	require.Nil(t, result.FeeTransfer)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestApproveTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+	banker := createRandomUser(t)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:       util.FeeKindTransfer,
+		Currency:   fromAccount.Currency,
+		Method:     util.FeeFlat,
+		FlatAmount: 10,
+	})
+
+	pending, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            500,
+		ApprovalThreshold: 100,
+		ChargeFee:         true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, pending.Transfer.Status)
+	require.Equal(t, int64(10), pending.Transfer.Fee)
+	require.Nil(t, pending.FeeTransfer)
+
+	// čekající převod blokuje i svůj poplatek
+	reserved, err := store.GetAccountReservedAmount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(510), reserved)
+
+	result, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.FeeTransfer)
+	require.Equal(t, int64(10), result.FeeTransfer.Amount)
+	require.Equal(t, int64(1000-500-10), result.FromAccount.Balance)
+}
+
+func TestTransferBatchTxFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:       util.FeeKindTransfer,
+		Currency:   fromAccount.Currency,
+		Method:     util.FeeFlat,
+		FlatAmount: 10,
+	})
+
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.BatchBestEffort,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: toAccount.ID, Amount: 500},
+			{ToAccountID: toAccount.ID, Amount: 300},
+			// zbývá 180, položka i s poplatkem potřebuje 200
+			{ToAccountID: toAccount.ID, Amount: 190},
+		},
+		ChargeFee: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), result.Batch.SucceededCount)
+	require.Equal(t, int64(800), result.Batch.TotalAmount)
+	require.Equal(t, int64(1000-800-20), result.FromAccount.Balance)
+	require.Equal(t, util.BatchItemFailed, result.Items[2].Status)
+
+	for _, item := range result.Items[:2] {
+		transfer, err := store.GetTransfer(context.Background(), item.TransferID.Int64)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), transfer.Fee)
+	}
+
+	toAccount, err = store.GetAccount(context.Background(), toAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(800), toAccount.Balance)
+}
+
+func TestChargeMaintenanceFeeTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 30)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:       util.FeeKindMaintenance,
+		Currency:   account.Currency,
+		Method:     util.FeeFlat,
+		FlatAmount: 50,
+		MinBalance: 10000,
+	})
+	month := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// účet má méně než poplatek, strhne se jen to, co na něm je
+	result, err := store.ChargeMaintenanceFeeTx(context.Background(), ChargeMaintenanceFeeTxParams{
+		AccountID: account.ID,
+		Month:     month,
+		Balance:   account.Balance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(30), result.Fee.Amount)
+	require.False(t, result.Fee.Waived)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Fee.TransferID.Int64)
+	require.Equal(t, util.FeeKindMaintenance, result.Transfer.Transfer.FeeKind.String)
+	require.False(t, result.Transfer.Transfer.FeeOf.Valid)
+	require.Zero(t, result.Transfer.FromAccount.Balance)
+
+	// stejný měsíc se podruhé nestrhne
+	_, err = store.ChargeMaintenanceFeeTx(context.Background(), ChargeMaintenanceFeeTxParams{
+		AccountID: account.ID,
+		Month:     month,
+		Balance:   account.Balance,
+	})
+	require.ErrorIs(t, err, ErrFeeCharged)
+}
+
+func TestChargeMaintenanceFeeTxWaived(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 100)
+	banker := createRandomUser(t)
+	setFeeSchedule(t, UpsertFeeScheduleParams{
+		Kind:       util.FeeKindMaintenance,
+		Currency:   account.Currency,
+		Method:     util.FeeFlat,
+		FlatAmount: 50,
+		MinBalance: 10000,
+	})
+
+	_, err := store.UpsertFeeWaiver(context.Background(), UpsertFeeWaiverParams{
+		Username: account.Owner,
+		Kind:     util.FeeKindMaintenance,
+		WaivedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ChargeMaintenanceFeeTx(context.Background(), ChargeMaintenanceFeeTxParams{
+		AccountID: account.ID,
+		Month:     time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Balance:   account.Balance,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Fee.Waived)
+	require.Zero(t, result.Fee.Amount)
+	require.Nil(t, result.Transfer)
+
+	got, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, got.Balance)
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertFxRate(t *testing.T) {
+	arg := UpsertFxRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.92",
+	}
+
+	rate1, err := testQueries.UpsertFxRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "0.92000000", rate1.Rate)
+	require.NotZero(t, rate1.UpdatedAt)
+
+	// druhý upsert stejného páru kurz přepíše
+	arg.Rate = "0.93"
+	rate2, err := testQueries.UpsertFxRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "0.93000000", rate2.Rate)
+
+	rate3, err := testQueries.GetFxRate(context.Background(), GetFxRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate2.Rate, rate3.Rate)
+
+	_, err = testQueries.UpsertFxRate(context.Background(), UpsertFxRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.CAD,
+		Rate:         "0",
+	})
+	require.Error(t, err)
+}
+
+func TestFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.92000000",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	quote1, err := testQueries.CreateFxQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, quote1.ID)
+	require.Equal(t, arg.Username, quote1.Username)
+	require.Equal(t, arg.Rate, quote1.Rate)
+	require.WithinDuration(t, arg.ExpiresAt, quote1.ExpiresAt, time.Second)
+
+	quote2, err := testQueries.GetFxQuote(context.Background(), arg.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+
+	_, err = testQueries.GetFxQuote(context.Background(), uuid.New())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
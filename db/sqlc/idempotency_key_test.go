@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotencyKey(t *testing.T, username string, expiresAt time.Time) IdempotencyKey {
+	arg := CreateIdempotencyKeyParams{
+		Username:       username,
+		Key:            util.RandomString(16),
+		RequestHash:    util.RandomString(64),
+		ResponseStatus: 200,
+		ResponseBody:   json.RawMessage(`{"id":1}`),
+		ExpiresAt:      expiresAt,
+	}
+
+	key, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.Key, key.Key)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.Equal(t, arg.ResponseStatus, key.ResponseStatus)
+	require.JSONEq(t, string(arg.ResponseBody), string(key.ResponseBody))
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+	require.NotZero(t, key.CreatedAt)
+
+	return key
+}
+
+func TestGetIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	key1 := createRandomIdempotencyKey(t, user.Username, time.Now().Add(time.Minute))
+
+	key2, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      key1.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, key1.RequestHash, key2.RequestHash)
+	require.JSONEq(t, string(key1.ResponseBody), string(key2.ResponseBody))
+
+	// klíče jsou oddělené pro každého uživatele
+	otherUser := createRandomUser(t)
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: otherUser.Username,
+		Key:      key1.Key,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateIdempotencyKeyConflict(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomIdempotencyKey(t, user.Username, time.Now().Add(time.Minute))
+
+	// live key can't be overwritten
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:       user.Username,
+		Key:            key.Key,
+		RequestHash:    util.RandomString(64),
+		ResponseStatus: 200,
+		ResponseBody:   json.RawMessage(`{}`),
+		ExpiresAt:      time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExpiredIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomIdempotencyKey(t, user.Username, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      key.Key,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// expired key can be reused before it is deleted
+	reused, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:       user.Username,
+		Key:            key.Key,
+		RequestHash:    util.RandomString(64),
+		ResponseStatus: 200,
+		ResponseBody:   json.RawMessage(`{}`),
+		ExpiresAt:      time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, key.RequestHash, reused.RequestHash)
+
+	expired := createRandomIdempotencyKey(t, user.Username, time.Now().Add(-time.Minute))
+	err = testQueries.DeleteExpiredIdempotencyKeys(context.Background())
+	require.NoError(t, err)
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      reused.Key,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:       user.Username,
+		Key:            expired.Key,
+		RequestHash:    util.RandomString(64),
+		ResponseStatus: 200,
+		ResponseBody:   json.RawMessage(`{}`),
+		ExpiresAt:      time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// ErrInterestAccrued is returned by AccrueInterestTx when the interest of the date is already accrued
+var ErrInterestAccrued = errors.New("interest is already accrued")
+
+// ErrInterestPosted is returned by PostInterestTx when the interest of the month is already posted
+var ErrInterestPosted = errors.New("interest is already posted")
+
+// AccrueInterestTxParams contains the interest of all interest bearing accounts for one day
+type AccrueInterestTxParams struct {
+	// UTC day of the accrual
+	Date     time.Time                     `json:"date"`
+	Accruals []CreateInterestAccrualParams `json:"accruals"`
+}
+
+// AccrueInterestTx saves the accruals of the date together with the run which marks the whole date as accrued.
+// The run is inserted first, so a concurrent transaction with the same date waits for its commit
+// and then fails with ErrInterestAccrued without saving anything.
+func (store *SQLStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (InterestAccrualRun, error) {
+	var run InterestAccrualRun
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		run, err = q.CreateInterestAccrualRun(ctx, CreateInterestAccrualRunParams{
+			AccrualDate:  arg.Date,
+			AccountCount: int32(len(arg.Accruals)),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInterestAccrued
+			}
+			return err
+		}
+
+		for _, accrual := range arg.Accruals {
+			if err := q.CreateInterestAccrual(ctx, accrual); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return run, err
+}
+
+// PostInterestTxParams contains the input parameters of the monthly interest posting
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// first day of the posted month
+	Month time.Time `json:"month"`
+}
+
+// PostInterestTxResult contains the posting and the transfer which paid the interest
+type PostInterestTxResult struct {
+	Posting InterestPosting `json:"posting"`
+	// nil if nothing was paid
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// PostInterestTx pays the interest accrued in the month together with the carry of the previous posting.
+// Only whole minor units are paid by a transfer from the interest_expense account of the currency, the remaining
+// fraction is carried to the next posting, so no interest is lost by rounding. Frozen accounts can't receive money,
+// their whole interest is carried until a posting after they are unfrozen, closed accounts lose it. The posting
+// is unique per account and month, a concurrent transaction posting the same month fails with ErrInterestPosted
+// and its transfer is rolled back.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		accrued := new(big.Rat)
+
+		last, err := q.GetLastInterestPosting(ctx, GetLastInterestPostingParams{
+			AccountID: arg.AccountID,
+			Month:     arg.Month,
+		})
+		switch {
+		case err == nil:
+			if err := addFraction(accrued, last.Carry); err != nil {
+				return err
+			}
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		accruals, err := q.ListInterestAccruals(ctx, ListInterestAccrualsParams{
+			AccountID: arg.AccountID,
+			FromDate:  arg.Month,
+			ToDate:    arg.Month.AddDate(0, 1, 0),
+		})
+		if err != nil {
+			return err
+		}
+		for _, accrual := range accruals {
+			if err := addFraction(accrued, accrual.Amount); err != nil {
+				return err
+			}
+		}
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		// vyplácí se jen celé haléře, zbytek se přičte k úrokům dalšího měsíce
+		var amount int64
+		if account.Status == util.AccountActive {
+			amount = new(big.Int).Quo(accrued.Num(), accrued.Denom()).Int64()
+		}
+		carry := new(big.Rat).Sub(accrued, new(big.Rat).SetInt64(amount))
+
+		var transferID sql.NullInt64
+		if amount > 0 {
+			expenseAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+				Currency: account.Currency,
+				Type:     util.AccountInterestExpense,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot get the %s interest expense account: %w", account.Currency, err)
+			}
+
+			transferResult, err := transfer(ctx, q, TransferTxParams{
+				FromAccountID: expenseAccount.ID,
+				ToAccountID:   account.ID,
+				Amount:        amount,
+			}, sql.NullInt64{})
+			if err != nil {
+				return err
+			}
+			result.Transfer = &transferResult
+			transferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+		}
+
+		result.Posting, err = q.CreateInterestPosting(ctx, CreateInterestPostingParams{
+			AccountID:  arg.AccountID,
+			Month:      arg.Month,
+			Accrued:    accrued.RatString(),
+			Amount:     amount,
+			Carry:      carry.RatString(),
+			TransferID: transferID,
+		})
+		if err == sql.ErrNoRows {
+			return ErrInterestPosted
+		}
+		return err
+	})
+
+	return result, err
+}
+
+// addFraction adds the exact fraction saved by the interest accrual or posting to sum
+func addFraction(sum *big.Rat, fraction string) error {
+	r, ok := new(big.Rat).SetString(fraction)
+	if !ok {
+		return fmt.Errorf("invalid interest amount %q", fraction)
+	}
+
+	sum.Add(sum, r)
+	return nil
+}
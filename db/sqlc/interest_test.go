@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomInterestMonth returns the first day of a random month in the past, the accrual runs are unique
+// per date in the whole database, so the tests can't use the current dates
+func randomInterestMonth() time.Time {
+	return time.Date(int(util.RandomInt(1900, 1999)), time.Month(util.RandomInt(1, 11)), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAccrueInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 100000)
+	date := randomInterestMonth().AddDate(0, 0, int(util.RandomInt(0, 27)))
+
+	arg := AccrueInterestTxParams{
+		Date: date,
+		Accruals: []CreateInterestAccrualParams{
+			{
+				AccountID:   account.ID,
+				AccrualDate: date,
+				Balance:     account.Balance,
+				AnnualRate:  "0.01500000",
+				DayCount:    util.DayCountActual365,
+				Amount:      "300/73",
+			},
+		},
+	}
+
+	run, err := store.AccrueInterestTx(context.Background(), arg)
+	if err == ErrInterestAccrued {
+		t.Skip("the random date is already accrued")
+	}
+	require.NoError(t, err)
+	require.True(t, date.Equal(run.AccrualDate))
+	require.Equal(t, int32(1), run.AccountCount)
+
+	// stejný den se podruhé nenaúročí
+	_, err = store.AccrueInterestTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInterestAccrued)
+
+	accruals, err := store.ListInterestAccruals(context.Background(), ListInterestAccrualsParams{
+		AccountID: account.ID,
+		FromDate:  date,
+		ToDate:    date.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, accruals, 1)
+	require.Equal(t, "300/73", accruals[0].Amount)
+	require.Equal(t, "0.01500000", accruals[0].AnnualRate)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 100000)
+	month := randomInterestMonth()
+
+	// 3 x 300/73 = 900/73 = 12 + 24/73
+	for i := 0; i < 3; i++ {
+		err := store.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+			AccountID:   account.ID,
+			AccrualDate: month.AddDate(0, 0, i),
+			Balance:     account.Balance,
+			AnnualRate:  "0.01500000",
+			DayCount:    util.DayCountActual365,
+			Amount:      "300/73",
+		})
+		require.NoError(t, err)
+	}
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     month,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "900/73", result.Posting.Accrued)
+	require.Equal(t, int64(12), result.Posting.Amount)
+	require.Equal(t, "24/73", result.Posting.Carry)
+
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Posting.TransferID.Int64)
+	require.Equal(t, int64(12), result.Transfer.Transfer.Amount)
+	require.Equal(t, account.ID, result.Transfer.ToAccount.ID)
+	require.Equal(t, account.Balance+12, result.Transfer.ToAccount.Balance)
+	require.Equal(t, util.SystemUser, result.Transfer.FromAccount.Owner)
+	require.Equal(t, util.AccountInterestExpense, result.Transfer.FromAccount.Type)
+	require.Equal(t, account.Currency, result.Transfer.FromAccount.Currency)
+	require.Equal(t, int64(12), result.Transfer.ToEntry.Amount)
+
+	// stejný měsíc se podruhé nevyplatí
+	_, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     month,
+	})
+	require.ErrorIs(t, err, ErrInterestPosted)
+
+	// zbytek se přičte k úrokům dalšího měsíce: 24/73 + 50/73 = 1 + 1/73
+	nextMonth := month.AddDate(0, 1, 0)
+	err = store.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:   account.ID,
+		AccrualDate: nextMonth,
+		Balance:     account.Balance,
+		AnnualRate:  "0.01500000",
+		DayCount:    util.DayCountActual365,
+		Amount:      "50/73",
+	})
+	require.NoError(t, err)
+
+	result, err = store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     nextMonth,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "74/73", result.Posting.Accrued)
+	require.Equal(t, int64(1), result.Posting.Amount)
+	require.Equal(t, "1/73", result.Posting.Carry)
+}
+
+func TestPostInterestTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccountWithBalance(t, 100000)
+	month := randomInterestMonth()
+
+	err := store.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:   account.ID,
+		AccrualDate: month,
+		Balance:     account.Balance,
+		AnnualRate:  "0.50000000",
+		DayCount:    util.DayCount30360,
+		Amount:      "1250/9",
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: util.AccountFrozen,
+	})
+	require.NoError(t, err)
+
+	// zmrazený účet nic nedostane, celý úrok počká na další výplatu
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Month:     month,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer)
+	require.False(t, result.Posting.TransferID.Valid)
+	require.Zero(t, result.Posting.Amount)
+	require.Equal(t, "1250/9", result.Posting.Carry)
+
+	got, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, got.Balance)
+}
+
+func TestListInterestBearingBalances(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	account, err := store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.USD,
+		Type:     util.AccountSavings,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: account.ID, Amount: 500})
+	require.NoError(t, err)
+
+	// zůstatek na konci dne se počítá bez záznamů, které přišly po půlnoci
+	dayEnd := time.Now()
+	_, err = store.DepositTx(context.Background(), DepositTxParams{AccountID: account.ID, Amount: 300})
+	require.NoError(t, err)
+
+	balances, err := store.ListInterestBearingBalances(context.Background(), dayEnd)
+	require.NoError(t, err)
+
+	var found bool
+	for _, balance := range balances {
+		if balance.AccountID == account.ID {
+			found = true
+			require.Equal(t, int64(500), balance.Balance)
+			require.Equal(t, "0.01500000", balance.AnnualRate)
+			require.Equal(t, util.DayCountActual365, balance.DayCount)
+		}
+	}
+	require.True(t, found)
+}
@@ -3,7 +3,11 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -12,6 +16,42 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// active, frozen or closed, only active accounts can send and receive money
+	Status string `json:"status"`
+	// checking, savings or business, the system user has cash, interest_expense and fee_revenue accounts
+	Type string `json:"type"`
+	// how much the balance of the checking account can go below zero, in minor units of the currency
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// maximum number of outgoing transfers since the start of the UTC month, zero is unlimited
+	MaxMonthlyTransfers int32 `json:"max_monthly_transfers"`
+}
+
+type AccountHold struct {
+	ID          int64  `json:"id"`
+	AccountID   int64  `json:"account_id"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+	// active, captured, released or expired, only active holds reduce the available balance
+	Status string `json:"status"`
+	// the hold stops reducing the available balance at this time even before it is marked as expired
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedBy string    `json:"created_by"`
+	// the transfer which captured the hold
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// the captured part of the amount, the rest is released
+	CapturedAmount int64        `json:"captured_amount"`
+	ClosedAt       sql.NullTime `json:"closed_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// users who share the account, the owner of the account is always its member with the owner role
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	// owner, co-owner, viewer, can-initiate or needs-approval
+	Role      string    `json:"role"`
+	AddedBy   string    `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -20,15 +60,298 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the transfer which created the entry, entries without it are reported by the reconciliation
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+type FeeSchedule struct {
+	// transfer or maintenance
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+	// flat or percentage, maintenance fees are always flat
+	Method     string `json:"method"`
+	FlatAmount int64  `json:"flat_amount"`
+	// part of the transferred amount charged by the percentage method, e.g. 0.005 is 0.5 %
+	Rate   string `json:"rate"`
+	MinFee int64  `json:"min_fee"`
+	// zero is unlimited
+	MaxFee int64 `json:"max_fee"`
+	// the maintenance fee is charged to the accounts with a lower balance at the end of the month
+	MinBalance int64     `json:"min_balance"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type FeeWaiver struct {
+	Username string `json:"username"`
+	// the user pays no fees of this kind
+	Kind      string    `json:"kind"`
+	WaivedBy  string    `json:"waived_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type FxRate struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// units of to_currency paid for one unit of from_currency
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	// the value of the Idempotency-Key header, unique per user
+	Key string `json:"key"`
+	// sha256 of the route and the request body
+	RequestHash    string          `json:"request_hash"`
+	ResponseStatus int32           `json:"response_status"`
+	ResponseBody   json.RawMessage `json:"response_body"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// balance at the end of the UTC day
+	Balance    int64  `json:"balance"`
+	AnnualRate string `json:"annual_rate"`
+	DayCount   string `json:"day_count"`
+	// interest of the day in minor units as an exact fraction, e.g. 123/73
+	Amount    string    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// the dates whose interest was already accrued for all accounts
+type InterestAccrualRun struct {
+	AccrualDate  time.Time `json:"accrual_date"`
+	AccountCount int32     `json:"account_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month
+	Month time.Time `json:"month"`
+	// interest accrued in the month together with the carry of the previous posting, exact fraction of minor units
+	Accrued string `json:"accrued"`
+	// whole minor units paid to the account
+	Amount int64 `json:"amount"`
+	// fraction of the accrued interest left for the next posting
+	Carry string `json:"carry"`
+	// transfer from the interest_expense account, null when nothing was paid
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type InterestRate struct {
+	AccountType string `json:"account_type"`
+	Currency    string `json:"currency"`
+	// e.g. 0.015 is 1.5 % per year
+	AnnualRate string `json:"annual_rate"`
+	// act/365 or 30/360
+	DayCount  string    `json:"day_count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MaintenanceFee struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the charged month
+	Month time.Time `json:"month"`
+	// balance at the end of the month
+	Balance int64 `json:"balance"`
+	// charged amount, lower than the fee when the account had not enough money
+	Amount     int64         `json:"amount"`
+	Waived     bool          `json:"waived"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID        int64  `json:"id"`
+	Requester string `json:"requester"`
+	Payer     string `json:"payer"`
+	// the account of the requester in the currency, it receives the money
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	// open, paid, declined, cancelled or expired
+	Status string `json:"status"`
+	// the transfer which paid the request
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	DecidedAt  sql.NullTime  `json:"decided_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ReconciliationIssue struct {
+	ID    int64 `json:"id"`
+	RunID int64 `json:"run_id"`
+	// balance_drift, orphan_entries or unbalanced_transfer
+	Kind       string        `json:"kind"`
+	AccountID  sql.NullInt64 `json:"account_id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	Expected   int64         `json:"expected"`
+	Actual     int64         `json:"actual"`
+	Details    string        `json:"details"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID int64 `json:"id"`
+	// running, ok, drift or failed
+	Status           string       `json:"status"`
+	AccountsChecked  int64        `json:"accounts_checked"`
+	TransfersChecked int64        `json:"transfers_checked"`
+	IssuesFound      int64        `json:"issues_found"`
+	Error            string       `json:"error"`
+	StartedAt        time.Time    `json:"started_at"`
+	FinishedAt       sql.NullTime `json:"finished_at"`
+}
+
+type RevokedToken struct {
+	// the ID of the revoked token payload
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// it must be positive, both accounts have the same currency
+	Amount int64 `json:"amount"`
+	// once, daily, weekly or monthly
+	Recurrence string    `json:"recurrence"`
+	StartAt    time.Time `json:"start_at"`
+	// no occurrence after it is executed, null repeats the transfer forever
+	EndAt sql.NullTime `json:"end_at"`
+	// the next occurrence or the next retry of the current one
+	NextRunAt time.Time `json:"next_run_at"`
+	// number of finished occurrences, the next occurrence is computed from start_at and runs
+	Runs int32 `json:"runs"`
+	// number of failed attempts of the current occurrence
+	Attempts  int32     `json:"attempts"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransferExecution struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	OccurrenceAt        time.Time `json:"occurrence_at"`
+	Attempt             int32     `json:"attempt"`
+	// succeeded, retrying or failed
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	Error      string        `json:"error"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// it must be positive
+	// it must be positive, in the currency of from_account
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount converted to the currency of to_account
+	ToAmount     int64  `json:"to_amount"`
+	ExchangeRate string `json:"exchange_rate"`
+	// the reversed transfer, reversals go from its to_account back to its from_account
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+	// sum of the reversals of the transfer, in the currency of to_account
+	ReversedAmount int64 `json:"reversed_amount"`
+	// pending, posted, rejected or cancelled, only posted transfers have entries
+	Status string `json:"status"`
+	// the user who approved, rejected or cancelled the pending transfer
+	DecidedBy sql.NullString `json:"decided_by"`
+	DecidedAt sql.NullTime   `json:"decided_at"`
+	// transfer fee paid by from_account, it is posted as a separate fee transfer together with the transfer
+	Fee int64 `json:"fee"`
+	// transfer or maintenance for the fee transfers, they don't count into the transfer limits
+	FeeKind sql.NullString `json:"fee_kind"`
+	// the transfer whose fee is paid by this fee transfer
+	FeeOf sql.NullInt64 `json:"fee_of"`
+	// the account member who sent the transfer and whose transfer limits it counts into, null for the system and fee transfers
+	InitiatedBy sql.NullString `json:"initiated_by"`
+	// the transfer was sent by a member who needs approval, so the owner or a co-owner of the account must authorize it
+	MemberApproval bool `json:"member_approval"`
+	// the owner or co-owner who authorized the transfer of the member, null until it is authorized
+	AuthorizedBy sql.NullString `json:"authorized_by"`
+}
+
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	// all_or_nothing or best_effort
+	Mode string `json:"mode"`
+	// completed, partially_completed or failed
+	Status         string `json:"status"`
+	ItemCount      int32  `json:"item_count"`
+	SucceededCount int32  `json:"succeeded_count"`
+	FailedCount    int32  `json:"failed_count"`
+	// sum of the amounts of the succeeded items
+	TotalAmount int64     `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TransferBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// index of the item in the request, starting from 0
+	Position int32 `json:"position"`
+	// not a foreign key, the item with a missing account is only failed
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+	// posted, pending, failed or skipped
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	Error      string        `json:"error"`
+}
+
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// set for the limits of the user, the limits of an account have it null
+	Owner sql.NullString `json:"owner"`
+	// set for the limits of the account, the limits of a user have it null
+	AccountID sql.NullInt64 `json:"account_id"`
+	Currency  string        `json:"currency"`
+	// in minor units of the currency, zero is unlimited
+	PerTransaction int64 `json:"per_transaction"`
+	// sum of the outgoing transfers since the start of the UTC day, zero is unlimited
+	Daily int64 `json:"daily"`
+	// sum of the outgoing transfers since the start of the UTC month, zero is unlimited
+	Monthly   int64     `json:"monthly"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -38,4 +361,12 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// depositor or banker
+	Role string `json:"role"`
+}
+
+type UserTokenRevocation struct {
+	Username string `json:"username"`
+	// every token of the user issued before this time is revoked
+	RevokedBefore time.Time `json:"revoked_before"`
 }
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// AcceptPaymentRequestTxParams contains the input parameters of the payment request acceptance
+type AcceptPaymentRequestTxParams struct {
+	PaymentRequestID int64 `json:"payment_request_id"`
+	// account of the payer which pays the request, it must have the currency of the request
+	FromAccountID int64 `json:"from_account_id"`
+	// optional, the payment must fit into the transfer limits like the transfers created by the payer
+	Limits DefaultTransferLimits `json:"-"`
+	// optional, payments with a larger amount create pending transfers waiting for the approval of a banker
+	ApprovalThreshold int64 `json:"-"`
+	// optional, the payer pays the transfer fee like with the transfers created by the payer
+	ChargeFee bool `json:"-"`
+	// optional, the payer is an account member whose transfers need approval, the payment is created
+	// as pending regardless of its amount and waits for the authorization of the account owner or a co-owner
+	RequireApproval bool `json:"-"`
+}
+
+// AcceptPaymentRequestTxResult contains the paid request and the transfer which paid it
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest `json:"payment_request"`
+	TransferTxResult
+}
+
+// AcceptPaymentRequestTx pays the open payment request by a transfer from the account of the payer to the account
+// of the requester. The request is locked before the accounts, like the transfer in ApproveTransferTx, so it can't
+// be paid twice nor declined while it is being paid. A payment above the approval threshold only makes the request
+// pending with the pending transfer, the request becomes paid when a banker approves the transfer and open again
+// (or expired if it expired meanwhile) when the transfer is rejected or cancelled. It returns ErrPaymentRequestNotOpen if the request was already
+// decided or it expired.
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		request, err := q.GetPaymentRequestForUpdate(ctx, arg.PaymentRequestID)
+		if err != nil {
+			return err
+		}
+
+		if request.Status != util.PaymentRequestOpen {
+			return fmt.Errorf("%w: payment request %d is %s", ErrPaymentRequestNotOpen, request.ID, request.Status)
+		}
+		// žádost přestává platit expirací, i když ji ještě nikdo neoznačil jako expirovanou
+		if !request.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: payment request %d expired at %s", ErrPaymentRequestNotOpen, request.ID, request.ExpiresAt)
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID:     arg.FromAccountID,
+			ToAccountID:       request.ToAccountID,
+			Amount:            request.Amount,
+			Limits:            arg.Limits,
+			ApprovalThreshold: arg.ApprovalThreshold,
+			ChargeFee:         arg.ChargeFee,
+			RequireApproval:   arg.RequireApproval,
+			InitiatedBy:       request.Payer,
+		}, sql.NullInt64{})
+		if err != nil {
+			return err
+		}
+
+		// čekající převod zatím žádné peníze nepřevedl, takže žádost ještě není zaplacená
+		status := util.PaymentRequestPaid
+		if result.Transfer.Status == util.TransferPending {
+			status = util.PaymentRequestPending
+		}
+
+		result.PaymentRequest, err = q.PayPaymentRequest(ctx, PayPaymentRequestParams{
+			Status:     status,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+			ID:         request.ID,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, payer string, toAccount Account, amount int64, expiresAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       payer,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		Currency:    toAccount.Currency,
+		Description: util.RandomString(10),
+		ExpiresAt:   expiresAt,
+	}
+
+	request, err := testQueries.CreatePaymentRequest(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, request.ID)
+	require.Equal(t, arg.Requester, request.Requester)
+	require.Equal(t, arg.Payer, request.Payer)
+	require.Equal(t, arg.ToAccountID, request.ToAccountID)
+	require.Equal(t, arg.Amount, request.Amount)
+	require.Equal(t, util.PaymentRequestOpen, request.Status)
+	require.False(t, request.TransferID.Valid)
+	require.False(t, request.DecidedAt.Valid)
+
+	return request
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountWithBalance(t, 0)
+	request := createRandomPaymentRequest(t, fromAccount.Owner, toAccount, 400, time.Now().Add(time.Hour))
+
+	result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestPaid, result.PaymentRequest.Status)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.PaymentRequest.TransferID)
+	require.True(t, result.PaymentRequest.DecidedAt.Valid)
+	require.Equal(t, util.TransferPosted, result.Transfer.Status)
+	require.Equal(t, int64(600), result.FromAccount.Balance)
+	require.Equal(t, int64(400), result.ToAccount.Balance)
+
+	// zaplacenou žádost nejde zaplatit znovu ani odmítnout
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotOpen)
+
+	_, err = store.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		Status: util.PaymentRequestDeclined,
+		ID:     request.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAcceptPaymentRequestTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 100)
+	toAccount := createRandomAccountWithBalance(t, 0)
+	request := createRandomPaymentRequest(t, fromAccount.Owner, toAccount, 400, time.Now().Add(time.Hour))
+
+	_, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nepovedená platba žádost nechá otevřenou
+	request, err = store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestOpen, request.Status)
+}
+
+func TestAcceptPaymentRequestTxPendingTransfer(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountWithBalance(t, 0)
+	request := createRandomPaymentRequest(t, fromAccount.Owner, toAccount, 700, time.Now().Add(time.Hour))
+
+	accept := func() AcceptPaymentRequestTxResult {
+		result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+			PaymentRequestID:  request.ID,
+			FromAccountID:     fromAccount.ID,
+			ApprovalThreshold: 500,
+		})
+		require.NoError(t, err)
+		require.Equal(t, util.TransferPending, result.Transfer.Status)
+		require.Equal(t, util.PaymentRequestPending, result.PaymentRequest.Status)
+		require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.PaymentRequest.TransferID)
+		return result
+	}
+
+	// zrušený převod žádost nezaplatil, takže je znovu otevřená
+	result := accept()
+	cancelled, err := store.DecideTransferTx(context.Background(), DecideTransferParams{
+		Status:    util.TransferCancelled,
+		DecidedBy: sql.NullString{String: fromAccount.Owner, Valid: true},
+		ID:        result.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferCancelled, cancelled.Status)
+
+	reopened, err := store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestOpen, reopened.Status)
+	require.False(t, reopened.TransferID.Valid)
+	require.False(t, reopened.DecidedAt.Valid)
+
+	// schválený převod žádost zaplatí
+	result = accept()
+	banker := createRandomUser(t)
+	approved, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: result.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, approved.Transfer.Status)
+
+	paid, err := store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestPaid, paid.Status)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, paid.TransferID)
+	require.True(t, paid.DecidedAt.Valid)
+}
+
+func TestAcceptPaymentRequestTxPendingTransferExpired(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountWithBalance(t, 0)
+	request := createRandomPaymentRequest(t, fromAccount.Owner, toAccount, 700, time.Now().Add(time.Second))
+
+	result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID:  request.ID,
+		FromAccountID:     fromAccount.ID,
+		ApprovalThreshold: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestPending, result.PaymentRequest.Status)
+
+	time.Sleep(time.Second)
+
+	// žádost vypršela, zatímco převod čekal na schválení, takže se po zamítnutí převodu už znovu neotevře
+	_, err = store.DecideTransferTx(context.Background(), DecideTransferParams{
+		Status:    util.TransferRejected,
+		DecidedBy: sql.NullString{String: fromAccount.Owner, Valid: true},
+		ID:        result.Transfer.ID,
+	})
+	require.NoError(t, err)
+
+	expired, err := store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestExpired, expired.Status)
+	require.False(t, expired.TransferID.Valid)
+	require.True(t, expired.DecidedAt.Valid)
+
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotOpen)
+}
+
+func TestPaymentRequestExpiration(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountWithBalance(t, 0)
+	request := createRandomPaymentRequest(t, fromAccount.Owner, toAccount, 400, time.Now().Add(time.Second))
+
+	time.Sleep(time.Second)
+
+	// vypršelou žádost nejde zaplatit ani odmítnout, i když ještě není označená jako expirovaná
+	_, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotOpen)
+
+	_, err = store.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		Status: util.PaymentRequestDeclined,
+		ID:     request.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	expired, err := store.ExpirePaymentRequests(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	request, err = store.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestExpired, request.Status)
+	require.True(t, request.DecidedAt.Valid)
+}
+
+func TestListPaymentRequests(t *testing.T) {
+	payer := createRandomUser(t)
+	toAccount := createRandomAccountWithBalance(t, 0)
+
+	var requests []PaymentRequest
+	for i := 0; i < 3; i++ {
+		requests = append(requests, createRandomPaymentRequest(t, payer.Username, toAccount, 100, time.Now().Add(time.Hour)))
+	}
+
+	declined, err := testQueries.ClosePaymentRequest(context.Background(), ClosePaymentRequestParams{
+		Status: util.PaymentRequestDeclined,
+		ID:     requests[0].ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentRequestDeclined, declined.Status)
+
+	incoming, err := testQueries.ListIncomingPaymentRequests(context.Background(), ListIncomingPaymentRequestsParams{
+		Payer:  payer.Username,
+		Status: sql.NullString{String: util.PaymentRequestOpen, Valid: true},
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, incoming, 2)
+	// nejnovější žádosti jsou první
+	require.Equal(t, requests[2].ID, incoming[0].ID)
+	require.Equal(t, requests[1].ID, incoming[1].ID)
+
+	outgoing, err := testQueries.ListOutgoingPaymentRequests(context.Background(), ListOutgoingPaymentRequestsParams{
+		Requester: toAccount.Owner,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 3)
+}
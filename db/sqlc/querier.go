@@ -4,24 +4,135 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountBalances(ctx context.Context, arg AddAccountBalancesParams) error
+	AddTransferReversedAmount(ctx context.Context, arg AddTransferReversedAmountParams) (Transfer, error)
+	AuthorizeTransfer(ctx context.Context, arg AuthorizeTransferParams) (Transfer, error)
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
+	CancelAccountPaymentRequests(ctx context.Context, toAccountID int64) error
+	CancelAccountScheduledTransfers(ctx context.Context, accountID int64) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	ClaimDueScheduledTransfer(ctx context.Context, now time.Time) (ScheduledTransfer, error)
+	CloseAccountHold(ctx context.Context, arg CloseAccountHoldParams) (AccountHold, error)
+	ClosePaymentRequest(ctx context.Context, arg ClosePaymentRequestParams) (PaymentRequest, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountHold(ctx context.Context, arg CreateAccountHoldParams) (AccountHold, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateBatchTransfers(ctx context.Context, arg CreateBatchTransfersParams) ([]int64, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeTransfers(ctx context.Context, arg CreateFeeTransfersParams) ([]int64, error)
+	CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error
+	CreateInterestAccrualRun(ctx context.Context, arg CreateInterestAccrualRunParams) (InterestAccrualRun, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	CreateMaintenanceFee(ctx context.Context, arg CreateMaintenanceFeeParams) (MaintenanceFee, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreateReconciliationIssue(ctx context.Context, arg CreateReconciliationIssueParams) (ReconciliationIssue, error)
+	CreateReconciliationRun(ctx context.Context) (ReconciliationRun, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferExecution(ctx context.Context, arg CreateScheduledTransferExecutionParams) (ScheduledTransferExecution, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransferBatchItems(ctx context.Context, arg CreateTransferBatchItemsParams) ([]TransferBatchItem, error)
+	CreateTransferEntries(ctx context.Context, transferIds []int64) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DecideTransfer(ctx context.Context, arg DecideTransferParams) (Transfer, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) (AccountMember, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteFeeSchedule(ctx context.Context, arg DeleteFeeScheduleParams) (FeeSchedule, error)
+	DeleteFeeWaiver(ctx context.Context, arg DeleteFeeWaiverParams) (FeeWaiver, error)
+	DeleteUserTokenRevocations(ctx context.Context, olderThan time.Time) error
+	ExpireAccountHolds(ctx context.Context, now time.Time) (int64, error)
+	ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error)
+	FinishReconciliationRun(ctx context.Context, arg FinishReconciliationRunParams) (ReconciliationRun, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerCurrencyAndType(ctx context.Context, arg GetAccountByOwnerCurrencyAndTypeParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountHold(ctx context.Context, id int64) (AccountHold, error)
+	GetAccountHoldForUpdate(ctx context.Context, id int64) (AccountHold, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	GetAccountReservedAmount(ctx context.Context, accountID int64) (int64, error)
+	GetAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (TransferLimit, error)
+	GetAccountTransferUsage(ctx context.Context, arg GetAccountTransferUsageParams) (GetAccountTransferUsageRow, error)
+	GetAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedule, error)
+	GetFeeWaiver(ctx context.Context, arg GetFeeWaiverParams) (FeeWaiver, error)
+	GetFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error)
+	GetFxRate(ctx context.Context, arg GetFxRateParams) (FxRate, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLastInterestAccrualRun(ctx context.Context) (InterestAccrualRun, error)
+	GetLastInterestPosting(ctx context.Context, arg GetLastInterestPostingParams) (InterestPosting, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetReconciliationRun(ctx context.Context, id int64) (ReconciliationRun, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStatementBalances(ctx context.Context, arg GetStatementBalancesParams) (GetStatementBalancesRow, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetUserTransferLimit(ctx context.Context, arg GetUserTransferLimitParams) (TransferLimit, error)
+	GetUserTransferUsage(ctx context.Context, arg GetUserTransferUsageParams) (GetUserTransferUsageRow, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	ListAccountHolds(ctx context.Context, arg ListAccountHoldsParams) ([]AccountHold, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
-	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
-	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListAllTransfers(ctx context.Context, arg ListAllTransfersParams) ([]Transfer, error)
+	ListEntries(ctx context.Context, arg ListEntriesParams) ([]ListEntriesRow, error)
+	ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error)
+	ListFeeWaivers(ctx context.Context, username string) ([]FeeWaiver, error)
+	ListIncomingPaymentRequests(ctx context.Context, arg ListIncomingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error)
+	ListInterestBearingBalances(ctx context.Context, dayEnd time.Time) ([]ListInterestBearingBalancesRow, error)
+	ListInterestRates(ctx context.Context) ([]InterestRate, error)
+	ListMaintenanceFeeAccounts(ctx context.Context, arg ListMaintenanceFeeAccountsParams) ([]ListMaintenanceFeeAccountsRow, error)
+	ListOutgoingPaymentRequests(ctx context.Context, arg ListOutgoingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListReconciliationIssues(ctx context.Context, arg ListReconciliationIssuesParams) ([]ReconciliationIssue, error)
+	ListReconciliationRuns(ctx context.Context, arg ListReconciliationRunsParams) ([]ReconciliationRun, error)
+	ListScheduledTransferExecutions(ctx context.Context, arg ListScheduledTransferExecutionsParams) ([]ScheduledTransferExecution, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListTransferBatchItems(ctx context.Context, arg ListTransferBatchItemsParams) ([]TransferBatchItem, error)
+	ListTransferBatches(ctx context.Context, arg ListTransferBatchesParams) ([]TransferBatch, error)
+	ListTransferReversals(ctx context.Context, reversalOf sql.NullInt64) ([]Transfer, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]ListTransfersRow, error)
+	ListUnpostedInterestMonths(ctx context.Context, before time.Time) ([]ListUnpostedInterestMonthsRow, error)
+	PayPaymentRequest(ctx context.Context, arg PayPaymentRequestParams) (PaymentRequest, error)
+	PayPendingPaymentRequest(ctx context.Context, transferID sql.NullInt64) error
+	ReconcileAccounts(ctx context.Context, arg ReconcileAccountsParams) ([]ReconcileAccountsRow, error)
+	ReconcileTransfers(ctx context.Context, arg ReconcileTransfersParams) ([]ReconcileTransfersRow, error)
+	ReopenPaymentRequest(ctx context.Context, transferID sql.NullInt64) error
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error
+	SetAccountHoldTransfer(ctx context.Context, arg SetAccountHoldTransferParams) (AccountHold, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateScheduledTransferRun(ctx context.Context, arg UpdateScheduledTransferRunParams) (ScheduledTransfer, error)
+	UpsertAccountTransferLimit(ctx context.Context, arg UpsertAccountTransferLimitParams) (TransferLimit, error)
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error)
+	UpsertFeeWaiver(ctx context.Context, arg UpsertFeeWaiverParams) (FeeWaiver, error)
+	UpsertFxRate(ctx context.Context, arg UpsertFxRateParams) (FxRate, error)
+	UpsertInterestRate(ctx context.Context, arg UpsertInterestRateParams) (InterestRate, error)
+	UpsertUserTransferLimit(ctx context.Context, arg UpsertUserTransferLimitParams) (TransferLimit, error)
 }
 
 var _ Querier = (*Queries)(nil)
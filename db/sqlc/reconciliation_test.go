@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomReconciliationRun(t *testing.T) ReconciliationRun {
+	run, err := testQueries.CreateReconciliationRun(context.Background())
+	require.NoError(t, err)
+	require.NotZero(t, run.ID)
+	require.Equal(t, "running", run.Status)
+	require.NotZero(t, run.StartedAt)
+	require.False(t, run.FinishedAt.Valid)
+
+	return run
+}
+
+func TestFinishReconciliationRun(t *testing.T) {
+	run := createRandomReconciliationRun(t)
+
+	arg := FinishReconciliationRunParams{
+		ID:               run.ID,
+		Status:           "drift",
+		AccountsChecked:  10,
+		TransfersChecked: 20,
+		IssuesFound:      1,
+	}
+	finished, err := testQueries.FinishReconciliationRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Status, finished.Status)
+	require.Equal(t, arg.AccountsChecked, finished.AccountsChecked)
+	require.Equal(t, arg.TransfersChecked, finished.TransfersChecked)
+	require.Equal(t, arg.IssuesFound, finished.IssuesFound)
+	require.True(t, finished.FinishedAt.Valid)
+
+	got, err := testQueries.GetReconciliationRun(context.Background(), run.ID)
+	require.NoError(t, err)
+	require.Equal(t, finished, got)
+}
+
+func TestListReconciliationRuns(t *testing.T) {
+	run1 := createRandomReconciliationRun(t)
+	run2 := createRandomReconciliationRun(t)
+
+	runs, err := testQueries.ListReconciliationRuns(context.Background(), ListReconciliationRunsParams{
+		Limit:  2,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	// nejnovější běh je první
+	require.Equal(t, run2.ID, runs[0].ID)
+	require.Equal(t, run1.ID, runs[1].ID)
+}
+
+func TestReconciliationIssues(t *testing.T) {
+	run := createRandomReconciliationRun(t)
+	account := createRandomAccount(t)
+
+	arg := CreateReconciliationIssueParams{
+		RunID:     run.ID,
+		Kind:      "balance_drift",
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Expected:  0,
+		Actual:    account.Balance,
+		Details:   "test",
+	}
+	issue, err := testQueries.CreateReconciliationIssue(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, issue.AccountID)
+	require.False(t, issue.TransferID.Valid)
+
+	issues, err := testQueries.ListReconciliationIssues(context.Background(), ListReconciliationIssuesParams{
+		RunID:  run.ID,
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ReconciliationIssue{issue}, issues)
+}
+
+func TestReconcileAccounts(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	createTransfers(t, account1, account2)
+
+	rows, err := testQueries.ReconcileAccounts(context.Background(), ReconcileAccountsParams{
+		AfterID: account1.ID - 1,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	// účty vznikly s počátečním zůstatkem bez záznamů, takže ho rekonciliace musí ukázat
+	for i, account := range []Account{account1, account2} {
+		require.Equal(t, account.ID, rows[i].ID)
+		require.Equal(t, account.Balance, rows[i].Balance-rows[i].EntriesSum)
+		require.Zero(t, rows[i].OrphanEntries)
+	}
+}
+
+func TestReconcileTransfers(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	results := createTransfers(t, account1, account2)
+
+	require.Equal(t, sql.NullInt64{Int64: results[0].Transfer.ID, Valid: true}, results[0].FromEntry.TransferID)
+	require.Equal(t, sql.NullInt64{Int64: results[0].Transfer.ID, Valid: true}, results[0].ToEntry.TransferID)
+
+	rows, err := testQueries.ReconcileTransfers(context.Background(), ReconcileTransfersParams{
+		AfterID: results[0].Transfer.ID - 1,
+		Limit:   int32(len(results)),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, len(results))
+
+	for i, row := range rows {
+		transfer := results[i].Transfer
+		require.Equal(t, transfer.ID, row.ID)
+		require.Equal(t, int64(2), row.EntriesCount)
+		require.Equal(t, -transfer.Amount, row.FromEntriesSum)
+		require.Equal(t, transfer.ToAmount, row.ToEntriesSum)
+	}
+}
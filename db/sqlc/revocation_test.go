@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokedToken(t *testing.T) {
+	user := createRandomUser(t)
+	issuedAt := time.Now()
+
+	arg := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: issuedAt,
+	}
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testQueries.CreateRevokedToken(context.Background(), CreateRevokedTokenParams{
+		ID:        arg.ID,
+		Username:  user.Username,
+		ExpiresAt: issuedAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	user := createRandomUser(t)
+
+	oldToken := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now().Add(-time.Minute),
+	}
+
+	err := testQueries.RevokeUserTokens(context.Background(), RevokeUserTokensParams{
+		Username:      user.Username,
+		RevokedBefore: time.Now(),
+	})
+	require.NoError(t, err)
+
+	newToken := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now().Add(time.Minute),
+	}
+
+	// every token issued before the revocation is revoked, the newer ones are still valid
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), oldToken)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), newToken)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
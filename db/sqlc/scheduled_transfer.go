@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// ExecuteScheduledTransferTxParams contains the input parameters of the scheduled transfer execution
+type ExecuteScheduledTransferTxParams struct {
+	// scheduled transfers with next_run_at before or equal to Now are due
+	Now time.Time
+	// number of attempts of one occurrence, the occurrence is skipped after the last failed attempt
+	MaxAttempts int32
+	// delay between the failed attempt and the next one
+	RetryInterval time.Duration
+	// optional, every execution must fit into the transfer limits like the transfers created by the owner
+	Limits DefaultTransferLimits
+	// optional, executions with a larger amount create pending transfers waiting for the approval of a banker,
+	// executions of a member who needs approval always wait for the authorization of the account owner
+	ApprovalThreshold int64
+	// optional, every execution pays the transfer fee like the transfers created by the owner
+	ChargeFee bool
+}
+
+// ExecuteScheduledTransferTxResult contains the updated scheduled transfer and its execution,
+// the execution is empty when the scheduled transfer was completed without executing it
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer          `json:"scheduled_transfer"`
+	Execution         ScheduledTransferExecution `json:"execution"`
+	// the created transfer if the execution succeeded
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// ScheduledTransferError is returned by ExecuteScheduledTransferTx when the claimed scheduled transfer fails
+// with an unexpected error. The whole transaction is rolled back, so the failure must be recorded
+// by FailScheduledTransferTx, otherwise the same scheduled transfer would be claimed first again and again.
+type ScheduledTransferError struct {
+	// the scheduled transfer as it was claimed, before the failed execution
+	ScheduledTransfer ScheduledTransfer
+	Err               error
+}
+
+func (e *ScheduledTransferError) Error() string {
+	return fmt.Sprintf("scheduled transfer %d: %v", e.ScheduledTransfer.ID, e.Err)
+}
+
+func (e *ScheduledTransferError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteScheduledTransferTx claims one due scheduled transfer, executes its current occurrence and moves it
+// to the next one. The scheduled transfer is claimed by SELECT ... FOR UPDATE SKIP LOCKED and stays locked
+// until the transfer and the new schedule are committed, so concurrent executions (e.g. on more replicas
+// of the server) skip it and every occurrence is executed only once. It returns sql.ErrNoRows if there is
+// no due scheduled transfer and *ScheduledTransferError if the claimed one failed with an unexpected error.
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.ClaimDueScheduledTransfer(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		if err := executeOccurrence(ctx, q, scheduled, arg, &result); err != nil {
+			return &ScheduledTransferError{ScheduledTransfer: scheduled, Err: err}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// executeOccurrence executes the current occurrence of the claimed scheduled transfer
+func executeOccurrence(ctx context.Context, q *Queries, scheduled ScheduledTransfer, arg ExecuteScheduledTransferTxParams, result *ExecuteScheduledTransferTxResult) error {
+	// konec mohl vlastník posunout dopředu až po naplánování dalšího výskytu
+	occurrence, ok := nextOccurrence(scheduled, scheduled.Runs)
+	if !ok {
+		var err error
+		result.ScheduledTransfer, err = q.UpdateScheduledTransferRun(ctx, UpdateScheduledTransferRunParams{
+			ID:        scheduled.ID,
+			Status:    util.ScheduledTransferCompleted,
+			Runs:      scheduled.Runs,
+			Attempts:  scheduled.Attempts,
+			NextRunAt: scheduled.NextRunAt,
+		})
+		return err
+	}
+
+	execution := CreateScheduledTransferExecutionParams{
+		ScheduledTransferID: scheduled.ID,
+		OccurrenceAt:        occurrence,
+		Attempt:             scheduled.Attempts + 1,
+		Status:              util.ExecutionSucceeded,
+	}
+
+	// vlastníka naplánovaného převodu mohl vlastník účtu mezitím odebrat ze členů nebo mu změnit roli
+	role, err := accountMemberRole(ctx, q, scheduled.FromAccountID, scheduled.Owner)
+	if err != nil {
+		return err
+	}
+	if !util.CanInitiateTransfers(role) {
+		err = fmt.Errorf("%w: %s can't send transfers from account [%d]", ErrMemberNotAllowed, scheduled.Owner, scheduled.FromAccountID)
+	} else {
+		result.Transfer, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID:     scheduled.FromAccountID,
+			ToAccountID:       scheduled.ToAccountID,
+			Amount:            scheduled.Amount,
+			Limits:            arg.Limits,
+			ApprovalThreshold: arg.ApprovalThreshold,
+			ChargeFee:         arg.ChargeFee,
+			RequireApproval:   role == util.MemberNeedsApproval,
+			InitiatedBy:       scheduled.Owner,
+		}, sql.NullInt64{})
+	}
+	switch {
+	case err == nil:
+		execution.TransferID = sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true}
+	case errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrTransferLimitExceeded), errors.Is(err, ErrAccountNotActive),
+		errors.Is(err, ErrMemberNotAllowed):
+		// nedostatek peněz, překročený limit, zmrazený účet i chybějící oprávnění se zjistí před prvním zápisem,
+		// takže transakce může pokračovat
+		execution.Error = err.Error()
+	default:
+		return err
+	}
+
+	result.ScheduledTransfer, result.Execution, err = recordScheduledTransferExecution(ctx, q, scheduled, execution,
+		arg.Now, arg.MaxAttempts, arg.RetryInterval)
+	return err
+}
+
+// accountMemberRole returns the role of the user on the account, the owner of the account has the owner role
+// and the users who aren't members of the account have no role
+func accountMemberRole(ctx context.Context, q *Queries, accountID int64, username string) (string, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	if account.Owner == username {
+		return util.MemberOwner, nil
+	}
+
+	member, err := q.GetAccountMember(ctx, GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// recordScheduledTransferExecution saves the execution and moves the scheduled transfer to the retry
+// of the failed occurrence or to its next occurrence, the occurrence is skipped after maxAttempts failed attempts
+func recordScheduledTransferExecution(ctx context.Context, q *Queries, scheduled ScheduledTransfer,
+	execution CreateScheduledTransferExecutionParams, now time.Time, maxAttempts int32,
+	retryInterval time.Duration) (ScheduledTransfer, ScheduledTransferExecution, error) {
+	if execution.Error != "" {
+		if execution.Attempt < maxAttempts {
+			execution.Status = util.ExecutionRetrying
+		} else {
+			execution.Status = util.ExecutionFailed
+		}
+	}
+
+	run := UpdateScheduledTransferRunParams{
+		ID:        scheduled.ID,
+		Status:    util.ScheduledTransferActive,
+		Runs:      scheduled.Runs,
+		Attempts:  scheduled.Attempts,
+		NextRunAt: scheduled.NextRunAt,
+	}
+	if execution.Status == util.ExecutionRetrying {
+		run.Attempts = execution.Attempt
+		run.NextRunAt = now.Add(retryInterval)
+	} else {
+		run.Runs++
+		run.Attempts = 0
+		if next, ok := nextOccurrence(scheduled, run.Runs); ok {
+			run.NextRunAt = next
+		} else if execution.Status == util.ExecutionSucceeded {
+			run.Status = util.ScheduledTransferCompleted
+		} else {
+			run.Status = util.ScheduledTransferFailed
+		}
+	}
+
+	saved, err := q.CreateScheduledTransferExecution(ctx, execution)
+	if err != nil {
+		return ScheduledTransfer{}, saved, err
+	}
+
+	updated, err := q.UpdateScheduledTransferRun(ctx, run)
+	return updated, saved, err
+}
+
+// FailScheduledTransferTxParams contains the scheduled transfer which failed with an unexpected error
+type FailScheduledTransferTxParams struct {
+	// the scheduled transfer as it was claimed by the failed execution
+	ScheduledTransfer ScheduledTransfer
+	Error             string
+	Now               time.Time
+	MaxAttempts       int32
+	RetryInterval     time.Duration
+}
+
+// FailScheduledTransferTx records the failed attempt of the scheduled transfer whose execution was rolled back
+// because of an unexpected error, e.g. a missing fee revenue account. The failure is handled like insufficient
+// funds, the occurrence is retried after the retry interval and skipped after the last attempt, so one broken
+// scheduled transfer doesn't block the others. Nothing is recorded if a concurrent execution already moved
+// the scheduled transfer, the returned execution is empty in that case.
+func (store *SQLStore) FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetScheduledTransferForUpdate(ctx, arg.ScheduledTransfer.ID)
+		if err != nil {
+			return err
+		}
+		result.ScheduledTransfer = scheduled
+
+		// mezi rollbackem a zámkem ho mohla vyřídit jiná replika nebo ho vlastník zrušil
+		claimed := arg.ScheduledTransfer
+		if scheduled.Status != util.ScheduledTransferActive || scheduled.Runs != claimed.Runs ||
+			scheduled.Attempts != claimed.Attempts || !scheduled.NextRunAt.Equal(claimed.NextRunAt) {
+			return nil
+		}
+
+		occurrence, _ := nextOccurrence(scheduled, scheduled.Runs)
+		result.ScheduledTransfer, result.Execution, err = recordScheduledTransferExecution(ctx, q, scheduled,
+			CreateScheduledTransferExecutionParams{
+				ScheduledTransferID: scheduled.ID,
+				OccurrenceAt:        occurrence,
+				Attempt:             scheduled.Attempts + 1,
+				Error:               arg.Error,
+			}, arg.Now, arg.MaxAttempts, arg.RetryInterval)
+		return err
+	})
+
+	return result, err
+}
+
+// nextOccurrence returns the n-th occurrence of the scheduled transfer, it returns false
+// if the recurrence doesn't have it or if it is after the end of the scheduled transfer
+func nextOccurrence(scheduled ScheduledTransfer, n int32) (time.Time, bool) {
+	occurrence, ok := util.Occurrence(scheduled.StartAt, scheduled.Recurrence, n)
+	if !ok || (scheduled.EndAt.Valid && occurrence.After(scheduled.EndAt.Time)) {
+		return occurrence, false
+	}
+	return occurrence, true
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Account, toAccount Account, recurrence string, startAt time.Time, endAt sql.NullTime) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		Recurrence:    recurrence,
+		StartAt:       startAt,
+		EndAt:         endAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.Recurrence, scheduled.Recurrence)
+	require.WithinDuration(t, arg.StartAt, scheduled.NextRunAt, time.Microsecond)
+	require.Equal(t, util.ScheduledTransferActive, scheduled.Status)
+	require.Zero(t, scheduled.Runs)
+
+	return scheduled
+}
+
+// executeScheduledTransfer executes the due scheduled transfers until the one with the ID is executed,
+// ostatní testy mohou v databázi nechat jiné splatné převody
+func executeScheduledTransfer(t *testing.T, id int64, arg ExecuteScheduledTransferTxParams) ExecuteScheduledTransferTxResult {
+	store := NewStore(testDB)
+
+	for i := 0; i < 100; i++ {
+		result, err := store.ExecuteScheduledTransferTx(context.Background(), arg)
+		require.NoError(t, err)
+		if result.ScheduledTransfer.ID == id {
+			return result
+		}
+	}
+
+	t.Fatalf("scheduled transfer %d was not executed", id)
+	return ExecuteScheduledTransferTxResult{}
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	startAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
+	endAt := startAt.AddDate(0, 1, 0)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, util.RecurrenceMonthly, startAt,
+		sql.NullTime{Time: endAt, Valid: true})
+
+	arg := ExecuteScheduledTransferTxParams{
+		Now:           time.Now(),
+		MaxAttempts:   3,
+		RetryInterval: time.Hour,
+	}
+	result := executeScheduledTransfer(t, scheduled.ID, arg)
+
+	require.Equal(t, util.ExecutionSucceeded, result.Execution.Status)
+	require.Equal(t, int32(1), result.Execution.Attempt)
+	require.WithinDuration(t, startAt, result.Execution.OccurrenceAt, time.Microsecond)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true}, result.Execution.TransferID)
+	require.Equal(t, scheduled.Amount, result.Transfer.Transfer.Amount)
+	require.Equal(t, account1.Balance-scheduled.Amount, result.Transfer.FromAccount.Balance)
+
+	require.Equal(t, util.ScheduledTransferActive, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Runs)
+	require.WithinDuration(t, endAt, result.ScheduledTransfer.NextRunAt, time.Microsecond)
+
+	// druhý výskyt je zároveň poslední
+	arg.Now = endAt
+	result = executeScheduledTransfer(t, scheduled.ID, arg)
+	require.Equal(t, util.ExecutionSucceeded, result.Execution.Status)
+	require.Equal(t, util.ScheduledTransferCompleted, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(2), result.ScheduledTransfer.Runs)
+
+	executions, err := testQueries.ListScheduledTransferExecutions(context.Background(), ListScheduledTransferExecutionsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               10,
+		Offset:              0,
+	})
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+}
+
+func TestExecuteScheduledTransferTxInsufficientFunds(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 0)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	startAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, util.RecurrenceOnce, startAt, sql.NullTime{})
+
+	arg := ExecuteScheduledTransferTxParams{
+		Now:           time.Now().UTC().Truncate(time.Microsecond),
+		MaxAttempts:   2,
+		RetryInterval: time.Hour,
+	}
+	result := executeScheduledTransfer(t, scheduled.ID, arg)
+
+	require.Equal(t, util.ExecutionRetrying, result.Execution.Status)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Execution.Error)
+	require.False(t, result.Execution.TransferID.Valid)
+	require.Equal(t, util.ScheduledTransferActive, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Attempts)
+	require.Zero(t, result.ScheduledTransfer.Runs)
+	require.WithinDuration(t, arg.Now.Add(arg.RetryInterval), result.ScheduledTransfer.NextRunAt, time.Microsecond)
+
+	// poslední pokus skončí chybou a jednorázový převod už nemá další výskyt
+	arg.Now = result.ScheduledTransfer.NextRunAt
+	result = executeScheduledTransfer(t, scheduled.ID, arg)
+	require.Equal(t, util.ExecutionFailed, result.Execution.Status)
+	require.Equal(t, int32(2), result.Execution.Attempt)
+	require.Equal(t, util.ScheduledTransferFailed, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Runs)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount.Balance)
+}
+
+func TestExecuteScheduledTransferTxMember(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+	member := createRandomUser(t)
+	addAccountMember(t, account1, member.Username, util.MemberNeedsApproval)
+
+	startAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         member.Username,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Recurrence:    util.RecurrenceDaily,
+		StartAt:       startAt,
+	})
+	require.NoError(t, err)
+
+	arg := ExecuteScheduledTransferTxParams{
+		Now:           time.Now().UTC().Truncate(time.Microsecond),
+		MaxAttempts:   2,
+		RetryInterval: time.Hour,
+	}
+	// převod člena, který potřebuje schválení, čeká na vlastníka účtu
+	result := executeScheduledTransfer(t, scheduled.ID, arg)
+	require.Equal(t, util.ExecutionSucceeded, result.Execution.Status)
+	require.Equal(t, util.TransferPending, result.Transfer.Transfer.Status)
+	require.True(t, result.Transfer.Transfer.MemberApproval)
+	require.Equal(t, sql.NullString{String: member.Username, Valid: true}, result.Transfer.Transfer.InitiatedBy)
+
+	// odebraný člen už z účtu nic neposílá
+	_, err = testQueries.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: account1.ID,
+		Username:  member.Username,
+	})
+	require.NoError(t, err)
+
+	arg.Now = result.ScheduledTransfer.NextRunAt
+	result = executeScheduledTransfer(t, scheduled.ID, arg)
+	require.Equal(t, util.ExecutionRetrying, result.Execution.Status)
+	require.Contains(t, result.Execution.Error, ErrMemberNotAllowed.Error())
+	require.False(t, result.Execution.TransferID.Valid)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, util.RecurrenceDaily,
+		time.Now().Add(time.Hour), sql.NullTime{})
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ScheduledTransferCancelled, cancelled.Status)
+
+	// zrušený převod už nejde zrušit ani změnit
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		Amount: sql.NullInt64{Int64: 1, Valid: true},
+		ID:     scheduled.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestFailScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	startAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Microsecond)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, util.RecurrenceDaily, startAt, sql.NullTime{})
+
+	arg := FailScheduledTransferTxParams{
+		ScheduledTransfer: scheduled,
+		Error:             "fee revenue account is missing",
+		Now:               time.Now(),
+		MaxAttempts:       2,
+		RetryInterval:     time.Hour,
+	}
+
+	// první pokus se zopakuje později, takže převod přestane být splatný
+	result, err := store.FailScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.ExecutionRetrying, result.Execution.Status)
+	require.Equal(t, arg.Error, result.Execution.Error)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Attempts)
+	require.WithinDuration(t, arg.Now.Add(time.Hour), result.ScheduledTransfer.NextRunAt, time.Second)
+
+	// stejný neúspěch se nezaznamená dvakrát
+	again, err := store.FailScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, again.Execution.ID)
+	require.Equal(t, result.ScheduledTransfer, again.ScheduledTransfer)
+
+	// poslední pokus výskyt přeskočí a převod pokračuje dalším dnem
+	arg.ScheduledTransfer = result.ScheduledTransfer
+	result, err = store.FailScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.ExecutionFailed, result.Execution.Status)
+	require.Equal(t, util.ScheduledTransferActive, result.ScheduledTransfer.Status)
+	require.Equal(t, int32(1), result.ScheduledTransfer.Runs)
+	require.Zero(t, result.ScheduledTransfer.Attempts)
+	require.WithinDuration(t, startAt.AddDate(0, 0, 1), result.ScheduledTransfer.NextRunAt, time.Microsecond)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// StatementParams selects the account and the period of the statement,
+// the period includes FromTime and excludes ToTime
+type StatementParams struct {
+	AccountID int64
+	FromTime  time.Time
+	ToTime    time.Time
+}
+
+// StatementLine is one movement on the statement, Amount is the signed change
+// of the account balance in the currency of the account
+type StatementLine struct {
+	TransferID            int64     `json:"transfer_id"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id"`
+	Amount                int64     `json:"amount"`
+	ExchangeRate          string    `json:"exchange_rate"`
+	Balance               int64     `json:"balance"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// StatementWriter receives the statement from ExportStatement, WriteBalances is called once
+// before the lines so the writer can put both balances to the header of the document
+type StatementWriter interface {
+	WriteBalances(opening int64, closing int64) error
+	WriteLine(line StatementLine) error
+}
+
+// sqlc umí u database/sql jen :many, které načte všechny řádky do slice,
+// proto je dotaz na řádky výpisu napsaný ručně a čte se po jednom řádku
+const listStatementLines = `SELECT
+  id,
+  CASE WHEN from_account_id = $1 THEN to_account_id ELSE from_account_id END AS counterparty_account_id,
+  (CASE WHEN to_account_id = $1 THEN to_amount ELSE 0 END)
+    - (CASE WHEN from_account_id = $1 THEN amount ELSE 0 END) AS change,
+  exchange_rate,
+  created_at
+FROM transfers
+WHERE (from_account_id = $1 OR to_account_id = $1)
+  AND status = 'posted'
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY id
+`
+
+// ExportStatement streams the statement of the account to the writer without loading it into memory.
+// The balances and the lines are read in one repeatable read transaction, so they match
+// even if transfers are committed while the statement is written.
+func (store *SQLStore) ExportStatement(ctx context.Context, arg StatementParams, writer StatementWriter) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	// transakce jen čte, takže rollback stačí i po úspěšném exportu
+	defer tx.Rollback()
+
+	balances, err := New(tx).GetStatementBalances(ctx, GetStatementBalancesParams{
+		FromTime:  arg.FromTime,
+		ToTime:    arg.ToTime,
+		AccountID: arg.AccountID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteBalances(balances.OpeningBalance, balances.ClosingBalance); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, listStatementLines, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	balance := balances.OpeningBalance
+	for rows.Next() {
+		var line StatementLine
+		if err := rows.Scan(
+			&line.TransferID,
+			&line.CounterpartyAccountID,
+			&line.Amount,
+			&line.ExchangeRate,
+			&line.CreatedAt,
+		); err != nil {
+			return err
+		}
+		balance += line.Amount
+		line.Balance = balance
+
+		if err := writer.WriteLine(line); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingWriter keeps everything written by ExportStatement
+type recordingWriter struct {
+	opening int64
+	closing int64
+	lines   []StatementLine
+}
+
+func (w *recordingWriter) WriteBalances(opening int64, closing int64) error {
+	w.opening = opening
+	w.closing = closing
+	return nil
+}
+
+func (w *recordingWriter) WriteLine(line StatementLine) error {
+	w.lines = append(w.lines, line)
+	return nil
+}
+
+func TestExportStatement(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	results := createTransfers(t, account1, account2)
+
+	var writer recordingWriter
+	err := store.ExportStatement(context.Background(), StatementParams{
+		AccountID: account1.ID,
+		FromTime:  account1.CreatedAt,
+		ToTime:    time.Now().Add(time.Minute),
+	}, &writer)
+	require.NoError(t, err)
+
+	// account1 posílá 10, 30, 50 a dostává 20, 40
+	require.Equal(t, int64(1000), writer.opening)
+	require.Equal(t, int64(970), writer.closing)
+	require.Len(t, writer.lines, 5)
+
+	balances := []int64{990, 1010, 980, 1020, 970}
+	for i, line := range writer.lines {
+		require.Equal(t, results[i].Transfer.ID, line.TransferID)
+		require.Equal(t, account2.ID, line.CounterpartyAccountID)
+		require.Equal(t, balances[i], line.Balance)
+		if i%2 == 0 {
+			require.Equal(t, -results[i].Transfer.Amount, line.Amount)
+		} else {
+			require.Equal(t, results[i].Transfer.ToAmount, line.Amount)
+		}
+	}
+}
+
+func TestExportStatementPeriod(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	results := createTransfers(t, account1, account2)
+
+	// od třetího převodu včetně do čtvrtého bez něj
+	var writer recordingWriter
+	err := store.ExportStatement(context.Background(), StatementParams{
+		AccountID: account2.ID,
+		FromTime:  results[2].Transfer.CreatedAt,
+		ToTime:    results[3].Transfer.CreatedAt,
+	}, &writer)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(990), writer.opening)
+	require.Equal(t, int64(1020), writer.closing)
+	require.Len(t, writer.lines, 1)
+	require.Equal(t, results[2].Transfer.ID, writer.lines[0].TransferID)
+	require.Equal(t, int64(30), writer.lines[0].Amount)
+	require.Equal(t, writer.closing, writer.lines[0].Balance)
+}
@@ -3,9 +3,64 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
 )
 
+// ErrInsufficientFunds is returned by TransferTx when the available balance of the source account
+// is lower than the transferred amount
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrIdempotencyKeyUsed is returned by the transactions when the idempotency key already has
+// a saved response, e.g. when the same request was executed concurrently
+var ErrIdempotencyKeyUsed = errors.New("idempotency key is already used")
+
+// ErrTransferReversed is returned by ReverseTransferTx when the whole amount of the transfer is already reversed
+var ErrTransferReversed = errors.New("transfer is already reversed")
+
+// ErrInvalidReversal is returned by ReverseTransferTx when the transfer can't be reversed by the given amount
+var ErrInvalidReversal = errors.New("invalid reversal")
+
+// ErrTransferLimitExceeded is returned by the transfers when the amount doesn't fit into the transfer limits
+// of the source account or its owner
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// ErrTransferNotPending is returned by ApproveTransferTx when the transfer was already posted, rejected or cancelled
+var ErrTransferNotPending = errors.New("transfer is not pending")
+
+// ErrTransferNotAuthorized is returned by ApproveTransferTx when the transfer of a member who needs approval
+// wasn't authorized by the owner or a co-owner of the account yet
+var ErrTransferNotAuthorized = errors.New("transfer is not authorized by the account owner")
+
+// ErrTransferNotAwaitingMember is returned by AuthorizeTransferTx when the transfer doesn't wait for the owner
+// or a co-owner of the account, i.e. it wasn't sent by a member who needs approval or it is already authorized
+var ErrTransferNotAwaitingMember = errors.New("transfer is not awaiting the account owner")
+
+// ErrMemberNotAllowed is returned as the error of the scheduled transfer execution when its owner
+// isn't allowed to send transfers from the account anymore, e.g. it was removed from the account members
+var ErrMemberNotAllowed = errors.New("member is not allowed to send transfers from the account")
+
+// ErrHoldNotActive is returned by CaptureHoldTx when the hold was already captured, released or it expired
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// ErrInvalidCapture is returned by CaptureHoldTx when the hold can't be captured by the given amount
+var ErrInvalidCapture = errors.New("invalid capture")
+
+// ErrPaymentRequestNotOpen is returned by AcceptPaymentRequestTx when the request was already decided or it expired
+var ErrPaymentRequestNotOpen = errors.New("payment request is not open")
+
+// ErrAccountNotActive is returned by the transactions which move money when an account is frozen or closed
+var ErrAccountNotActive = errors.New("account is not active")
+
+// ErrAccountNotEmpty is returned by CloseAccountTx when the account has money and no account to sweep it to,
+// when it is overdrawn or when a part of its balance is reserved by pending transfers and active holds
+var ErrAccountNotEmpty = errors.New("account is not empty")
+
 // Store provides all functions to execute SQL queries and transactions
 // it also stores all combinations which will be using in transactions
 // Queries struct does not support transactions
@@ -16,6 +71,25 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	ExportStatement(ctx context.Context, arg StatementParams, writer StatementWriter) error
+	DepositTx(ctx context.Context, arg DepositTxParams) (TransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg WithdrawTxParams) (TransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error)
+	FailScheduledTransferTx(ctx context.Context, arg FailScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error)
+	GetTransferAllowance(ctx context.Context, account Account, username string, defaults DefaultTransferLimits) (TransferAllowance, error)
+	ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (TransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (TransferTxResult, error)
+	DecideTransferTx(ctx context.Context, arg DecideTransferParams) (Transfer, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (AccountHold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
+	AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (InterestAccrualRun, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	ChargeMaintenanceFeeTx(ctx context.Context, arg ChargeMaintenanceFeeTxParams) (ChargeMaintenanceFeeTxResult, error)
 }
 
 type SQLStore struct {
@@ -70,9 +144,32 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"ammount"`
+	// amount credited to the to account in its currency, transfers between accounts
+	// with the same currency can leave it and ExchangeRate empty
+	ToAmount     int64  `json:"to_amount"`
+	ExchangeRate string `json:"exchange_rate"`
+	// optional, if it is set the result is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+	// optional, if it is set the transfer must fit into the limits of the from account and its owner,
+	// the defaults in the currency of the from account are used for the owners without their own limits
+	Limits DefaultTransferLimits `json:"-"`
+	// optional, transfers with a larger amount are created as pending and wait for the approval of a banker
+	ApprovalThreshold int64 `json:"-"`
+	// optional, the from account pays the transfer fee by the fee schedule of its currency
+	ChargeFee bool `json:"-"`
+	// optional, the transfer is sent by an account member whose transfers need approval, it is created
+	// as pending regardless of its amount and waits for the authorization of the account owner or a co-owner
+	RequireApproval bool `json:"-"`
+	// optional, the account member who sends the transfer, the owner of the from account if it is empty,
+	// the transfer counts into the limits of this user and not of the other members of the account
+	InitiatedBy string `json:"-"`
 }
 
-// TransferTxResult type contains the result after execute money transfer db transaction
+// sameCurrencyRate is the exchange rate recorded with transfers between accounts with the same currency
+const sameCurrencyRate = "1"
+
+// TransferTxResult type contains the result after execute money transfer db transaction,
+// pending transfer has only the transfer and the unchanged from account
 type TransferTxResult struct {
 	// napopulovanou struktura Transfer, která nese údaje o tom odkud kam, kolik peněz a jaký moment se pohybovalo
 	Transfer Transfer `json:"transfer"`
@@ -83,6 +180,9 @@ type TransferTxResult struct {
 	// záznamy v tabulce entries pro každý účet ukazující pohyby mezi účty
 	FromEntry Entry `json:"from_entry"`
 	ToEntry   Entry `json:"to_entry"`
+	// převod poplatku z from account a jeho záznam, nil pokud se žádný poplatek neplatil
+	FeeTransfer *Transfer `json:"fee_transfer,omitempty"`
+	FeeEntry    *Entry    `json:"fee_entry,omitempty"`
 }
 
 var txKey = struct{}{}
@@ -98,51 +198,306 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+		result, err = transfer(ctx, q, arg, sql.NullInt64{})
 		if err != nil {
 			return err
 		}
 
-		// záznam o transakci pro účet ze kterého peníze odešli
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+
+}
+
+// transfer moves the money between the accounts inside the transaction of q, reversalOf is set
+// only by the reversals and links the new transfer to the reversed one
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, reversalOf sql.NullInt64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	// nejdřív se zamknou oba účty, aby mezi kontrolou zůstatku a jeho změnou
+	// nemohla jiná transakce z účtu peníze odebrat
+	fromAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	var fee int64
+	if arg.ChargeFee && fromAccount.Owner != util.SystemUser {
+		fee, err = transferFee(ctx, q, fromAccount, arg.Amount)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// hotovostní účty systému jdou do mínusu o peníze vložené do banky
+	if fromAccount.Owner != util.SystemUser {
+		if err := checkAvailableFunds(ctx, q, fromAccount, arg.Amount+fee, 0); err != nil {
+			return result, err
+		}
+	}
+
+	// převody systémových účtů nikdo z uživatelů neposílá
+	var initiatedBy sql.NullString
+	if fromAccount.Owner != util.SystemUser {
+		initiatedBy = sql.NullString{String: arg.InitiatedBy, Valid: true}
+		if arg.InitiatedBy == "" {
+			initiatedBy.String = fromAccount.Owner
+		}
+	}
+
+	if arg.Limits != nil && initiatedBy.Valid {
+		// zámek odesílatele serializuje všechny jeho převody, bere se až po zámcích účtů,
+		// takže pořadí zámků je ve všech transakcích stejné
+		if _, err := q.GetUserForUpdate(ctx, initiatedBy.String); err != nil {
+			return result, err
+		}
+
+		allowance, err := transferAllowance(ctx, q, fromAccount, initiatedBy.String, arg.Limits, time.Now())
+		if err != nil {
+			return result, err
+		}
+		if err := allowance.check(arg.Amount); err != nil {
+			return result, err
+		}
+	}
+
+	toAmount, exchangeRate := arg.ToAmount, arg.ExchangeRate
+	if toAmount == 0 {
+		toAmount, exchangeRate = arg.Amount, sameCurrencyRate
+	}
+
+	status := util.TransferPosted
+	if (arg.RequireApproval || arg.ApprovalThreshold > 0 && arg.Amount > arg.ApprovalThreshold) && fromAccount.Owner != util.SystemUser {
+		status = util.TransferPending
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:  arg.FromAccountID,
+		ToAccountID:    arg.ToAccountID,
+		Amount:         arg.Amount,
+		ToAmount:       toAmount,
+		ExchangeRate:   exchangeRate,
+		ReversalOf:     reversalOf,
+		Status:         status,
+		Fee:            fee,
+		InitiatedBy:    initiatedBy,
+		MemberApproval: arg.RequireApproval && status == util.TransferPending,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// čekající převod jen blokuje peníze na účtu, záznamy se vytvoří až po schválení
+	if status == util.TransferPending {
+		result.FromAccount = fromAccount
+		return result, nil
+	}
+
+	return postTransfer(ctx, q, result.Transfer)
+}
+
+// postTransfer creates the entries of the transfer and moves the money between its accounts, the fee of the transfer
+// is then charged by a separate fee transfer. Both accounts must be already locked by the transaction of q.
+func postTransfer(ctx context.Context, q *Queries, transfer Transfer) (TransferTxResult, error) {
+	result := TransferTxResult{Transfer: transfer}
+	var err error
+
+	// záznam o transakci pro účet ze kterého peníze odešli
+	transferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  transfer.FromAccountID,
+		Amount:     -transfer.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		// pokud vrátím error provede se roll back
+		return result, err
+	}
+	// záznam o transakci pro účet na který se peníze přidaly
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  transfer.ToAccountID,
+		Amount:     transfer.ToAmount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		// pokud vrátím error provede se roll back
+		return result, err
+	}
+	// update balance zahrnuje nutnost prevence potenscionálních deadlock v databází
+	// ToDo: update accounts balance
+	// This takes money from account 1
+	// It method with FOR Update so it will lock this record for concurent
+	// operation until the transaction will be commited or roll back
+
+	if transfer.FromAccountID < transfer.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, transfer.FromAccountID, -transfer.Amount, transfer.ToAccountID, transfer.ToAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, transfer.ToAccountID, transfer.ToAmount, transfer.FromAccountID, -transfer.Amount)
+	}
+	if err != nil || transfer.Fee == 0 {
+		return result, err
+	}
+
+	feeResult, err := chargeFee(ctx, q, result.FromAccount, transfer.Fee, util.FeeKindTransfer, transferID)
+	if err != nil {
+		return result, err
+	}
+	result.FeeTransfer = &feeResult.Transfer
+	result.FeeEntry = &feeResult.FromEntry
+	result.FromAccount = feeResult.FromAccount
+
+	return result, nil
+}
+
+// checkAvailableFunds returns ErrInsufficientFunds if the available balance of the locked account, i.e. its balance
+// with its overdraft limit and without the amounts reserved by its pending transfers and active holds, is lower than the amount. Released is
+// the reserved amount which is being released by the caller, e.g. by the approval of the pending transfer.
+func checkAvailableFunds(ctx context.Context, q *Queries, account Account, amount int64, released int64) error {
+	reservedAmount, err := q.GetAccountReservedAmount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	// běžný účet smí jít do mínusu až o povolený debet
+	if account.Balance+account.OverdraftLimit-(reservedAmount-released) < amount {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// ApproveTransferTxParams contains the input parameters of the approval of the pending transfer
+type ApproveTransferTxParams struct {
+	TransferID int64  `json:"transfer_id"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+// ApproveTransferTx posts the entries of the pending transfer. The transfer is locked before its accounts,
+// like in ReverseTransferTx, so it can't be approved twice or cancelled while it is being approved.
+// The payment request paid by the transfer becomes paid too.
+// It returns ErrTransferNotPending if the transfer was already decided and ErrTransferNotAuthorized
+// if the transfer of a member who needs approval wasn't authorized by the account owner yet.
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		pending, err := q.GetTransferForUpdate(ctx, arg.TransferID)
 		if err != nil {
-			// pokud vrátím error provede se roll back
 			return err
 		}
-		// záznam o transakci pro účet na který se peníze přidaly
+		if pending.Status != util.TransferPending {
+			return fmt.Errorf("%w: transfer %d is %s", ErrTransferNotPending, pending.ID, pending.Status)
+		}
+		if pending.MemberApproval && !pending.AuthorizedBy.Valid {
+			return fmt.Errorf("%w: transfer %d", ErrTransferNotAuthorized, pending.ID)
+		}
+
+		result, err = postPendingTransfer(ctx, q, pending, arg.ApprovedBy)
+		return err
+	})
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+	return result, err
+}
+
+// AuthorizeTransferTxParams contains the input parameters of the authorization of the transfer
+// sent by a member who needs approval
+type AuthorizeTransferTxParams struct {
+	TransferID   int64  `json:"transfer_id"`
+	AuthorizedBy string `json:"authorized_by"`
+	// převody nad touto částkou po autorizaci dál čekají na bankéře, 0 znamená bez schvalování bankéřem
+	ApprovalThreshold int64 `json:"-"`
+}
+
+// AuthorizeTransferTx authorizes the pending transfer of a member who needs approval on behalf of the account owner.
+// The transfer is posted, unless its amount is over the approval threshold, then it stays pending until a banker
+// approves it. The transfer is locked before its accounts, like in ApproveTransferTx.
+// It returns ErrTransferNotPending if the transfer was already decided and ErrTransferNotAwaitingMember
+// if it doesn't wait for the authorization.
+func (store *SQLStore) AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		pending, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if pending.Status != util.TransferPending {
+			return fmt.Errorf("%w: transfer %d is %s", ErrTransferNotPending, pending.ID, pending.Status)
+		}
+		if !pending.MemberApproval || pending.AuthorizedBy.Valid {
+			return fmt.Errorf("%w: transfer %d", ErrTransferNotAwaitingMember, pending.ID)
+		}
+
+		pending, err = q.AuthorizeTransfer(ctx, AuthorizeTransferParams{
+			AuthorizedBy: sql.NullString{String: arg.AuthorizedBy, Valid: true},
+			ID:           pending.ID,
 		})
 		if err != nil {
-			// pokud vrátím error provede se roll back
 			return err
 		}
-		// update balance zahrnuje nutnost prevence potenscionálních deadlock v databází
-		// ToDo: update accounts balance
-		// This takes money from account 1
-		// It method with FOR Update so it will lock this record for concurent
-		// operation until the transaction will be commited or roll back
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+		// velké převody schvaluje ještě bankéř, peníze zůstávají blokované
+		if arg.ApprovalThreshold > 0 && pending.Amount > arg.ApprovalThreshold {
+			result.Transfer = pending
+			return nil
 		}
 
-		return nil
+		result, err = postPendingTransfer(ctx, q, pending, arg.AuthorizedBy)
+		return err
 	})
 
 	return result, err
+}
+
+// postPendingTransfer posts the locked pending transfer decided by the given user and pays the payment request
+// paid by the transfer
+func postPendingTransfer(ctx context.Context, q *Queries, pending Transfer, decidedBy string) (TransferTxResult, error) {
+	fromAccount, err := lockAccounts(ctx, q, pending.FromAccountID, pending.ToAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	// peníze převodu i s poplatkem jsou blokované od jeho vytvoření, takže by měly na účtu pořád být
+	if err := checkAvailableFunds(ctx, q, fromAccount, pending.Amount+pending.Fee, pending.Amount+pending.Fee); err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := postTransfer(ctx, q, pending)
+	if err != nil {
+		return result, err
+	}
+
+	result.Transfer, err = q.DecideTransfer(ctx, DecideTransferParams{
+		Status:    util.TransferPosted,
+		DecidedBy: sql.NullString{String: decidedBy, Valid: true},
+		ID:        pending.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, q.PayPendingPaymentRequest(ctx, sql.NullInt64{Int64: pending.ID, Valid: true})
+}
+
+// DecideTransferTx rejects or cancels the pending transfer without posting its entries. The payment request
+// which the transfer should have paid is open again, so the payer can pay it another way, unless it expired
+// while the transfer was pending.
+// It returns sql.ErrNoRows if the transfer isn't pending anymore.
+func (store *SQLStore) DecideTransferTx(ctx context.Context, arg DecideTransferParams) (Transfer, error) {
+	var transfer Transfer
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		transfer, err = q.DecideTransfer(ctx, arg)
+		if err != nil {
+			return err
+		}
 
+		return q.ReopenPaymentRequest(ctx, sql.NullInt64{Int64: transfer.ID, Valid: true})
+	})
+
+	return transfer, err
 }
 
 func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64, accountID2 int64, amount2 int64) (account1 Account, account2 Account, err error) {
@@ -150,6 +505,9 @@ func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64,
 		ID:     accountID1,
 		Amount: amount1,
 	})
+	if err != nil {
+		return
+	}
 
 	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
 		ID:     accountID2,
@@ -167,3 +525,313 @@ func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64,
 	return
 
 }
+
+// lockAccounts locks both accounts of the transfer with SELECT ... FOR NO KEY UPDATE
+// and returns the source account. Účty se zamykají vždy od menšího ID, stejně jako
+// v addMoney, jinak by dvě protisměrné transakce skončily v deadlocku.
+// It returns ErrAccountNotActive if one of the accounts is frozen or closed.
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Account, err error) {
+	var toAccount Account
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+		if err != nil {
+			return
+		}
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+	} else {
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+		if err != nil {
+			return
+		}
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+	}
+	if err != nil {
+		return
+	}
+
+	// stav se kontroluje až pod zámkem, API ho kontroluje jen před začátkem transakce
+	if err = checkAccountActive(fromAccount); err != nil {
+		return
+	}
+	err = checkAccountActive(toAccount)
+	return
+}
+
+// checkAccountActive returns ErrAccountNotActive if money can't be moved from or to the locked account
+func checkAccountActive(account Account) error {
+	if account.Status != util.AccountActive {
+		return fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+	return nil
+}
+
+// IdempotencyParams identifies the request executed by a transaction, the response of the request
+// is saved under the key inside the same transaction so it is never saved without the changes
+// it describes (and the other way around)
+type IdempotencyParams struct {
+	Username    string
+	Key         string
+	RequestHash string
+	// HTTP status which is returned together with the saved response
+	ResponseStatus int32
+	ExpiresAt      time.Time
+	// optional, converts the result of the transaction into the saved response
+	// when the handler doesn't respond with the result itself
+	Response func(result interface{}) interface{}
+}
+
+// saveIdempotentResponse saves the response under the idempotency key, it does nothing if params is nil
+func saveIdempotentResponse(ctx context.Context, q *Queries, params *IdempotencyParams, response interface{}) error {
+	if params == nil {
+		return nil
+	}
+	if params.Response != nil {
+		response = params.Response(response)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:       params.Username,
+		Key:            params.Key,
+		RequestHash:    params.RequestHash,
+		ResponseStatus: params.ResponseStatus,
+		ResponseBody:   body,
+		ExpiresAt:      params.ExpiresAt,
+	})
+	// insert čeká na zámek řádku, pokud stejný klíč ukládá jiná transakce, a když ta commitne,
+	// query nevrátí žádný řádek, protože živý klíč se nepřepisuje
+	if err == sql.ErrNoRows {
+		return ErrIdempotencyKeyUsed
+	}
+	return err
+}
+
+// CreateAccountTxParams contains the input parameters of the create account transaction
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	// optional, users who own the account together with its owner, they become members with the co-owner role
+	CoOwners []string `json:"co_owners"`
+	// optional, if it is set the account is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// CreateAccountTx creates the account with its owner as the member with the owner role and its co-owners
+// as the members with the co-owner role and saves it under the idempotency key within a single database transaction
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAccountMember(ctx, CreateAccountMemberParams{
+			AccountID: account.ID,
+			Username:  account.Owner,
+			Role:      util.MemberOwner,
+			AddedBy:   account.Owner,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, coOwner := range arg.CoOwners {
+			_, err = q.CreateAccountMember(ctx, CreateAccountMemberParams{
+				AccountID: account.ID,
+				Username:  coOwner,
+				Role:      util.MemberCoOwner,
+				AddedBy:   account.Owner,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, account)
+	})
+
+	return account, err
+}
+
+// DepositTxParams contains the input parameters of the deposit transaction
+type DepositTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// optional, if it is set the result is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// DepositTx deposits the money to the account as a transfer from the cash account of the system user
+// with the same currency, so the deposit has balanced entries like any other transfer
+func (store *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (TransferTxResult, error) {
+	cashAccount, err := store.cashAccount(ctx, arg.AccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: cashAccount.ID,
+		ToAccountID:   arg.AccountID,
+		Amount:        arg.Amount,
+		Idempotency:   arg.Idempotency,
+	})
+}
+
+// WithdrawTxParams contains the input parameters of the withdrawal transaction
+type WithdrawTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// optional, if it is set the result is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+	// optional, the withdrawal must fit into the transfer limits of the account like any other transfer from it
+	Limits DefaultTransferLimits `json:"-"`
+	// optional, the account member who withdraws the money, the owner of the account if it is empty
+	InitiatedBy string `json:"-"`
+	// optional, the member who withdraws the money needs approval, the withdrawal waits for the authorization
+	// of the account owner or a co-owner
+	RequireApproval bool `json:"-"`
+}
+
+// WithdrawTx withdraws the money from the account as a transfer to the cash account of the system user
+// with the same currency, it returns ErrInsufficientFunds if the balance is lower than the amount
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (TransferTxResult, error) {
+	cashAccount, err := store.cashAccount(ctx, arg.AccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return store.TransferTx(ctx, TransferTxParams{
+		FromAccountID:   arg.AccountID,
+		ToAccountID:     cashAccount.ID,
+		Amount:          arg.Amount,
+		Idempotency:     arg.Idempotency,
+		Limits:          arg.Limits,
+		InitiatedBy:     arg.InitiatedBy,
+		RequireApproval: arg.RequireApproval,
+	})
+}
+
+// cashAccount returns the cash account of the system user in the currency of the account,
+// měna účtu se nikdy nemění, takže ji není nutné číst v transakci převodu
+func (store *SQLStore) cashAccount(ctx context.Context, accountID int64) (Account, error) {
+	account, err := store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	cashAccount, err := store.GetSystemAccount(ctx, GetSystemAccountParams{
+		Currency: account.Currency,
+		Type:     util.AccountCash,
+	})
+	if err != nil {
+		return cashAccount, fmt.Errorf("cannot get the %s cash account: %w", account.Currency, err)
+	}
+
+	return cashAccount, nil
+}
+
+// ReverseTransferTxParams contains the input parameters of the reverse transfer transaction
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// amount returned from the to account in its currency, zero reverses the rest of the transfer
+	Amount int64 `json:"amount"`
+	// optional, if it is set the result is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// ReverseTransferTxResult contains the reversal and the reversed transfer with the updated reversed amount
+type ReverseTransferTxResult struct {
+	TransferTxResult
+	ReversedTransfer Transfer `json:"reversed_transfer"`
+}
+
+// ReverseTransferTx returns the money of the transfer from its to account back to its from account as a new
+// transfer linked to the reversed one. The transfer can be reversed by more partial reversals until their sum
+// reaches its to_amount. Reversed transfer is locked for the whole transaction, so concurrent reversals of the
+// same transfer can't reverse more than it moved.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalOf.Valid {
+			return fmt.Errorf("%w: transfer %d is a reversal itself", ErrInvalidReversal, original.ID)
+		}
+		if original.Status != util.TransferPosted {
+			return fmt.Errorf("%w: transfer %d is %s", ErrInvalidReversal, original.ID, original.Status)
+		}
+
+		left := original.ToAmount - original.ReversedAmount
+		if left == 0 {
+			return ErrTransferReversed
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = left
+		}
+		if amount < 0 || amount > left {
+			return fmt.Errorf("%w: amount %d is not between 1 and %d left to reverse", ErrInvalidReversal, amount, left)
+		}
+
+		// částka vrácená na původní účet se počítá z celkové vrácené částky, takže součet všech
+		// částečných vrácení je u převodu mezi měnami po posledním vrácení přesně původní amount
+		toAmount := reversedFromAmount(original, original.ReversedAmount+amount) -
+			reversedFromAmount(original, original.ReversedAmount)
+		if toAmount == 0 {
+			return fmt.Errorf("%w: amount %d is converted to zero", ErrInvalidReversal, amount)
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  reversalRate(original),
+		}, sql.NullInt64{Int64: original.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		result.ReversedTransfer, err = q.AddTransferReversedAmount(ctx, AddTransferReversedAmountParams{
+			Amount: amount,
+			ID:     original.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
+
+// reversedFromAmount returns the part of the transfer amount which corresponds to the reversed part of its to_amount,
+// it is rounded toward zero like the conversion of the transfer
+func reversedFromAmount(transfer Transfer, reversed int64) int64 {
+	amount := new(big.Int).Mul(big.NewInt(transfer.Amount), big.NewInt(reversed))
+	return amount.Quo(amount, big.NewInt(transfer.ToAmount)).Int64()
+}
+
+// reversalRate returns the exchange rate of the reversal, it is the inverse of the rate actually paid by the transfer
+func reversalRate(transfer Transfer) string {
+	if transfer.Amount == transfer.ToAmount {
+		return sameCurrencyRate
+	}
+	return new(big.Rat).SetFrac64(transfer.Amount, transfer.ToAmount).FloatString(reversalRateScale)
+}
+
+// reversalRateScale matches numeric(18,8) of the exchange_rate column
+const reversalRateScale = 8
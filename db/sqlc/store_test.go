@@ -2,8 +2,13 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
 
 	"github.com/stretchr/testify/require"
 )
@@ -11,7 +16,8 @@ import (
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
+	// zdrojový účet musí mít dost peněz na všech n transakcí, jinak by je TransferTx odmítl
+	account1 := createRandomAccountWithBalance(t, 1000+util.RandomMoney())
 	account2 := createRandomAccount(t)
 	fmt.Println(">>before:", account1.Balance, account2.Balance)
 	// The best way to test if our transaction works well
@@ -63,6 +69,9 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account2.ID, transfer.ToAccountID)
 		// množství peněz v transferu se musí shodovat se zadaným množstvím pro převod
 		require.Equal(t, amount, transfer.Amount)
+		// převod mezi účty se stejnou měnou má kurz 1
+		require.Equal(t, amount, transfer.ToAmount)
+		require.Equal(t, "1.00000000", transfer.ExchangeRate)
 		// ID transfer nesmí být 0 protože je autoincrement, který se má výplnit v databázi sám
 		require.NotZero(t, transfer.ID)
 		// CreatedAt nesmí být 0 protože databázové schéma má nastavené, aby si aktuální čas databáze tabulka
@@ -140,8 +149,9 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxReverse(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// oba účty musí pokrýt všechny transakce v jednom směru, protože pořadí go rutin není dané
+	account1 := createRandomAccountWithBalance(t, 1000+util.RandomMoney())
+	account2 := createRandomAccountWithBalance(t, 1000+util.RandomMoney())
 	fmt.Println(">>before:", account1.Balance, account2.Balance)
 	// The best way to test if our transaction works well
 	// is to run it with several concurrent go routines.
@@ -200,4 +210,341 @@ func TestTransferTxReverse(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 
-}
\ No newline at end of file
+}
+
+// This test runs more concurrent transfers than the source account can cover and checks
+// that only the covered ones succeed and the balance never goes below zero.
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	// na účtu jsou peníze jen na polovinu transakcí
+	n := 10
+	succeeded := 5
+
+	account1 := createRandomAccountWithBalance(t, int64(succeeded)*amount)
+	account2 := createRandomAccount(t)
+
+	errs := make(chan error)
+	results := make(chan TransferTxResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+
+			errs <- err
+			results <- result
+		}()
+	}
+
+	ok := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		result := <-results
+
+		if err != nil {
+			require.ErrorIs(t, err, ErrInsufficientFunds)
+			continue
+		}
+
+		ok++
+		// zůstatek nesmí klesnout pod nulu v žádném okamžiku
+		require.GreaterOrEqual(t, result.FromAccount.Balance, int64(0))
+	}
+	require.Equal(t, succeeded, ok)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+int64(succeeded)*amount, updatedAccount2.Balance)
+}
+
+// The CHECK constraint has to reject a negative balance even when the query goes around TransferTx.
+func TestAccountBalanceCheckConstraint(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -(account.Balance + 1),
+	})
+	require.Error(t, err)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+}
+
+// The retried transfer with the same idempotency key must be rolled back, so the money moves only once.
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Idempotency: &IdempotencyParams{
+			Username:       account1.Owner,
+			Key:            util.RandomString(16),
+			RequestHash:    util.RandomString(64),
+			ResponseStatus: 200,
+			ExpiresAt:      time.Now().Add(time.Minute),
+		},
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// odpověď je uložená ve stejné transakci jako převod
+	stored, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	require.NoError(t, err)
+
+	var storedResult TransferTxResult
+	err = json.Unmarshal(stored.ResponseBody, &storedResult)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, storedResult.Transfer.ID)
+
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyUsed)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+}
+
+// The to account is credited with the converted amount and the transfer records the rate and both amounts.
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ToAmount:      92,
+		ExchangeRate:  "0.92000000",
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+	require.Equal(t, arg.ToAmount, result.Transfer.ToAmount)
+	require.Equal(t, arg.ExchangeRate, result.Transfer.ExchangeRate)
+
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ToAmount, result.ToEntry.Amount)
+
+	require.Equal(t, account1.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+arg.ToAmount, result.ToAccount.Balance)
+}
+
+func getCashAccount(t *testing.T, currency string) Account {
+	cashAccount, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Currency: currency,
+		Type:     util.AccountCash,
+	})
+	require.NoError(t, err)
+	return cashAccount
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountWithBalance(t, 0)
+	amount := int64(100)
+
+	result, err := store.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	cashAccount := getCashAccount(t, account.Currency)
+	require.Equal(t, cashAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.ToAccount.Balance)
+
+	// vklad má vyrovnané záznamy, součet přes oba účty je nula
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, amount, result.ToEntry.Amount)
+	require.Equal(t, cashAccount.ID, result.FromEntry.AccountID)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountWithBalance(t, 100)
+
+	result, err := store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    60,
+	})
+	require.NoError(t, err)
+
+	cashAccount := getCashAccount(t, account.Currency)
+	require.Equal(t, account.ID, result.Transfer.FromAccountID)
+	require.Equal(t, cashAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(40), result.FromAccount.Balance)
+
+	_, err = store.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    60,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestDepositTxAccountNotFound(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.DepositTx(context.Background(), DepositTxParams{
+		AccountID: -1,
+		Amount:    100,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	// částečné vrácení
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     40,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(40), result.Transfer.Amount)
+	require.Equal(t, sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true}, result.Transfer.ReversalOf)
+	require.Equal(t, int64(40), result.ReversedTransfer.ReversedAmount)
+	require.Equal(t, int64(60), result.FromAccount.Balance)
+	require.Equal(t, int64(940), result.ToAccount.Balance)
+
+	// víc, než zbývá vrátit, vrátit nejde
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Amount:     61,
+	})
+	require.ErrorIs(t, err, ErrInvalidReversal)
+
+	// vrácení je samo o sobě převod, který vrátit nejde
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrInvalidReversal)
+
+	// bez částky se vrátí zbytek převodu
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), result.Transfer.Amount)
+	require.Equal(t, transfer.Transfer.ToAmount, result.ReversedTransfer.ReversedAmount)
+	require.Equal(t, int64(0), result.FromAccount.Balance)
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferReversed)
+
+	reversals, err := store.ListTransferReversals(context.Background(), sql.NullInt64{Int64: transfer.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+}
+
+// Concurrent reversals of the same transfer can't return more than the transfer moved.
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: transfer.Transfer.ID,
+				Amount:     30,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInvalidReversal)
+	}
+	require.Equal(t, 3, succeeded)
+
+	updatedTransfer, err := store.GetTransfer(context.Background(), transfer.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(90), updatedTransfer.ReversedAmount)
+}
+
+// The partial reversals of a cross-currency transfer return exactly the original amount in total.
+func TestReverseTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ToAmount:      92,
+		ExchangeRate:  "0.92000000",
+	})
+	require.NoError(t, err)
+
+	var returned int64
+	for _, amount := range []int64{33, 33, 26} {
+		result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+			TransferID: transfer.Transfer.ID,
+			Amount:     amount,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "1.08695652", result.Transfer.ExchangeRate)
+		returned += result.Transfer.ToAmount
+	}
+
+	require.Equal(t, transfer.Transfer.Amount, returned)
+}
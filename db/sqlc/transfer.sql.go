@@ -5,26 +5,296 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+const addTransferReversedAmount = `-- name: AddTransferReversedAmount :one
+UPDATE transfers
+SET reversed_amount = reversed_amount + $1
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by
+`
+
+type AddTransferReversedAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddTransferReversedAmount(ctx context.Context, arg AddTransferReversedAmountParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, addTransferReversedAmount, arg.Amount, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
+	)
+	return i, err
+}
+
+const authorizeTransfer = `-- name: AuthorizeTransfer :one
+UPDATE transfers
+SET authorized_by = $1
+WHERE id = $2 AND status = 'pending' AND member_approval AND authorized_by IS NULL
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by
+`
+
+type AuthorizeTransferParams struct {
+	AuthorizedBy sql.NullString `json:"authorized_by"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) AuthorizeTransfer(ctx context.Context, arg AuthorizeTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, authorizeTransfer, arg.AuthorizedBy, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
+	)
+	return i, err
+}
+
+const createBatchTransfers = `-- name: CreateBatchTransfers :many
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  status,
+  fee,
+  initiated_by,
+  member_approval
+)
+SELECT $1::bigint, item.to_account_id, item.amount, item.amount, '1', item.status, item.fee, $6::varchar, $7::boolean
+FROM unnest(
+  $2::bigint[],
+  $3::bigint[],
+  $4::varchar[],
+  $5::bigint[]
+) WITH ORDINALITY AS item(to_account_id, amount, status, fee, position)
+ORDER BY item.position
+RETURNING id
+`
+
+type CreateBatchTransfersParams struct {
+	FromAccountID  int64    `json:"from_account_id"`
+	ToAccountIds   []int64  `json:"to_account_ids"`
+	Amounts        []int64  `json:"amounts"`
+	Statuses       []string `json:"statuses"`
+	Fees           []int64  `json:"fees"`
+	InitiatedBy    string   `json:"initiated_by"`
+	MemberApproval bool     `json:"member_approval"`
+}
+
+func (q *Queries) CreateBatchTransfers(ctx context.Context, arg CreateBatchTransfersParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, createBatchTransfers,
+		arg.FromAccountID,
+		pq.Array(arg.ToAccountIds),
+		pq.Array(arg.Amounts),
+		pq.Array(arg.Statuses),
+		pq.Array(arg.Fees),
+		arg.InitiatedBy,
+		arg.MemberApproval,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createFeeTransfers = `-- name: CreateFeeTransfers :many
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  to_amount,
+  exchange_rate,
+  status,
+  fee_kind,
+  fee_of
+)
+SELECT $1::bigint, $2::bigint, fee.amount, fee.amount, '1', 'posted', 'transfer', fee.fee_of
+FROM unnest(
+  $3::bigint[],
+  $4::bigint[]
+) AS fee(fee_of, amount)
+RETURNING id
+`
+
+type CreateFeeTransfersParams struct {
+	FromAccountID int64   `json:"from_account_id"`
+	ToAccountID   int64   `json:"to_account_id"`
+	FeeOf         []int64 `json:"fee_of"`
+	Amounts       []int64 `json:"amounts"`
+}
+
+func (q *Queries) CreateFeeTransfers(ctx context.Context, arg CreateFeeTransfersParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, createFeeTransfers,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		pq.Array(arg.FeeOf),
+		pq.Array(arg.Amounts),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  status,
+  fee,
+  fee_kind,
+  fee_of,
+  initiated_by,
+  member_approval
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID  int64          `json:"from_account_id"`
+	ToAccountID    int64          `json:"to_account_id"`
+	Amount         int64          `json:"amount"`
+	ToAmount       int64          `json:"to_amount"`
+	ExchangeRate   string         `json:"exchange_rate"`
+	ReversalOf     sql.NullInt64  `json:"reversal_of"`
+	Status         string         `json:"status"`
+	Fee            int64          `json:"fee"`
+	FeeKind        sql.NullString `json:"fee_kind"`
+	FeeOf          sql.NullInt64  `json:"fee_of"`
+	InitiatedBy    sql.NullString `json:"initiated_by"`
+	MemberApproval bool           `json:"member_approval"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversalOf,
+		arg.Status,
+		arg.Fee,
+		arg.FeeKind,
+		arg.FeeOf,
+		arg.InitiatedBy,
+		arg.MemberApproval,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
+	)
+	return i, err
+}
+
+const decideTransfer = `-- name: DecideTransfer :one
+UPDATE transfers
+SET status = $1,
+  decided_by = $2,
+  decided_at = now()
+WHERE id = $3 AND status = 'pending'
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by
+`
+
+type DecideTransferParams struct {
+	Status    string         `json:"status"`
+	DecidedBy sql.NullString `json:"decided_by"`
+	ID        int64          `json:"id"`
+}
+
+func (q *Queries) DecideTransfer(ctx context.Context, arg DecideTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, decideTransfer, arg.Status, arg.DecidedBy, arg.ID)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -32,12 +302,25 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -50,31 +333,231 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Status,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.Fee,
+		&i.FeeKind,
+		&i.FeeOf,
+		&i.InitiatedBy,
+		&i.MemberApproval,
+		&i.AuthorizedBy,
+	)
+	return i, err
+}
+
+const listAllTransfers = `-- name: ListAllTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by FROM transfers
+WHERE $1::varchar IS NULL OR status = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAllTransfersParams struct {
+	Status sql.NullString `json:"status"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
+}
+
+func (q *Queries) ListAllTransfers(ctx context.Context, arg ListAllTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listAllTransfers, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
+			&i.ReversedAmount,
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.Fee,
+			&i.FeeKind,
+			&i.FeeOf,
+			&i.InitiatedBy,
+			&i.MemberApproval,
+			&i.AuthorizedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferReversals = `-- name: ListTransferReversals :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, reversed_amount, status, decided_by, decided_at, fee, fee_kind, fee_of, initiated_by, member_approval, authorized_by FROM transfers
+WHERE reversal_of = $1
+ORDER BY id
+`
+
+func (q *Queries) ListTransferReversals(ctx context.Context, reversalOf sql.NullInt64) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferReversals, reversalOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
+			&i.ReversedAmount,
+			&i.Status,
+			&i.DecidedBy,
+			&i.DecidedAt,
+			&i.Fee,
+			&i.FeeKind,
+			&i.FeeOf,
+			&i.InitiatedBy,
+			&i.MemberApproval,
+			&i.AuthorizedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, running_balance FROM (
+  SELECT
+    account_transfers.id,
+    account_transfers.from_account_id,
+    account_transfers.to_account_id,
+    account_transfers.amount,
+    account_transfers.created_at,
+    account_transfers.to_amount,
+    account_transfers.exchange_rate,
+    account_transfers.status,
+    (account_transfers.balance - SUM(account_transfers.change) OVER (ORDER BY account_transfers.id DESC) + account_transfers.change)::bigint AS running_balance
+  FROM (
+    SELECT
+      transfers.id,
+      transfers.from_account_id,
+      transfers.to_account_id,
+      transfers.amount,
+      transfers.created_at,
+      transfers.to_amount,
+      transfers.exchange_rate,
+      transfers.status,
+      accounts.balance,
+      -- jen zaúčtované převody mění zůstatek
+      (CASE WHEN transfers.status <> 'posted' THEN 0
+        ELSE (CASE WHEN transfers.to_account_id = accounts.id THEN transfers.to_amount ELSE 0 END)
+          - (CASE WHEN transfers.from_account_id = accounts.id THEN transfers.amount ELSE 0 END)
+      END) AS change
+    FROM transfers
+    JOIN accounts ON accounts.id = $1
+    WHERE transfers.from_account_id = $1 OR transfers.to_account_id = $1
+  ) AS account_transfers
+) AS statement
+WHERE ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+  AND (
+    $4::varchar = ''
+    OR ($4 = 'incoming' AND to_account_id = $1)
+    OR ($4 = 'outgoing' AND from_account_id = $1)
+  )
 ORDER BY id
-LIMIT $3
-OFFSET $4
+LIMIT $5
+OFFSET $6
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	AccountID int64        `json:"account_id"`
+	FromTime  sql.NullTime `json:"from_time"`
+	ToTime    sql.NullTime `json:"to_time"`
+	Direction string       `json:"direction"`
+	Limit     int32        `json:"limit"`
+	Offset    int32        `json:"offset"`
 }
 
-func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+type ListTransfersRow struct {
+	ID             int64     `json:"id"`
+	FromAccountID  int64     `json:"from_account_id"`
+	ToAccountID    int64     `json:"to_account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	ToAmount       int64     `json:"to_amount"`
+	ExchangeRate   string    `json:"exchange_rate"`
+	Status         string    `json:"status"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]ListTransfersRow, error) {
 	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Direction,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -82,15 +565,19 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Transfer{}
+	items := []ListTransfersRow{}
 	for rows.Next() {
-		var i Transfer
+		var i ListTransfersRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.FromAccountID,
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.RunningBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxPending(t *testing.T) {
+	store := NewStore(testDB)
+	banker := createRandomUser(t)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	pending, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            700,
+		ApprovalThreshold: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, pending.Transfer.Status)
+	require.Empty(t, pending.FromEntry)
+	require.Empty(t, pending.ToEntry)
+
+	// čekající převod peníze nepřevede, ale rezervuje je
+	account1, err = testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), account1.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        400,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// čekající převod nejde vrátit
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: pending.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrInvalidReversal)
+
+	result, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, result.Transfer.Status)
+	require.Equal(t, sql.NullString{String: banker.Username, Valid: true}, result.Transfer.DecidedBy)
+	require.True(t, result.Transfer.DecidedAt.Valid)
+	require.Equal(t, int64(-700), result.FromEntry.Amount)
+	require.Equal(t, int64(700), result.ToEntry.Amount)
+	require.Equal(t, int64(300), result.FromAccount.Balance)
+	require.Equal(t, int64(700), result.ToAccount.Balance)
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
+
+func TestTransferTxPendingCancelled(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	pending, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            700,
+		ApprovalThreshold: 500,
+	})
+	require.NoError(t, err)
+
+	cancelled, err := store.DecideTransfer(context.Background(), DecideTransferParams{
+		Status:    util.TransferCancelled,
+		DecidedBy: sql.NullString{String: account1.Owner, Valid: true},
+		ID:        pending.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferCancelled, cancelled.Status)
+
+	// rozhodnutý převod už nejde rozhodnout znovu
+	_, err = store.DecideTransfer(context.Background(), DecideTransferParams{
+		Status: util.TransferRejected,
+		ID:     pending.Transfer.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+
+	// zrušením se rezervované peníze uvolní
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, result.Transfer.Status)
+	require.Equal(t, int64(0), result.FromAccount.Balance)
+}
+
+func TestAuthorizeTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	banker := createRandomUser(t)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	pending, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          300,
+		RequireApproval: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, pending.Transfer.Status)
+	require.True(t, pending.Transfer.MemberApproval)
+
+	// bankéř převod člena neschválí, dokud ho neautorizuje vlastník účtu
+	_, err = store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferNotAuthorized)
+
+	result, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferID:        pending.Transfer.ID,
+		AuthorizedBy:      account1.Owner,
+		ApprovalThreshold: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, result.Transfer.Status)
+	require.Equal(t, sql.NullString{String: account1.Owner, Valid: true}, result.Transfer.AuthorizedBy)
+	require.Equal(t, sql.NullString{String: account1.Owner, Valid: true}, result.Transfer.DecidedBy)
+	require.Equal(t, int64(700), result.FromAccount.Balance)
+	require.Equal(t, int64(300), result.ToAccount.Balance)
+
+	_, err = store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferID:   pending.Transfer.ID,
+		AuthorizedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
+
+func TestAuthorizeTransferTxOverThreshold(t *testing.T) {
+	store := NewStore(testDB)
+	banker := createRandomUser(t)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	pending, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            700,
+		ApprovalThreshold: 500,
+		RequireApproval:   true,
+	})
+	require.NoError(t, err)
+	require.True(t, pending.Transfer.MemberApproval)
+
+	// velký převod po autorizaci vlastníkem dál čeká na bankéře
+	result, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferID:        pending.Transfer.ID,
+		AuthorizedBy:      account1.Owner,
+		ApprovalThreshold: 500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPending, result.Transfer.Status)
+	require.Equal(t, sql.NullString{String: account1.Owner, Valid: true}, result.Transfer.AuthorizedBy)
+	require.Empty(t, result.FromEntry)
+
+	_, err = store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferID:   pending.Transfer.ID,
+		AuthorizedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrTransferNotAwaitingMember)
+
+	approved, err := store.ApproveTransferTx(context.Background(), ApproveTransferTxParams{
+		TransferID: pending.Transfer.ID,
+		ApprovedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, approved.Transfer.Status)
+	require.Equal(t, int64(300), approved.FromAccount.Balance)
+}
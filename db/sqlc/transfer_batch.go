@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// TransferBatchItemParams contains one transfer of the batch
+type TransferBatchItemParams struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+// TransferBatchTxParams contains the input parameters of the transfer batch, all items are transfers
+// from one account in its currency
+type TransferBatchTxParams struct {
+	Owner         string                    `json:"owner"`
+	FromAccountID int64                     `json:"from_account_id"`
+	Mode          string                    `json:"mode"`
+	Items         []TransferBatchItemParams `json:"items"`
+	// optional, if it is set the result is saved under the idempotency key in the same transaction
+	Idempotency *IdempotencyParams `json:"-"`
+	// optional, every item must fit into the limits like a separate transfer, the items of the batch
+	// are counted into the daily and monthly limits one after another
+	Limits DefaultTransferLimits `json:"-"`
+	// optional, items with a larger amount create pending transfers waiting for the approval of a banker
+	ApprovalThreshold int64 `json:"-"`
+	// optional, every item pays the transfer fee like a separate transfer
+	ChargeFee bool `json:"-"`
+	// optional, the owner of the batch is an account member whose transfers need approval, all items create
+	// pending transfers waiting for the authorization of the account owner or a co-owner
+	RequireApproval bool `json:"-"`
+}
+
+// TransferBatchTxResult contains the batch with the results of all its items ordered by their position
+type TransferBatchTxResult struct {
+	Batch       TransferBatch       `json:"batch"`
+	FromAccount Account             `json:"from_account"`
+	Items       []TransferBatchItem `json:"items"`
+}
+
+// TransferBatchTx executes the transfers of the batch in one transaction. All accounts of the batch are locked
+// by one query in the order of their IDs, like in lockAccounts, the items are checked in memory and the transfers,
+// entries and balances are written by a few bulk queries, so the number of queries doesn't grow with the number
+// of items. An item which can't be executed is failed with the reason, in the all or nothing mode the other items
+// are then skipped and no money is moved. The batch with the results of the items is saved in every mode.
+func (store *SQLStore) TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = transferBatch(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		return saveIdempotentResponse(ctx, q, arg.Idempotency, result)
+	})
+
+	return result, err
+}
+
+func transferBatch(ctx context.Context, q *Queries, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	ids := make([]int64, 0, len(arg.Items)+1)
+	ids = append(ids, arg.FromAccountID)
+	for _, item := range arg.Items {
+		ids = append(ids, item.ToAccountID)
+	}
+
+	locked, err := q.GetAccountsForUpdate(ctx, ids)
+	if err != nil {
+		return result, err
+	}
+	accounts := make(map[int64]Account, len(locked))
+	for _, account := range locked {
+		accounts[account.ID] = account
+	}
+
+	fromAccount, ok := accounts[arg.FromAccountID]
+	if !ok {
+		return result, sql.ErrNoRows
+	}
+	if err := checkAccountActive(fromAccount); err != nil {
+		return result, err
+	}
+
+	reservedAmount, err := q.GetAccountReservedAmount(ctx, fromAccount.ID)
+	if err != nil {
+		return result, err
+	}
+	available := fromAccount.Balance + fromAccount.OverdraftLimit - reservedAmount
+
+	// dávka se počítá do limitů člena, který ji poslal, stejně jako jeho jednotlivé převody
+	initiatedBy := arg.Owner
+	if initiatedBy == "" {
+		initiatedBy = fromAccount.Owner
+	}
+
+	var allowance *TransferAllowance
+	if arg.Limits != nil {
+		// zámek odesílatele se bere po zámcích účtů, stejně jako v transfer
+		if _, err := q.GetUserForUpdate(ctx, initiatedBy); err != nil {
+			return result, err
+		}
+
+		userAllowance, err := transferAllowance(ctx, q, fromAccount, initiatedBy, arg.Limits, time.Now())
+		if err != nil {
+			return result, err
+		}
+		allowance = &userAllowance
+	}
+
+	var feeSchedule *FeeSchedule
+	if arg.ChargeFee {
+		feeSchedule, err = transferFeeSchedule(ctx, q, fromAccount)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	n := len(arg.Items)
+	items := CreateTransferBatchItemsParams{
+		Positions:    make([]int32, n),
+		ToAccountIds: make([]int64, n),
+		Amounts:      make([]int64, n),
+		Statuses:     make([]string, n),
+		TransferIds:  make([]int64, n),
+		Errors:       make([]string, n),
+	}
+	fees := make([]int64, n)
+
+	// pozice položek, které projdou všemi kontrolami, v pořadí dávky
+	var accepted []int
+	for i, item := range arg.Items {
+		items.Positions[i] = int32(i)
+		items.ToAccountIds[i] = item.ToAccountID
+		items.Amounts[i] = item.Amount
+
+		err := checkBatchItem(fromAccount, accounts, item)
+		if err == nil && feeSchedule != nil {
+			fees[i], err = computeFee(*feeSchedule, item.Amount)
+		}
+		if err == nil && item.Amount+fees[i] > available {
+			err = ErrInsufficientFunds
+		}
+		if err == nil && allowance != nil {
+			err = allowance.check(item.Amount)
+		}
+		if err != nil {
+			items.Statuses[i] = util.BatchItemFailed
+			items.Errors[i] = err.Error()
+			continue
+		}
+
+		available -= item.Amount + fees[i]
+		if allowance != nil {
+			allowance.use(item.Amount)
+		}
+
+		items.Statuses[i] = util.TransferPosted
+		if arg.RequireApproval || arg.ApprovalThreshold > 0 && item.Amount > arg.ApprovalThreshold {
+			items.Statuses[i] = util.TransferPending
+		}
+		accepted = append(accepted, i)
+	}
+
+	failedCount := n - len(accepted)
+	if arg.Mode == util.BatchAllOrNothing && failedCount > 0 {
+		for _, i := range accepted {
+			items.Statuses[i] = util.BatchItemSkipped
+		}
+		accepted = nil
+	}
+
+	result.FromAccount = fromAccount
+	var totalAmount int64
+	if len(accepted) > 0 {
+		result.FromAccount, totalAmount, err = executeBatchItems(ctx, q, fromAccount, initiatedBy, arg.RequireApproval, accepted, &items, fees)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	status := util.BatchCompleted
+	switch {
+	case len(accepted) == 0:
+		status = util.BatchFailed
+	case failedCount > 0:
+		status = util.BatchPartiallyCompleted
+	}
+
+	result.Batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+		Owner:          arg.Owner,
+		FromAccountID:  fromAccount.ID,
+		Mode:           arg.Mode,
+		Status:         status,
+		ItemCount:      int32(n),
+		SucceededCount: int32(len(accepted)),
+		FailedCount:    int32(failedCount),
+		TotalAmount:    totalAmount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	items.BatchID = result.Batch.ID
+	result.Items, err = q.CreateTransferBatchItems(ctx, items)
+	if err != nil {
+		return result, err
+	}
+	// RETURNING nezaručuje pořadí řádků
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].Position < result.Items[j].Position
+	})
+
+	return result, nil
+}
+
+// checkBatchItem checks the to account of the item like the API checks the to account of a single transfer
+func checkBatchItem(fromAccount Account, accounts map[int64]Account, item TransferBatchItemParams) error {
+	toAccount, ok := accounts[item.ToAccountID]
+	switch {
+	case !ok:
+		return fmt.Errorf("account [%d] not found", item.ToAccountID)
+	case toAccount.ID == fromAccount.ID:
+		return fmt.Errorf("account [%d] is the from account", item.ToAccountID)
+	case toAccount.Owner == util.SystemUser:
+		return fmt.Errorf("account [%d] is a system account", item.ToAccountID)
+	case toAccount.Status != util.AccountActive:
+		return fmt.Errorf("account [%d] is %s", item.ToAccountID, toAccount.Status)
+	case toAccount.Currency != fromAccount.Currency:
+		return fmt.Errorf("account [%d] currency mismatch: %s vs %s", item.ToAccountID, toAccount.Currency, fromAccount.Currency)
+	}
+	return nil
+}
+
+// executeBatchItems creates the transfers of the accepted items, posts the entries of the posted ones together
+// with their fee transfers and moves their money, it sets the transfer IDs of the items and returns the updated
+// from account and the total amount without the fees. Fees are indexed by the positions of the items.
+// The transfers of a member who needs approval wait for the authorization of the account owner.
+func executeBatchItems(ctx context.Context, q *Queries, fromAccount Account, initiatedBy string, memberApproval bool, accepted []int, items *CreateTransferBatchItemsParams, fees []int64) (Account, int64, error) {
+	transfers := CreateBatchTransfersParams{
+		FromAccountID:  fromAccount.ID,
+		InitiatedBy:    initiatedBy,
+		MemberApproval: memberApproval,
+		ToAccountIds:   make([]int64, len(accepted)),
+		Amounts:        make([]int64, len(accepted)),
+		Statuses:       make([]string, len(accepted)),
+		Fees:           make([]int64, len(accepted)),
+	}
+	for k, i := range accepted {
+		transfers.ToAccountIds[k] = items.ToAccountIds[i]
+		transfers.Amounts[k] = items.Amounts[i]
+		transfers.Statuses[k] = items.Statuses[i]
+		transfers.Fees[k] = fees[i]
+	}
+
+	transferIDs, err := q.CreateBatchTransfers(ctx, transfers)
+	if err != nil {
+		return fromAccount, 0, err
+	}
+	if len(transferIDs) != len(accepted) {
+		return fromAccount, 0, fmt.Errorf("created %d transfers for %d items", len(transferIDs), len(accepted))
+	}
+	// ID převodů se přidělují v pořadí položek díky ORDER BY v insertu, RETURNING ale pořadí nezaručuje
+	sort.Slice(transferIDs, func(i, j int) bool { return transferIDs[i] < transferIDs[j] })
+
+	var totalAmount, debit, feeTotal int64
+	var posted []int64
+	feeTransfers := CreateFeeTransfersParams{FromAccountID: fromAccount.ID}
+	credits := make(map[int64]int64)
+	for k, i := range accepted {
+		items.TransferIds[i] = transferIDs[k]
+		totalAmount += items.Amounts[i]
+
+		// čekající převod jen blokuje peníze na účtu, záznamy se vytvoří až po schválení
+		if items.Statuses[i] != util.TransferPosted {
+			continue
+		}
+		posted = append(posted, transferIDs[k])
+		debit += items.Amounts[i] + fees[i]
+		credits[items.ToAccountIds[i]] += items.Amounts[i]
+
+		if fees[i] > 0 {
+			feeTransfers.FeeOf = append(feeTransfers.FeeOf, transferIDs[k])
+			feeTransfers.Amounts = append(feeTransfers.Amounts, fees[i])
+			feeTotal += fees[i]
+		}
+	}
+
+	if len(posted) == 0 {
+		return fromAccount, totalAmount, nil
+	}
+
+	// poplatky se platí samostatnými převody, stejně jako u jednotlivého převodu v postTransfer
+	if feeTotal > 0 {
+		revenueAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Currency: fromAccount.Currency,
+			Type:     util.AccountFeeRevenue,
+		})
+		if err != nil {
+			return fromAccount, 0, fmt.Errorf("cannot get the %s fee revenue account: %w", fromAccount.Currency, err)
+		}
+		feeTransfers.ToAccountID = revenueAccount.ID
+
+		feeIDs, err := q.CreateFeeTransfers(ctx, feeTransfers)
+		if err != nil {
+			return fromAccount, 0, err
+		}
+		posted = append(posted, feeIDs...)
+		credits[revenueAccount.ID] += feeTotal
+	}
+
+	if err := q.CreateTransferEntries(ctx, posted); err != nil {
+		return fromAccount, 0, err
+	}
+
+	fromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     fromAccount.ID,
+		Amount: -debit,
+	})
+	if err != nil {
+		return fromAccount, 0, err
+	}
+
+	// každý účet se připíše jednou, i když je příjemcem více položek
+	balances := AddAccountBalancesParams{
+		Ids:     make([]int64, 0, len(credits)),
+		Amounts: make([]int64, 0, len(credits)),
+	}
+	for accountID, amount := range credits {
+		balances.Ids = append(balances.Ids, accountID)
+		balances.Amounts = append(balances.Amounts, amount)
+	}
+	if err := q.AddAccountBalances(ctx, balances); err != nil {
+		return fromAccount, 0, err
+	}
+
+	return fromAccount, totalAmount, nil
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomAccountInCurrency creates an empty account of a new user in the given currency,
+// the items of a batch must have the same currency as the from account
+func createRandomAccountInCurrency(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: currency,
+		Type:     util.AccountChecking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestTransferBatchTxBestEffort(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount1 := createRandomAccountInCurrency(t, fromAccount.Currency)
+	toAccount2 := createRandomAccountInCurrency(t, fromAccount.Currency)
+
+	otherCurrency := util.USD
+	if fromAccount.Currency == util.USD {
+		otherCurrency = util.EUR
+	}
+	otherAccount := createRandomAccountInCurrency(t, otherCurrency)
+
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.BatchBestEffort,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: toAccount1.ID, Amount: 300},
+			{ToAccountID: otherAccount.ID, Amount: 100},
+			{ToAccountID: toAccount2.ID, Amount: 200},
+			{ToAccountID: toAccount1.ID, Amount: 100},
+			// na tuto položku už nezbývá dost peněz
+			{ToAccountID: toAccount2.ID, Amount: 500},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.BatchPartiallyCompleted, result.Batch.Status)
+	require.Equal(t, int32(5), result.Batch.ItemCount)
+	require.Equal(t, int32(3), result.Batch.SucceededCount)
+	require.Equal(t, int32(2), result.Batch.FailedCount)
+	require.Equal(t, int64(600), result.Batch.TotalAmount)
+	require.Equal(t, int64(400), result.FromAccount.Balance)
+
+	require.Len(t, result.Items, 5)
+	expected := []string{
+		util.TransferPosted,
+		util.BatchItemFailed,
+		util.TransferPosted,
+		util.TransferPosted,
+		util.BatchItemFailed,
+	}
+	for i, item := range result.Items {
+		require.Equal(t, int32(i), item.Position)
+		require.Equal(t, expected[i], item.Status)
+		require.Equal(t, item.Status == util.TransferPosted, item.TransferID.Valid)
+		require.Equal(t, item.Status == util.TransferPosted, item.Error == "")
+	}
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Items[4].Error)
+
+	for _, item := range result.Items {
+		if !item.TransferID.Valid {
+			continue
+		}
+		transfer, err := store.GetTransfer(context.Background(), item.TransferID.Int64)
+		require.NoError(t, err)
+		require.Equal(t, fromAccount.ID, transfer.FromAccountID)
+		require.Equal(t, item.ToAccountID, transfer.ToAccountID)
+		require.Equal(t, item.Amount, transfer.Amount)
+	}
+
+	account1, err := store.GetAccount(context.Background(), toAccount1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), account1.Balance)
+
+	account2, err := store.GetAccount(context.Background(), toAccount2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), account2.Balance)
+
+	entries, err := store.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: fromAccount.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	failed, err := store.ListTransferBatchItems(context.Background(), ListTransferBatchItemsParams{
+		BatchID: result.Batch.ID,
+		Status:  sql.NullString{String: util.BatchItemFailed, Valid: true},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	require.Equal(t, int32(1), failed[0].Position)
+	require.Equal(t, int32(4), failed[1].Position)
+}
+
+func TestTransferBatchTxAllOrNothing(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.BatchAllOrNothing,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: toAccount.ID, Amount: 300},
+			{ToAccountID: fromAccount.ID, Amount: 100},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, util.BatchFailed, result.Batch.Status)
+	require.Equal(t, int32(0), result.Batch.SucceededCount)
+	require.Equal(t, int32(1), result.Batch.FailedCount)
+	require.Zero(t, result.Batch.TotalAmount)
+	require.Equal(t, util.BatchItemSkipped, result.Items[0].Status)
+	require.False(t, result.Items[0].TransferID.Valid)
+	require.Equal(t, util.BatchItemFailed, result.Items[1].Status)
+
+	account1, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account1.Balance)
+
+	account2, err := store.GetAccount(context.Background(), toAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, account2.Balance)
+}
+
+func TestTransferBatchTxRequireApproval(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+	member := createRandomUser(t)
+	addAccountMember(t, fromAccount, member.Username, util.MemberNeedsApproval)
+
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:           member.Username,
+		FromAccountID:   fromAccount.ID,
+		Mode:            util.BatchAllOrNothing,
+		Items:           []TransferBatchItemParams{{ToAccountID: toAccount.ID, Amount: 300}},
+		RequireApproval: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.BatchCompleted, result.Batch.Status)
+	require.Equal(t, util.TransferPending, result.Items[0].Status)
+	require.Equal(t, fromAccount.Balance, result.FromAccount.Balance)
+
+	// položky člena autorizuje vlastník účtu jako jeho jednotlivé převody
+	transfer, err := store.GetTransfer(context.Background(), result.Items[0].TransferID.Int64)
+	require.NoError(t, err)
+	require.True(t, transfer.MemberApproval)
+	require.Equal(t, sql.NullString{String: member.Username, Valid: true}, transfer.InitiatedBy)
+
+	authorized, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		TransferID:   transfer.ID,
+		AuthorizedBy: fromAccount.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.TransferPosted, authorized.Transfer.Status)
+	require.Equal(t, fromAccount.Balance-300, authorized.FromAccount.Balance)
+}
+
+func TestListTransferBatches(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createRandomAccountWithBalance(t, 1000)
+	toAccount := createRandomAccountInCurrency(t, fromAccount.Currency)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+			Owner:         fromAccount.Owner,
+			FromAccountID: fromAccount.ID,
+			Mode:          util.BatchAllOrNothing,
+			Items:         []TransferBatchItemParams{{ToAccountID: toAccount.ID, Amount: 10}},
+		})
+		require.NoError(t, err)
+	}
+
+	batches, err := store.ListTransferBatches(context.Background(), ListTransferBatchesParams{
+		Owner: fromAccount.Owner,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 3)
+	require.Greater(t, batches[0].ID, batches[1].ID)
+	for _, batch := range batches {
+		require.Equal(t, util.BatchCompleted, batch.Status)
+	}
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// TransferLimits are the limits of the outgoing transfers in minor units of the currency, zero is unlimited
+type TransferLimits struct {
+	PerTransaction int64 `json:"per_transaction"`
+	Daily          int64 `json:"daily"`
+	Monthly        int64 `json:"monthly"`
+}
+
+// DefaultTransferLimits are the transfer limits of the users without their own limits, keyed by the currency,
+// a currency without the default limits is unlimited
+type DefaultTransferLimits map[string]TransferLimits
+
+// NewDefaultTransferLimits returns the default transfer limits of the users from the config
+func NewDefaultTransferLimits(config util.Config) DefaultTransferLimits {
+	return DefaultTransferLimits{
+		util.USD: {
+			PerTransaction: config.TransferLimitPerTransactionUSD,
+			Daily:          config.TransferLimitDailyUSD,
+			Monthly:        config.TransferLimitMonthlyUSD,
+		},
+		util.EUR: {
+			PerTransaction: config.TransferLimitPerTransactionEUR,
+			Daily:          config.TransferLimitDailyEUR,
+			Monthly:        config.TransferLimitMonthlyEUR,
+		},
+		util.CAD: {
+			PerTransaction: config.TransferLimitPerTransactionCAD,
+			Daily:          config.TransferLimitDailyCAD,
+			Monthly:        config.TransferLimitMonthlyCAD,
+		},
+	}
+}
+
+// LimitUsage shows one limit of the account and of the user who sends the transfer together with the amounts already
+// transferred in the period of the limit
+type LimitUsage struct {
+	AccountLimit int64 `json:"account_limit"`
+	UserLimit    int64 `json:"user_limit"`
+	AccountUsed  int64 `json:"account_used"`
+	UserUsed     int64 `json:"user_used"`
+}
+
+// Remaining returns the amount which can still be transferred, limited is false if neither
+// the account nor the user has the limit set
+func (usage LimitUsage) Remaining() (remaining int64, limited bool) {
+	for _, limit := range []struct{ limit, used int64 }{
+		{usage.AccountLimit, usage.AccountUsed},
+		{usage.UserLimit, usage.UserUsed},
+	} {
+		if limit.limit == 0 {
+			continue
+		}
+		// limit mohl bankéř snížit pod už převedenou částku
+		left := limit.limit - limit.used
+		if left < 0 {
+			left = 0
+		}
+		if !limited || left < remaining {
+			remaining, limited = left, true
+		}
+	}
+	return
+}
+
+// TransferCountUsage shows the maximum number of the transfers from the account in the month
+// together with the number of the transfers already created in the month, zero limit is unlimited
+type TransferCountUsage struct {
+	Limit int32 `json:"limit"`
+	Used  int64 `json:"used"`
+}
+
+// TransferAllowance contains the limits which apply to the transfers from one account, the reversals
+// which refund received transfers don't count into any of them
+type TransferAllowance struct {
+	PerTransaction LimitUsage `json:"per_transaction"`
+	Daily          LimitUsage `json:"daily"`
+	Monthly        LimitUsage `json:"monthly"`
+	// limited only for the savings accounts
+	MonthlyTransfers TransferCountUsage `json:"monthly_transfers"`
+}
+
+// check returns ErrTransferLimitExceeded if the amount doesn't fit into any of the limits
+func (allowance TransferAllowance) check(amount int64) error {
+	for _, period := range []struct {
+		name  string
+		usage LimitUsage
+	}{
+		{"per transaction", allowance.PerTransaction},
+		{"daily", allowance.Daily},
+		{"monthly", allowance.Monthly},
+	} {
+		if remaining, limited := period.usage.Remaining(); limited && amount > remaining {
+			return fmt.Errorf("%w: %s limit allows only %d more", ErrTransferLimitExceeded, period.name, remaining)
+		}
+	}
+
+	count := allowance.MonthlyTransfers
+	if count.Limit > 0 && count.Used >= int64(count.Limit) {
+		return fmt.Errorf("%w: account allows only %d transfers per month", ErrTransferLimitExceeded, count.Limit)
+	}
+	return nil
+}
+
+// use adds the amount to the used amounts of the daily and monthly limits, so the next check
+// counts with the transfer created in the same transaction, e.g. by the previous item of the batch
+func (allowance *TransferAllowance) use(amount int64) {
+	for _, usage := range []*LimitUsage{&allowance.Daily, &allowance.Monthly} {
+		usage.AccountUsed += amount
+		usage.UserUsed += amount
+	}
+	allowance.MonthlyTransfers.Used++
+}
+
+// GetTransferAllowance returns the limits of the transfers sent from the account by the user and the amounts
+// already transferred in the current day and month, defaults in the currency of the account are used if the user
+// has no own limits set
+func (store *SQLStore) GetTransferAllowance(ctx context.Context, account Account, username string, defaults DefaultTransferLimits) (TransferAllowance, error) {
+	return transferAllowance(ctx, store.Queries, account, username, defaults, time.Now())
+}
+
+// transferAllowance reads the limits and the usage of the account inside the transaction of q. Limits of the account
+// are optional and only tighten the limits of its owner, which apply to all owner's accounts in the same currency.
+// Dny a měsíce se počítají v UTC, stejně pro všechny uživatele.
+func transferAllowance(ctx context.Context, q *Queries, account Account, username string, defaults DefaultTransferLimits, now time.Time) (TransferAllowance, error) {
+	var allowance TransferAllowance
+
+	var accountLimits TransferLimits
+	accountLimit, err := q.GetAccountTransferLimit(ctx, sql.NullInt64{Int64: account.ID, Valid: true})
+	switch {
+	case err == nil:
+		accountLimits = transferLimits(accountLimit)
+	case err != sql.ErrNoRows:
+		return allowance, err
+	}
+
+	userLimits := defaults[account.Currency]
+	userLimit, err := q.GetUserTransferLimit(ctx, GetUserTransferLimitParams{
+		Owner:    sql.NullString{String: username, Valid: true},
+		Currency: account.Currency,
+	})
+	switch {
+	case err == nil:
+		userLimits = transferLimits(userLimit)
+	case err != sql.ErrNoRows:
+		return allowance, err
+	}
+
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	accountUsage, err := q.GetAccountTransferUsage(ctx, GetAccountTransferUsageParams{
+		DayStart:   dayStart,
+		AccountID:  account.ID,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return allowance, err
+	}
+
+	userUsage, err := q.GetUserTransferUsage(ctx, GetUserTransferUsageParams{
+		DayStart:   dayStart,
+		Username:   username,
+		Currency:   account.Currency,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return allowance, err
+	}
+
+	allowance.PerTransaction = LimitUsage{
+		AccountLimit: accountLimits.PerTransaction,
+		UserLimit:    userLimits.PerTransaction,
+	}
+	allowance.Daily = LimitUsage{
+		AccountLimit: accountLimits.Daily,
+		UserLimit:    userLimits.Daily,
+		AccountUsed:  accountUsage.Daily,
+		UserUsed:     userUsage.Daily,
+	}
+	allowance.Monthly = LimitUsage{
+		AccountLimit: accountLimits.Monthly,
+		UserLimit:    userLimits.Monthly,
+		AccountUsed:  accountUsage.Monthly,
+		UserUsed:     userUsage.Monthly,
+	}
+	allowance.MonthlyTransfers = TransferCountUsage{
+		Limit: account.MaxMonthlyTransfers,
+		Used:  accountUsage.MonthlyCount,
+	}
+
+	return allowance, nil
+}
+
+func transferLimits(limit TransferLimit) TransferLimits {
+	return TransferLimits{
+		PerTransaction: limit.PerTransaction,
+		Daily:          limit.Daily,
+		Monthly:        limit.Monthly,
+	}
+}
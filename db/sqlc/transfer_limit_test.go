@@ -0,0 +1,284 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitUsageRemaining(t *testing.T) {
+	_, limited := LimitUsage{AccountUsed: 100, UserUsed: 100}.Remaining()
+	require.False(t, limited)
+
+	remaining, limited := LimitUsage{AccountLimit: 300, UserLimit: 1000, AccountUsed: 100, UserUsed: 900}.Remaining()
+	require.True(t, limited)
+	require.Equal(t, int64(100), remaining)
+
+	// snížený limit pod už převedenou částku nepovolí nic, ale nevrací záporné číslo
+	remaining, limited = LimitUsage{AccountLimit: 50, AccountUsed: 100}.Remaining()
+	require.True(t, limited)
+	require.Zero(t, remaining)
+}
+
+func TestUpsertTransferLimits(t *testing.T) {
+	account := createRandomAccount(t)
+
+	arg := UpsertAccountTransferLimitParams{
+		AccountID:      sql.NullInt64{Int64: account.ID, Valid: true},
+		Currency:       account.Currency,
+		PerTransaction: 100,
+		Daily:          1000,
+	}
+	limit1, err := testQueries.UpsertAccountTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, limit1.AccountID)
+	require.False(t, limit1.Owner.Valid)
+
+	arg.Daily = 500
+	limit2, err := testQueries.UpsertAccountTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, limit1.ID, limit2.ID)
+	require.Equal(t, int64(500), limit2.Daily)
+
+	userLimit, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Owner:    sql.NullString{String: account.Owner, Valid: true},
+		Currency: account.Currency,
+		Monthly:  10000,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, limit1.ID, userLimit.ID)
+
+	gotLimit, err := testQueries.GetUserTransferLimit(context.Background(), GetUserTransferLimitParams{
+		Owner:    sql.NullString{String: account.Owner, Valid: true},
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, userLimit.ID, gotLimit.ID)
+	require.Equal(t, int64(10000), gotLimit.Monthly)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(50)
+	n := 10
+	succeeded := 4
+
+	account1 := createRandomAccountWithBalance(t, int64(n)*amount)
+	account2 := createRandomAccount(t)
+
+	_, err := testQueries.UpsertAccountTransferLimit(context.Background(), UpsertAccountTransferLimitParams{
+		AccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		Currency:  account1.Currency,
+		Daily:     int64(succeeded) * amount,
+	})
+	require.NoError(t, err)
+
+	defaults := DefaultTransferLimits{account1.Currency: {PerTransaction: amount}}
+
+	// převod nad limit jedné transakce neprojde ani s dostatkem peněz
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount + 1,
+		Limits:        defaults,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	// souběžné převody nesmí denní limit překročit
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Limits:        defaults,
+			})
+			errs <- err
+		}()
+	}
+
+	ok := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err != nil {
+			require.ErrorIs(t, err, ErrTransferLimitExceeded)
+			continue
+		}
+		ok++
+	}
+	require.Equal(t, succeeded, ok)
+
+	allowance, err := store.GetTransferAllowance(context.Background(), account1, account1.Owner, defaults)
+	require.NoError(t, err)
+	require.Equal(t, int64(succeeded)*amount, allowance.Daily.AccountUsed)
+	require.Equal(t, int64(succeeded)*amount, allowance.Monthly.UserUsed)
+
+	remaining, limited := allowance.Daily.Remaining()
+	require.True(t, limited)
+	require.Zero(t, remaining)
+
+	// bez limitů v parametrech se převod nekontroluje, např. u vrácení peněz
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxUserLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccount(t)
+
+	// limity uživatele nahrazují výchozí limity, nula je bez limitu
+	_, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Owner:    sql.NullString{String: account1.Owner, Valid: true},
+		Currency: account1.Currency,
+		Monthly:  100,
+	})
+	require.NoError(t, err)
+
+	defaults := DefaultTransferLimits{account1.Currency: {PerTransaction: 10}}
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        60,
+		Limits:        defaults,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        60,
+		Limits:        defaults,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+}
+
+func TestTransferTxInitiatorLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccount(t)
+	coOwner := createRandomUser(t)
+	addAccountMember(t, account1, coOwner.Username, util.MemberCoOwner)
+
+	// vlastník má přísný limit, spoluvlastník posílá převody v rámci svého vlastního
+	defaults := DefaultTransferLimits{account1.Currency: {Daily: 100}}
+	_, err := testQueries.UpsertUserTransferLimit(context.Background(), UpsertUserTransferLimitParams{
+		Owner:    sql.NullString{String: coOwner.Username, Valid: true},
+		Currency: account1.Currency,
+		Daily:    500,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        400,
+		Limits:        defaults,
+		InitiatedBy:   coOwner.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sql.NullString{String: coOwner.Username, Valid: true}, result.Transfer.InitiatedBy)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        200,
+		Limits:        defaults,
+		InitiatedBy:   coOwner.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	// převod spoluvlastníka se do limitu vlastníka nepočítá, do limitu účtu ano
+	ownerAllowance, err := store.GetTransferAllowance(context.Background(), account1, account1.Owner, defaults)
+	require.NoError(t, err)
+	require.Zero(t, ownerAllowance.Daily.UserUsed)
+	require.Equal(t, int64(400), ownerAllowance.Daily.AccountUsed)
+
+	coOwnerAllowance, err := store.GetTransferAllowance(context.Background(), account1, coOwner.Username, defaults)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), coOwnerAllowance.Daily.UserUsed)
+	require.Equal(t, int64(500), coOwnerAllowance.Daily.UserLimit)
+}
+
+func TestTransferTxDefaultLimitsPerCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccountInCurrency(t, util.EUR)
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account1.ID, Amount: 1000})
+	require.NoError(t, err)
+	account2 := createRandomAccountInCurrency(t, util.EUR)
+
+	// výchozí limity jiné měny se na účet v EUR nevztahují
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Limits:        DefaultTransferLimits{util.USD: {PerTransaction: 10}},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Limits:        DefaultTransferLimits{util.USD: {PerTransaction: 1000}, util.EUR: {PerTransaction: 10}},
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+}
+
+func TestTransferAllowanceMonthlyTransfers(t *testing.T) {
+	allowance := TransferAllowance{MonthlyTransfers: TransferCountUsage{Limit: 2, Used: 1}}
+	require.NoError(t, allowance.check(100))
+
+	// převod vytvořený ve stejné transakci se započítá do dalšího
+	allowance.use(100)
+	require.ErrorIs(t, allowance.check(100), ErrTransferLimitExceeded)
+
+	unlimited := TransferAllowance{MonthlyTransfers: TransferCountUsage{Used: 100}}
+	require.NoError(t, unlimited.check(100))
+}
+
+func TestTransferAllowanceReversals(t *testing.T) {
+	store := NewStore(testDB)
+
+	sender := createRandomAccountWithBalance(t, 1000)
+	recipient := createRandomAccountInCurrency(t, sender.Currency)
+
+	defaults := DefaultTransferLimits{recipient.Currency: {Daily: 500, Monthly: 500}}
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   recipient.ID,
+		Amount:        400,
+	})
+	require.NoError(t, err)
+
+	// vrácení omylem přijatého převodu nesnižuje limity toho, kdo peníze vrací
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.ID,
+	})
+	require.NoError(t, err)
+
+	allowance, err := store.GetTransferAllowance(context.Background(), recipient, recipient.Owner, defaults)
+	require.NoError(t, err)
+	require.Zero(t, allowance.Daily.AccountUsed)
+	require.Zero(t, allowance.Daily.UserUsed)
+	require.Zero(t, allowance.Monthly.UserUsed)
+	require.Zero(t, allowance.MonthlyTransfers.Used)
+
+	remaining, limited := allowance.Daily.Remaining()
+	require.True(t, limited)
+	require.Equal(t, int64(500), remaining)
+}
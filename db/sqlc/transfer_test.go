@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createTransfers moves money between the accounts: 3 transfers from account1 and 2 back
+func createTransfers(t *testing.T, account1 Account, account2 Account) []TransferTxResult {
+	store := NewStore(testDB)
+
+	var results []TransferTxResult
+	for i := 0; i < 5; i++ {
+		arg := TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        int64(10 * (i + 1)),
+		}
+		if i%2 == 1 {
+			arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+		}
+
+		result, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func TestListTransfers(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	results := createTransfers(t, account1, account2)
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, len(results))
+
+	// zůstatek po každém převodu musí odpovídat zůstatku, který vrátila transakce
+	for i, transfer := range transfers {
+		require.Equal(t, results[i].Transfer.ID, transfer.ID)
+
+		balance := results[i].FromAccount.Balance
+		if transfer.ToAccountID == account1.ID {
+			balance = results[i].ToAccount.Balance
+		}
+		require.Equal(t, balance, transfer.RunningBalance)
+	}
+
+	incoming, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		Direction: "incoming",
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, incoming, 2)
+	for _, transfer := range incoming {
+		require.Equal(t, account1.ID, transfer.ToAccountID)
+	}
+
+	outgoing, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		Direction: "outgoing",
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 3)
+	for _, transfer := range outgoing {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+	}
+}
+
+func TestListTransfersTimeRange(t *testing.T) {
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 1000)
+	createTransfers(t, account1, account2)
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		FromTime:  sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	transfers, err = testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		FromTime:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		ToTime:    sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+}
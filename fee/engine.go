@@ -0,0 +1,97 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// DefaultInterval is used when the configured interval is not positive
+const DefaultInterval = time.Hour
+
+// Engine charges the monthly maintenance fee to the accounts whose balance at the end of the previous month
+// was below the minimum balance of the maintenance fee schedule of their currency. The charged fees are saved
+// per account and month, so the engine can run every interval and more engines (one in every replica
+// of the server) never charge the same month twice.
+type Engine struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewEngine creates a new engine which looks for the uncharged accounts every interval
+func NewEngine(store db.Store, interval time.Duration) *Engine {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Engine{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run charges the maintenance fees every interval until the context is cancelled
+func (engine *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(engine.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := engine.RunDue(ctx, time.Now()); err != nil {
+			log.Println("cannot charge maintenance fees:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue charges the maintenance fees of the UTC month before now and returns the number of charged accounts.
+// Only the previous month is charged, the months missed when no server was running are not charged retroactively.
+func (engine *Engine) RunDue(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	return engine.ChargeMonth(ctx, month)
+}
+
+// ChargeMonth charges the maintenance fee of the month given by its first day to all accounts which are below
+// the minimum balance at its end and weren't charged yet. The account which fails is only logged and tried
+// again by the next run, so one account can't stop the fees of the others.
+func (engine *Engine) ChargeMonth(ctx context.Context, month time.Time) (int, error) {
+	accounts, err := engine.store.ListMaintenanceFeeAccounts(ctx, db.ListMaintenanceFeeAccountsParams{
+		MonthEnd: month.AddDate(0, 1, 0),
+		Month:    month,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			return charged, ctx.Err()
+		}
+
+		_, err := engine.store.ChargeMaintenanceFeeTx(ctx, db.ChargeMaintenanceFeeTxParams{
+			AccountID: account.AccountID,
+			Month:     month,
+			Balance:   account.Balance,
+		})
+		if err != nil {
+			if !errors.Is(err, db.ErrFeeCharged) {
+				log.Printf("cannot charge the maintenance fee of account %d for %s: %v",
+					account.AccountID, month.Format("2006-01"), err)
+			}
+			continue
+		}
+		charged++
+	}
+
+	return charged, nil
+}
@@ -0,0 +1,85 @@
+package fee
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Date(2026, time.January, 1, 0, 30, 0, 0, time.UTC)
+	month := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	store.EXPECT().
+		ListMaintenanceFeeAccounts(gomock.Any(), gomock.Eq(db.ListMaintenanceFeeAccountsParams{
+			MonthEnd: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Month:    month,
+		})).
+		Times(1).
+		Return([]db.ListMaintenanceFeeAccountsRow{
+			{AccountID: 1, Balance: 500},
+			{AccountID: 2, Balance: -100},
+			{AccountID: 3, Balance: 0},
+		}, nil)
+
+	store.EXPECT().
+		ChargeMaintenanceFeeTx(gomock.Any(), gomock.Eq(db.ChargeMaintenanceFeeTxParams{AccountID: 1, Month: month, Balance: 500})).
+		Times(1).
+		Return(db.ChargeMaintenanceFeeTxResult{}, nil)
+	// jiná replika už poplatek strhla
+	store.EXPECT().
+		ChargeMaintenanceFeeTx(gomock.Any(), gomock.Eq(db.ChargeMaintenanceFeeTxParams{AccountID: 2, Month: month, Balance: -100})).
+		Times(1).
+		Return(db.ChargeMaintenanceFeeTxResult{}, db.ErrFeeCharged)
+	// chyba jednoho účtu nezastaví ostatní
+	store.EXPECT().
+		ChargeMaintenanceFeeTx(gomock.Any(), gomock.Eq(db.ChargeMaintenanceFeeTxParams{AccountID: 3, Month: month, Balance: 0})).
+		Times(1).
+		Return(db.ChargeMaintenanceFeeTxResult{}, sql.ErrConnDone)
+
+	charged, err := NewEngine(store, time.Second).RunDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, charged)
+}
+
+func TestRunDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListMaintenanceFeeAccounts(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().ChargeMaintenanceFeeTx(gomock.Any(), gomock.Any()).Times(0)
+
+	charged, err := NewEngine(store, 0).RunDue(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, charged)
+}
+
+func TestNormalizeRate(t *testing.T) {
+	rate, err := NormalizeRate("0.005")
+	require.NoError(t, err)
+	require.Equal(t, "0.00500000", rate)
+
+	rate, err = NormalizeRate("1")
+	require.NoError(t, err)
+	require.Equal(t, "1.00000000", rate)
+
+	for _, rate := range []string{"", "abc", "-0.01", "1.01", "0.000000001"} {
+		_, err := NormalizeRate(rate)
+		require.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+}
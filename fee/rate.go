@@ -0,0 +1,29 @@
+package fee
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrInvalidRate is returned when the fee rate is not a decimal number between 0 and 1
+// with at most RateScale decimal places
+var ErrInvalidRate = errors.New("invalid fee rate")
+
+// RateScale is the number of decimal places of the fee rates, it matches numeric(18,8) of the rate column
+const RateScale = 8
+
+// NormalizeRate checks the decimal fee rate and returns it formatted with RateScale decimal places
+func NormalizeRate(rate string) (string, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() < 0 || r.Cmp(big.NewRat(1, 1)) > 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRate, rate)
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateScale), nil)))
+	if !scaled.IsInt() {
+		return "", fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, rate, RateScale)
+	}
+
+	return r.FloatString(RateScale), nil
+}
@@ -0,0 +1,10 @@
+package fx
+
+import "context"
+
+// Provider is an interface for getting the current FX rates
+type Provider interface {
+	// Rate returns the current rate for converting the from currency to the to currency
+	// It returns ErrRateNotFound if the currency pair is not known
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
@@ -0,0 +1,79 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// RateScale is the number of decimal places of the rates, it matches numeric(18,8)
+// of the rate columns so the rate stored with the transfer is the same one used for the conversion
+const RateScale = 8
+
+// UnitRate is the rate of the transfers between accounts with the same currency
+const UnitRate = "1.00000000"
+
+var (
+	// ErrRateNotFound is returned by the providers when they don't know the rate of the currency pair
+	ErrRateNotFound = errors.New("fx rate not found")
+	// ErrInvalidRate is returned when the rate is not a positive decimal number with at most RateScale decimal places
+	ErrInvalidRate = errors.New("invalid fx rate")
+	// ErrAmountTooSmall is returned when the converted amount is rounded down to zero
+	ErrAmountTooSmall = errors.New("converted amount is zero")
+)
+
+// Rate says how many units of the To currency are paid for one unit of the From currency
+type Rate struct {
+	From string `json:"from_currency"`
+	To   string `json:"to_currency"`
+	// decimal number with RateScale decimal places, e.g. "0.92000000"
+	Rate string `json:"rate"`
+}
+
+// NormalizeRate checks the decimal rate and returns it formatted with RateScale decimal places
+func NormalizeRate(rate string) (string, error) {
+	r, err := parseRate(rate)
+	if err != nil {
+		return "", err
+	}
+
+	return r.FloatString(RateScale), nil
+}
+
+func parseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, rate)
+	}
+
+	// víc desetinných míst by se v databázi zaokrouhlilo a uložený kurz by neodpovídal převedené částce
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateScale), nil)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, rate, RateScale)
+	}
+
+	return r, nil
+}
+
+// Convert converts the amount in minor units of the source currency to minor units of the target currency.
+// All supported currencies have two decimal places, so the minor units are converted directly. The result
+// is rounded toward zero, so the fraction of the minor unit is never paid out to the client.
+func Convert(amount int64, rate string) (int64, error) {
+	r, err := parseRate(rate)
+	if err != nil {
+		return 0, err
+	}
+
+	converted := new(big.Int).Mul(big.NewInt(amount), r.Num())
+	// Quo rounds toward zero
+	converted.Quo(converted, r.Denom())
+
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount of %d with rate %s overflows", amount, rate)
+	}
+	if converted.Sign() == 0 {
+		return 0, ErrAmountTooSmall
+	}
+
+	return converted.Int64(), nil
+}
@@ -0,0 +1,59 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name     string
+		amount   int64
+		rate     string
+		expected int64
+	}{
+		{name: "UnitRate", amount: 1234, rate: UnitRate, expected: 1234},
+		{name: "Exact", amount: 1000, rate: "0.92", expected: 920},
+		// zlomek centu se vždy zaokrouhlí dolů
+		{name: "RoundDown", amount: 10, rate: "0.92", expected: 9},
+		{name: "RoundDownAlmostOne", amount: 1, rate: "1.99999999", expected: 1},
+		{name: "SmallestRate", amount: 100000000, rate: "0.00000001", expected: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			converted, err := Convert(tc.amount, tc.rate)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	_, err := Convert(1, "0.5")
+	require.ErrorIs(t, err, ErrAmountTooSmall)
+
+	_, err = Convert(9223372036854775807, "2")
+	require.Error(t, err)
+
+	for _, rate := range []string{"", "abc", "0", "-1.5", "0.000000001"} {
+		_, err = Convert(100, rate)
+		require.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+}
+
+func TestNormalizeRate(t *testing.T) {
+	rate, err := NormalizeRate("0.92")
+	require.NoError(t, err)
+	require.Equal(t, "0.92000000", rate)
+
+	rate, err = NormalizeRate("1")
+	require.NoError(t, err)
+	require.Equal(t, UnitRate, rate)
+
+	_, err = NormalizeRate("1.123456789")
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
@@ -0,0 +1,63 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticProvider returns fixed rates, it is used for local development with rates loaded from a file
+type StaticProvider struct {
+	rates map[string]Rate
+}
+
+// NewStaticProvider creates a new StaticProvider with the given rates
+// every direction needs its own rate, the inverse rate is never computed
+func NewStaticProvider(rates []Rate) (*StaticProvider, error) {
+	provider := &StaticProvider{
+		rates: make(map[string]Rate, len(rates)),
+	}
+
+	for _, rate := range rates {
+		normalized, err := NormalizeRate(rate.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("rate %s/%s: %w", rate.From, rate.To, err)
+		}
+		rate.Rate = normalized
+
+		provider.rates[pairKey(rate.From, rate.To)] = rate
+	}
+
+	return provider, nil
+}
+
+// LoadStaticProvider creates a new StaticProvider with the rates from the JSON file
+// the file contains a list of rates: [{"from_currency": "USD", "to_currency": "EUR", "rate": "0.92"}]
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fx rates %s: %w", path, err)
+	}
+
+	var rates []Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("cannot parse fx rates %s: %w", path, err)
+	}
+
+	return NewStaticProvider(rates)
+}
+
+// Rate returns the rate of the currency pair
+func (provider *StaticProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	rate, ok := provider.rates[pairKey(from, to)]
+	if !ok {
+		return Rate{}, ErrRateNotFound
+	}
+
+	return rate, nil
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
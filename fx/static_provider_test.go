@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider([]Rate{
+		{From: "USD", To: "EUR", Rate: "0.92"},
+	})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, Rate{From: "USD", To: "EUR", Rate: "0.92000000"}, rate)
+
+	// opačný směr se nedopočítává
+	_, err = provider.Rate(context.Background(), "EUR", "USD")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestStaticProviderInvalidRate(t *testing.T) {
+	_, err := NewStaticProvider([]Rate{
+		{From: "USD", To: "EUR", Rate: "-1"},
+	})
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestLoadStaticProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx_rates.json")
+	err := os.WriteFile(path, []byte(`[{"from_currency": "EUR", "to_currency": "CAD", "rate": "1.47"}]`), 0600)
+	require.NoError(t, err)
+
+	provider, err := LoadStaticProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "EUR", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, "1.47000000", rate.Rate)
+
+	_, err = LoadStaticProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+// the rates shipped with the repository for local development must be valid
+func TestLoadStaticProviderRepositoryRates(t *testing.T) {
+	_, err := LoadStaticProvider("../fx_rates.json")
+	require.NoError(t, err)
+}
@@ -0,0 +1,46 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// StoreProvider returns the rates saved in the fx_rates table
+type StoreProvider struct {
+	store db.Querier
+}
+
+// NewStoreProvider creates a new StoreProvider
+func NewStoreProvider(store db.Querier) *StoreProvider {
+	return &StoreProvider{
+		store: store,
+	}
+}
+
+// Rate returns the rate of the currency pair from the fx_rates table
+func (provider *StoreProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	fxRate, err := provider.store.GetFxRate(ctx, db.GetFxRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Rate{}, ErrRateNotFound
+		}
+		return Rate{}, err
+	}
+
+	// numeric(18,8) už má správný počet desetinných míst, normalizace jen ověří, že je kurz kladný
+	rate, err := NormalizeRate(fxRate.Rate)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{
+		From: fxRate.FromCurrency,
+		To:   fxRate.ToCurrency,
+		Rate: rate,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.GetFxRateParams{FromCurrency: "USD", ToCurrency: "EUR"}
+
+	store.EXPECT().GetFxRate(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.FxRate{
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		Rate:         "0.92000000",
+	}, nil)
+	store.EXPECT().GetFxRate(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.FxRate{}, sql.ErrNoRows)
+
+	provider := NewStoreProvider(store)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, Rate{From: "USD", To: "EUR", Rate: "0.92000000"}, rate)
+
+	_, err = provider.Rate(context.Background(), "USD", "EUR")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
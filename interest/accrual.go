@@ -0,0 +1,84 @@
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+)
+
+// ErrInvalidRate is returned when the annual rate is not a non-negative decimal number
+// with at most RateScale decimal places
+var ErrInvalidRate = errors.New("invalid interest rate")
+
+// RateScale is the number of decimal places of the annual rates, it matches numeric(18,8) of the rate columns
+const RateScale = 8
+
+// NormalizeRate checks the decimal annual rate and returns it formatted with RateScale decimal places
+func NormalizeRate(rate string) (string, error) {
+	r, err := parseRate(rate)
+	if err != nil {
+		return "", err
+	}
+
+	return r.FloatString(RateScale), nil
+}
+
+func parseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, rate)
+	}
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(RateScale), nil)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, rate, RateScale)
+	}
+
+	return r, nil
+}
+
+// DayFraction returns the part of the year accrued by the date as days/basis. ACT/365 accrues every calendar
+// day as 1/365. 30/360 counts the days from the date to the next one by the 30/360 bond basis, so the 30th
+// of a month with 31 days accrues nothing and the last day of February accrues also the days missing to 30,
+// every month accrues 30/360 in total.
+func DayFraction(dayCount string, date time.Time) (days int64, basis int64, err error) {
+	switch dayCount {
+	case util.DayCountActual365:
+		return 1, 365, nil
+	case util.DayCount30360:
+		next := date.AddDate(0, 0, 1)
+
+		d1, d2 := date.Day(), next.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+
+		days := 360*(next.Year()-date.Year()) + 30*(int(next.Month())-int(date.Month())) + d2 - d1
+		return int64(days), 360, nil
+	}
+
+	return 0, 0, fmt.Errorf("unsupported day count %q", dayCount)
+}
+
+// Accrue returns the interest of the end-of-day balance for the date in minor units. The result is the exact
+// fraction, it is rounded only when the interest of the whole month is posted.
+func Accrue(balance int64, annualRate string, dayCount string, date time.Time) (*big.Rat, error) {
+	rate, err := parseRate(annualRate)
+	if err != nil {
+		return nil, err
+	}
+
+	days, basis, err := DayFraction(dayCount, date)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := new(big.Rat).Mul(new(big.Rat).SetInt64(balance), rate)
+	return amount.Mul(amount, big.NewRat(days, basis)), nil
+}
@@ -0,0 +1,118 @@
+package interest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDayFraction30360(t *testing.T) {
+	testCases := []struct {
+		name string
+		date time.Time
+		days int64
+	}{
+		{name: "OrdinaryDay", date: date(2026, time.March, 10), days: 1},
+		{name: "Thirtieth", date: date(2026, time.January, 30), days: 0},
+		{name: "ThirtyFirst", date: date(2026, time.January, 31), days: 1},
+		{name: "EndOfFebruary", date: date(2026, time.February, 28), days: 3},
+		{name: "EndOfFebruaryLeapYear", date: date(2028, time.February, 29), days: 2},
+		{name: "BeforeEndOfFebruaryLeapYear", date: date(2028, time.February, 28), days: 1},
+		{name: "EndOfYear", date: date(2026, time.December, 31), days: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			days, basis, err := DayFraction(util.DayCount30360, tc.date)
+			require.NoError(t, err)
+			require.Equal(t, tc.days, days)
+			require.Equal(t, int64(360), basis)
+		})
+	}
+}
+
+func TestDayFractionWholeMonths(t *testing.T) {
+	// podle 30/360 má každý měsíc 30 dní, podle ACT/365 tolik, kolik má kalendářních
+	for _, year := range []int{2026, 2028} {
+		for month := time.January; month <= time.December; month++ {
+			var days30360, daysActual int64
+			for d := date(year, month, 1); d.Month() == month; d = d.AddDate(0, 0, 1) {
+				days, _, err := DayFraction(util.DayCount30360, d)
+				require.NoError(t, err)
+				days30360 += days
+
+				days, basis, err := DayFraction(util.DayCountActual365, d)
+				require.NoError(t, err)
+				require.Equal(t, int64(365), basis)
+				daysActual += days
+			}
+
+			require.Equal(t, int64(30), days30360, "%d-%02d", year, month)
+			require.Equal(t, int64(date(year, month+1, 1).Sub(date(year, month, 1))/(24*time.Hour)), daysActual)
+		}
+	}
+}
+
+func TestDayFractionUnsupported(t *testing.T) {
+	_, _, err := DayFraction("act/360", date(2026, time.March, 10))
+	require.Error(t, err)
+}
+
+func TestAccrue(t *testing.T) {
+	// 1000.00 USD při 1.5 % ročně je 1500 centů za rok
+	amount, err := Accrue(100000, "0.01500000", util.DayCountActual365, date(2026, time.March, 10))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(1500, 365), amount)
+
+	amount, err = Accrue(100000, "0.01500000", util.DayCount30360, date(2026, time.February, 28))
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(4500, 360), amount)
+
+	amount, err = Accrue(100000, "0.01500000", util.DayCount30360, date(2026, time.March, 30))
+	require.NoError(t, err)
+	require.Zero(t, amount.Sign())
+}
+
+func TestAccrueWholeYear(t *testing.T) {
+	// součet zlomků za celý rok nesmí ztratit ani zlomek centu
+	sum := new(big.Rat)
+	for d := date(2026, time.January, 1); d.Year() == 2026; d = d.AddDate(0, 0, 1) {
+		amount, err := Accrue(123456, "0.01250000", util.DayCount30360, d)
+		require.NoError(t, err)
+		sum.Add(sum, amount)
+	}
+
+	require.Equal(t, big.NewRat(123456*125, 10000), sum)
+}
+
+func TestAccrueErrors(t *testing.T) {
+	for _, rate := range []string{"", "abc", "-0.01", "0.000000001"} {
+		_, err := Accrue(100, rate, util.DayCountActual365, date(2026, time.March, 10))
+		require.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+
+	_, err := Accrue(100, "0.01", "act/360", date(2026, time.March, 10))
+	require.Error(t, err)
+}
+
+func TestNormalizeRate(t *testing.T) {
+	rate, err := NormalizeRate("0.015")
+	require.NoError(t, err)
+	require.Equal(t, "0.01500000", rate)
+
+	rate, err = NormalizeRate("0")
+	require.NoError(t, err)
+	require.Equal(t, "0.00000000", rate)
+
+	_, err = NormalizeRate("-1")
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
@@ -0,0 +1,165 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// DefaultInterval is used when the configured interval is not positive
+const DefaultInterval = time.Hour
+
+// Engine accrues the interest of the interest bearing accounts for every finished UTC day and posts the interest
+// of every finished month. The accrued dates are saved by AccrueInterestTx, so the engine continues after the last
+// accrued date when it is restarted, it catches up the days when no server was running, and more engines (one in
+// every replica of the server) never accrue the same date or post the same month twice.
+type Engine struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewEngine creates a new engine which looks for the finished days every interval
+func NewEngine(store db.Store, interval time.Duration) *Engine {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Engine{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run accrues and posts the interest every interval until the context is cancelled
+func (engine *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(engine.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := engine.RunDue(ctx, time.Now()); err != nil {
+			log.Println("cannot accrue interest:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue accrues the interest of all finished days before now which are not accrued yet and then posts
+// the interest of the months finished by them. It returns the number of accrued days. Without any accrued
+// day it starts with yesterday, the interest isn't accrued retroactively.
+func (engine *Engine) RunDue(ctx context.Context, now time.Time) (int, error) {
+	today := day(now.UTC())
+
+	date := today.AddDate(0, 0, -1)
+	last, err := engine.store.GetLastInterestAccrualRun(ctx)
+	switch {
+	case err == nil:
+		date = day(last.AccrualDate).AddDate(0, 0, 1)
+	case err != sql.ErrNoRows:
+		return 0, err
+	}
+
+	accrued := 0
+	for ; date.Before(today); date = date.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return accrued, ctx.Err()
+		}
+
+		err := engine.AccrueDate(ctx, date)
+		if err != nil && !errors.Is(err, db.ErrInterestAccrued) {
+			return accrued, fmt.Errorf("cannot accrue interest for %s: %w", date.Format("2006-01-02"), err)
+		}
+		accrued++
+	}
+
+	// date je první den, který ještě není naúročený, všechny měsíce před ním jsou naúročené celé
+	_, err = engine.PostMonths(ctx, time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC))
+	return accrued, err
+}
+
+// AccrueDate accrues the interest of the end-of-day balances of the date for all interest bearing accounts.
+// It returns db.ErrInterestAccrued if the date was already accrued.
+func (engine *Engine) AccrueDate(ctx context.Context, date time.Time) error {
+	balances, err := engine.store.ListInterestBearingBalances(ctx, date.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	accruals := make([]db.CreateInterestAccrualParams, 0, len(balances))
+	for _, balance := range balances {
+		// záporný zůstatek úrok nenese
+		if balance.Balance <= 0 {
+			continue
+		}
+
+		amount, err := Accrue(balance.Balance, balance.AnnualRate, balance.DayCount, date)
+		if err != nil {
+			return fmt.Errorf("cannot accrue the interest of account %d: %w", balance.AccountID, err)
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		accruals = append(accruals, db.CreateInterestAccrualParams{
+			AccountID:   balance.AccountID,
+			AccrualDate: date,
+			Balance:     balance.Balance,
+			AnnualRate:  balance.AnnualRate,
+			DayCount:    balance.DayCount,
+			Amount:      amount.RatString(),
+		})
+	}
+
+	_, err = engine.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{
+		Date:     date,
+		Accruals: accruals,
+	})
+	return err
+}
+
+// PostMonths posts the interest of all accounts for the months before the given first day of a month
+// which are not posted yet and returns the number of postings. The posting which fails is only logged
+// and tried again by the next run, so one account can't stop the interest of the others.
+func (engine *Engine) PostMonths(ctx context.Context, before time.Time) (int, error) {
+	months, err := engine.store.ListUnpostedInterestMonths(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, month := range months {
+		if ctx.Err() != nil {
+			return posted, ctx.Err()
+		}
+
+		_, err := engine.store.PostInterestTx(ctx, db.PostInterestTxParams{
+			AccountID: month.AccountID,
+			Month:     day(month.Month),
+		})
+		if err != nil {
+			if !errors.Is(err, db.ErrInterestPosted) {
+				log.Printf("cannot post the interest of account %d for %s: %v",
+					month.AccountID, month.Month.Format("2006-01"), err)
+			}
+			continue
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+// day returns the start of the day of t in UTC, dates read from the database can have other locations
+func day(t time.Time) time.Time {
+	year, month, d := t.Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
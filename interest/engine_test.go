@@ -0,0 +1,197 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Date(2026, time.February, 2, 10, 0, 0, 0, time.UTC)
+
+	// naposledy se naúročil 30. leden, zbývá 31. leden a 1. únor
+	store.EXPECT().
+		GetLastInterestAccrualRun(gomock.Any()).
+		Times(1).
+		Return(db.InterestAccrualRun{AccrualDate: date(2026, time.January, 30)}, nil)
+
+	balances := []db.ListInterestBearingBalancesRow{
+		{AccountID: 1, AnnualRate: "0.01500000", DayCount: util.DayCountActual365, Balance: 100000},
+		// záporný ani nulový zůstatek se nenaúročí
+		{AccountID: 2, AnnualRate: "0.01500000", DayCount: util.DayCountActual365, Balance: 0},
+		{AccountID: 3, AnnualRate: "0.01000000", DayCount: util.DayCount30360, Balance: 36000},
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			ListInterestBearingBalances(gomock.Any(), gomock.Eq(date(2026, time.February, 1))).
+			Times(1).
+			Return(balances, nil),
+		store.EXPECT().
+			AccrueInterestTx(gomock.Any(), gomock.Eq(db.AccrueInterestTxParams{
+				Date: date(2026, time.January, 31),
+				Accruals: []db.CreateInterestAccrualParams{
+					{
+						AccountID:   1,
+						AccrualDate: date(2026, time.January, 31),
+						Balance:     100000,
+						AnnualRate:  "0.01500000",
+						DayCount:    util.DayCountActual365,
+						Amount:      "300/73",
+					},
+					{
+						AccountID:   3,
+						AccrualDate: date(2026, time.January, 31),
+						Balance:     36000,
+						AnnualRate:  "0.01000000",
+						DayCount:    util.DayCount30360,
+						Amount:      "1",
+					},
+				},
+			})).
+			Times(1).
+			Return(db.InterestAccrualRun{}, nil),
+		store.EXPECT().
+			ListInterestBearingBalances(gomock.Any(), gomock.Eq(date(2026, time.February, 2))).
+			Times(1).
+			Return(nil, nil),
+		// jiná replika už 1. únor naúročila
+		store.EXPECT().
+			AccrueInterestTx(gomock.Any(), gomock.Eq(db.AccrueInterestTxParams{
+				Date:     date(2026, time.February, 1),
+				Accruals: []db.CreateInterestAccrualParams{},
+			})).
+			Times(1).
+			Return(db.InterestAccrualRun{}, db.ErrInterestAccrued),
+		store.EXPECT().
+			ListUnpostedInterestMonths(gomock.Any(), gomock.Eq(date(2026, time.February, 1))).
+			Times(1).
+			Return([]db.ListUnpostedInterestMonthsRow{
+				{AccountID: 1, Month: date(2026, time.January, 1)},
+				{AccountID: 3, Month: date(2026, time.January, 1)},
+			}, nil),
+	)
+
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: 1, Month: date(2026, time.January, 1)})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, nil)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: 3, Month: date(2026, time.January, 1)})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, db.ErrInterestPosted)
+
+	accrued, err := NewEngine(store, time.Second).RunDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, accrued)
+}
+
+func TestRunDueFirstRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Date(2026, time.March, 10, 0, 30, 0, 0, time.UTC)
+
+	// bez předchozího běhu se začne včerejškem
+	store.EXPECT().GetLastInterestAccrualRun(gomock.Any()).Times(1).Return(db.InterestAccrualRun{}, sql.ErrNoRows)
+	store.EXPECT().
+		ListInterestBearingBalances(gomock.Any(), gomock.Eq(date(2026, time.March, 10))).
+		Times(1).
+		Return(nil, nil)
+	store.EXPECT().
+		AccrueInterestTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.InterestAccrualRun{}, nil)
+	store.EXPECT().
+		ListUnpostedInterestMonths(gomock.Any(), gomock.Eq(date(2026, time.March, 1))).
+		Times(1).
+		Return(nil, nil)
+
+	accrued, err := NewEngine(store, 0).RunDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, accrued)
+}
+
+func TestRunDueUpToDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Date(2026, time.March, 10, 23, 0, 0, 0, time.UTC)
+
+	store.EXPECT().
+		GetLastInterestAccrualRun(gomock.Any()).
+		Times(1).
+		Return(db.InterestAccrualRun{AccrualDate: date(2026, time.March, 9)}, nil)
+	store.EXPECT().ListInterestBearingBalances(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().
+		ListUnpostedInterestMonths(gomock.Any(), gomock.Eq(date(2026, time.March, 1))).
+		Times(1).
+		Return(nil, nil)
+
+	accrued, err := NewEngine(store, 0).RunDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, accrued)
+}
+
+func TestRunDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	store.EXPECT().
+		GetLastInterestAccrualRun(gomock.Any()).
+		Times(1).
+		Return(db.InterestAccrualRun{AccrualDate: date(2026, time.March, 7)}, nil)
+	store.EXPECT().
+		ListInterestBearingBalances(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	// nepovedený den se musí zopakovat dřív, než se naúročí další
+	store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().ListUnpostedInterestMonths(gomock.Any(), gomock.Any()).Times(0)
+
+	accrued, err := NewEngine(store, 0).RunDue(context.Background(), now)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, accrued)
+}
+
+func TestPostMonthsContinuesAfterError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	month := date(2026, time.January, 1)
+
+	store.EXPECT().
+		ListUnpostedInterestMonths(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListUnpostedInterestMonthsRow{{AccountID: 1, Month: month}, {AccountID: 2, Month: month}}, nil)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: 1, Month: month})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, sql.ErrConnDone)
+	store.EXPECT().
+		PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: 2, Month: month})).
+		Times(1).
+		Return(db.PostInterestTxResult{}, nil)
+
+	posted, err := NewEngine(store, 0).PostMonths(context.Background(), date(2026, time.February, 1))
+	require.NoError(t, err)
+	require.Equal(t, 1, posted)
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/karlib/simple_bank/api"
 	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/fee"
+	"github.com/karlib/simple_bank/interest"
+	"github.com/karlib/simple_bank/reconcile"
+	"github.com/karlib/simple_bank/scheduler"
 	"github.com/karlib/simple_bank/util"
 	_ "github.com/lib/pq"
 )
@@ -23,14 +31,71 @@ func main() {
 
 	store := db.NewStore(conn)
 
+	// "simple_bank reconcile" jen zkontroluje účetní knihu a skončí, server se nespouští
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconciliation(config, store)
+		return
+	}
+
 	server, err := api.NewServer(config, store)
 	if err !=nil {
 		log.Fatal("cannot create server:", err)
 	}
 
+	// SIGHUP znovu načte config a vymění klíče pro tokeny bez restartu serveru
+	go reloadTokenKeysOnSignal(server)
+
+	// každá replika serveru má vlastní scheduler, naplánované převody si zamykají v databázi
+	transferScheduler := scheduler.NewScheduler(store, config.SchedulerInterval,
+		config.ScheduledTransferMaxAttempts, config.ScheduledTransferRetryInterval, db.NewDefaultTransferLimits(config), config.TransferApprovalThreshold)
+	go transferScheduler.Run(context.Background())
+
+	// úroky se počítají za každý uzavřený den jen jednou, i když běží engine v každé replice
+	go interest.NewEngine(store, config.InterestInterval).Run(context.Background())
+
+	// poplatek za vedení účtu se strhne za každý měsíc jen jednou, i když běží engine v každé replice
+	go fee.NewEngine(store, config.FeeInterval).Run(context.Background())
+
 	err = server.Start(config.ServerAddress)
 	if err != nil {
 		log.Fatal("cannot start")
 	}
 
 }
+
+// reloadTokenKeysOnSignal reloads the config and rotates the token keys every time the process receives SIGHUP
+func reloadTokenKeysOnSignal(server *api.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		config, err := util.LoadConfig(".")
+		if err != nil {
+			log.Println("cannot reload config:", err)
+			continue
+		}
+
+		if err := server.ReloadTokenKeys(config); err != nil {
+			log.Println("cannot reload token keys:", err)
+			continue
+		}
+		log.Println("token keys reloaded")
+	}
+}
+
+// runReconciliation reconciles the ledger once and exits with code 1 if any issue was found
+func runReconciliation(config util.Config, store db.Store) {
+	reconciler := reconcile.NewReconciler(store, config.ReconciliationBatchSize)
+
+	run, err := reconciler.Run(context.Background())
+	if err != nil {
+		log.Fatal("cannot reconcile the ledger:", err)
+	}
+
+	log.Printf("reconciliation run %d finished with status %s: %d accounts and %d transfers checked, %d issues found",
+		run.ID, run.Status, run.AccountsChecked, run.TransfersChecked, run.IssuesFound)
+
+	if run.Status != reconcile.StatusOK {
+		os.Exit(1)
+	}
+}
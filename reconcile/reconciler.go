@@ -0,0 +1,222 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+)
+
+// statuses of the reconciliation runs
+const (
+	StatusRunning = "running"
+	StatusOK      = "ok"
+	StatusDrift   = "drift"
+	StatusFailed  = "failed"
+)
+
+// kinds of the reported issues
+const (
+	// the balance of the account differs from the sum of its entries
+	KindBalanceDrift = "balance_drift"
+	// the account has entries which don't belong to any transfer
+	KindOrphanEntries = "orphan_entries"
+	// the entries of the transfer don't match its amounts
+	KindUnbalancedTransfer = "unbalanced_transfer"
+)
+
+// DefaultBatchSize is used when the configured batch size is not positive
+const DefaultBatchSize = 1000
+
+// Reconciler checks that the balances of the accounts match their entries and that every transfer
+// has its two entries. Accounts and transfers are read in batches by plain SELECTs without row locks,
+// so the reconciliation can run against the live database while TransferTx is running. The balance
+// and the entries of an account are read by one statement, so they always come from the same snapshot.
+type Reconciler struct {
+	store     db.Querier
+	batchSize int32
+}
+
+// NewReconciler creates a new reconciler which reads batchSize accounts or transfers per query
+func NewReconciler(store db.Querier, batchSize int32) *Reconciler {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Reconciler{
+		store:     store,
+		batchSize: batchSize,
+	}
+}
+
+// Run reconciles all accounts and transfers and saves the found issues under a new reconciliation run.
+// The returned run is finished with StatusOK, StatusDrift or StatusFailed together with the error.
+func (reconciler *Reconciler) Run(ctx context.Context) (db.ReconciliationRun, error) {
+	run, err := reconciler.store.CreateReconciliationRun(ctx)
+	if err != nil {
+		return run, err
+	}
+
+	finish := db.FinishReconciliationRunParams{
+		ID:     run.ID,
+		Status: StatusOK,
+	}
+
+	err = reconciler.reconcileAccounts(ctx, &finish)
+	if err == nil {
+		err = reconciler.reconcileTransfers(ctx, &finish)
+	}
+
+	if err != nil {
+		finish.Status = StatusFailed
+		finish.Error = err.Error()
+		// kontext už může být zrušený, výsledek běhu se ale uložit musí
+		ctx = context.Background()
+	} else if finish.IssuesFound > 0 {
+		finish.Status = StatusDrift
+	}
+
+	run, finishErr := reconciler.store.FinishReconciliationRun(ctx, finish)
+	if err != nil {
+		return run, err
+	}
+	return run, finishErr
+}
+
+func (reconciler *Reconciler) reconcileAccounts(ctx context.Context, finish *db.FinishReconciliationRunParams) error {
+	var afterID int64
+	for {
+		accounts, err := reconciler.store.ReconcileAccounts(ctx, db.ReconcileAccountsParams{
+			AfterID: afterID,
+			Limit:   reconciler.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			if account.Balance != account.EntriesSum {
+				details := fmt.Sprintf("balance of account %d is %d, its entries sum to %d", account.ID, account.Balance, account.EntriesSum)
+				if err := reconciler.report(ctx, finish, db.CreateReconciliationIssueParams{
+					Kind:      KindBalanceDrift,
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					Expected:  account.EntriesSum,
+					Actual:    account.Balance,
+					Details:   details,
+				}); err != nil {
+					return err
+				}
+			}
+
+			if account.OrphanEntries > 0 {
+				details := fmt.Sprintf("account %d has %d entries without a transfer", account.ID, account.OrphanEntries)
+				if err := reconciler.report(ctx, finish, db.CreateReconciliationIssueParams{
+					Kind:      KindOrphanEntries,
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					Expected:  0,
+					Actual:    account.OrphanEntries,
+					Details:   details,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		finish.AccountsChecked += int64(len(accounts))
+		if len(accounts) < int(reconciler.batchSize) {
+			return nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (reconciler *Reconciler) reconcileTransfers(ctx context.Context, finish *db.FinishReconciliationRunParams) error {
+	var afterID int64
+	for {
+		transfers, err := reconciler.store.ReconcileTransfers(ctx, db.ReconcileTransfersParams{
+			AfterID: afterID,
+			Limit:   reconciler.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, transfer := range transfers {
+			issue, unbalanced := unbalancedTransfer(transfer)
+			if !unbalanced {
+				continue
+			}
+			if err := reconciler.report(ctx, finish, issue); err != nil {
+				return err
+			}
+		}
+
+		finish.TransfersChecked += int64(len(transfers))
+		if len(transfers) < int(reconciler.batchSize) {
+			return nil
+		}
+		afterID = transfers[len(transfers)-1].ID
+	}
+}
+
+// unbalancedTransfer returns the issue of the transfer if its entries don't match it, the posted transfer must have
+// exactly one entry of -amount on the from account and one entry of to_amount on the to account, the transfers
+// in other statuses must have no entries
+func unbalancedTransfer(transfer db.ReconcileTransfersRow) (db.CreateReconciliationIssueParams, bool) {
+	issue := db.CreateReconciliationIssueParams{
+		Kind:       KindUnbalancedTransfer,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+	}
+
+	if transfer.Status != util.TransferPosted {
+		if transfer.EntriesCount == 0 {
+			return issue, false
+		}
+		issue.Expected = 0
+		issue.Actual = transfer.EntriesCount
+		issue.Details = fmt.Sprintf("%s transfer %d has %d entries instead of 0", transfer.Status, transfer.ID, transfer.EntriesCount)
+		return issue, true
+	}
+
+	fromExpected, toExpected := -transfer.Amount, transfer.ToAmount
+	// převod na stejný účet má oba záznamy na jednom účtu, takže se sečtou do obou součtů
+	if transfer.FromAccountID == transfer.ToAccountID {
+		fromExpected = transfer.ToAmount - transfer.Amount
+		toExpected = fromExpected
+	}
+
+	switch {
+	case transfer.FromEntriesSum != fromExpected:
+		issue.AccountID = sql.NullInt64{Int64: transfer.FromAccountID, Valid: true}
+		issue.Expected = fromExpected
+		issue.Actual = transfer.FromEntriesSum
+		issue.Details = fmt.Sprintf("entries of transfer %d on from account %d sum to %d instead of %d",
+			transfer.ID, transfer.FromAccountID, transfer.FromEntriesSum, fromExpected)
+	case transfer.ToEntriesSum != toExpected:
+		issue.AccountID = sql.NullInt64{Int64: transfer.ToAccountID, Valid: true}
+		issue.Expected = toExpected
+		issue.Actual = transfer.ToEntriesSum
+		issue.Details = fmt.Sprintf("entries of transfer %d on to account %d sum to %d instead of %d",
+			transfer.ID, transfer.ToAccountID, transfer.ToEntriesSum, toExpected)
+	case transfer.EntriesCount != 2:
+		issue.Expected = 2
+		issue.Actual = transfer.EntriesCount
+		issue.Details = fmt.Sprintf("transfer %d has %d entries instead of 2", transfer.ID, transfer.EntriesCount)
+	default:
+		return issue, false
+	}
+
+	return issue, true
+}
+
+func (reconciler *Reconciler) report(ctx context.Context, finish *db.FinishReconciliationRunParams, issue db.CreateReconciliationIssueParams) error {
+	issue.RunID = finish.ID
+	if _, err := reconciler.store.CreateReconciliationIssue(ctx, issue); err != nil {
+		return err
+	}
+
+	finish.IssuesFound++
+	return nil
+}
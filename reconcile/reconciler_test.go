@@ -0,0 +1,215 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	run := db.ReconciliationRun{ID: 1, Status: StatusRunning}
+
+	// dávka po dvou, třetí účet je v druhé dávce, která je zároveň poslední
+	gomock.InOrder(
+		store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil),
+		store.EXPECT().
+			ReconcileAccounts(gomock.Any(), gomock.Eq(db.ReconcileAccountsParams{AfterID: 0, Limit: 2})).
+			Times(1).
+			Return([]db.ReconcileAccountsRow{
+				{ID: 1, Balance: 100, EntriesSum: 100},
+				{ID: 3, Balance: 0, EntriesSum: 0},
+			}, nil),
+		store.EXPECT().
+			ReconcileAccounts(gomock.Any(), gomock.Eq(db.ReconcileAccountsParams{AfterID: 3, Limit: 2})).
+			Times(1).
+			Return([]db.ReconcileAccountsRow{{ID: 4, Balance: -100, EntriesSum: -100}}, nil),
+		store.EXPECT().
+			ReconcileTransfers(gomock.Any(), gomock.Eq(db.ReconcileTransfersParams{AfterID: 0, Limit: 2})).
+			Times(1).
+			Return([]db.ReconcileTransfersRow{
+				{ID: 1, Status: util.TransferPosted, FromAccountID: 4, ToAccountID: 1, Amount: 100, ToAmount: 100, EntriesCount: 2, FromEntriesSum: -100, ToEntriesSum: 100},
+			}, nil),
+		store.EXPECT().
+			FinishReconciliationRun(gomock.Any(), gomock.Eq(db.FinishReconciliationRunParams{
+				ID:               run.ID,
+				Status:           StatusOK,
+				AccountsChecked:  3,
+				TransfersChecked: 1,
+			})).
+			Times(1).
+			Return(db.ReconciliationRun{ID: run.ID, Status: StatusOK}, nil),
+	)
+	store.EXPECT().CreateReconciliationIssue(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := NewReconciler(store, 2).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, StatusOK, result.Status)
+}
+
+func TestRunDrift(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	run := db.ReconciliationRun{ID: 2, Status: StatusRunning}
+
+	store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+	store.EXPECT().
+		ReconcileAccounts(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ReconcileAccountsRow{{ID: 1, Balance: 150, EntriesSum: 100, OrphanEntries: 1}}, nil)
+	store.EXPECT().
+		ReconcileTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ReconcileTransfersRow{
+			// chybí záznam na cílovém účtu
+			{ID: 7, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, EntriesCount: 1, FromEntriesSum: -10},
+		}, nil)
+
+	var issues []db.CreateReconciliationIssueParams
+	store.EXPECT().
+		CreateReconciliationIssue(gomock.Any(), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(ctx context.Context, arg db.CreateReconciliationIssueParams) (db.ReconciliationIssue, error) {
+			issues = append(issues, arg)
+			return db.ReconciliationIssue{}, nil
+		})
+	store.EXPECT().
+		FinishReconciliationRun(gomock.Any(), gomock.Eq(db.FinishReconciliationRunParams{
+			ID:               run.ID,
+			Status:           StatusDrift,
+			AccountsChecked:  1,
+			TransfersChecked: 1,
+			IssuesFound:      3,
+		})).
+		Times(1).
+		Return(db.ReconciliationRun{ID: run.ID, Status: StatusDrift}, nil)
+
+	result, err := NewReconciler(store, 10).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, StatusDrift, result.Status)
+
+	require.Len(t, issues, 3)
+	for _, issue := range issues {
+		require.Equal(t, run.ID, issue.RunID)
+	}
+
+	require.Equal(t, KindBalanceDrift, issues[0].Kind)
+	require.Equal(t, int64(100), issues[0].Expected)
+	require.Equal(t, int64(150), issues[0].Actual)
+
+	require.Equal(t, KindOrphanEntries, issues[1].Kind)
+	require.Equal(t, int64(1), issues[1].Actual)
+
+	require.Equal(t, KindUnbalancedTransfer, issues[2].Kind)
+	require.Equal(t, sql.NullInt64{Int64: 7, Valid: true}, issues[2].TransferID)
+	require.Equal(t, sql.NullInt64{Int64: 2, Valid: true}, issues[2].AccountID)
+	require.Equal(t, int64(10), issues[2].Expected)
+	require.Equal(t, int64(0), issues[2].Actual)
+}
+
+func TestRunFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	run := db.ReconciliationRun{ID: 3, Status: StatusRunning}
+
+	store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+	store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().ReconcileTransfers(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().
+		FinishReconciliationRun(gomock.Any(), gomock.Eq(db.FinishReconciliationRunParams{
+			ID:     run.ID,
+			Status: StatusFailed,
+			Error:  sql.ErrConnDone.Error(),
+		})).
+		Times(1).
+		Return(db.ReconciliationRun{ID: run.ID, Status: StatusFailed}, nil)
+
+	result, err := NewReconciler(store, 10).Run(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, StatusFailed, result.Status)
+}
+
+func TestUnbalancedTransfer(t *testing.T) {
+	testCases := []struct {
+		name       string
+		transfer   db.ReconcileTransfersRow
+		unbalanced bool
+		expected   int64
+		actual     int64
+	}{
+		{
+			name:     "Balanced",
+			transfer: db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, EntriesCount: 2, FromEntriesSum: -10, ToEntriesSum: 10},
+		},
+		{
+			name:     "CrossCurrency",
+			transfer: db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 9, EntriesCount: 2, FromEntriesSum: -10, ToEntriesSum: 9},
+		},
+		{
+			name:     "SameAccount",
+			transfer: db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 1, Amount: 10, ToAmount: 10, EntriesCount: 2},
+		},
+		{
+			name:       "WrongFromAmount",
+			transfer:   db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, EntriesCount: 2, FromEntriesSum: -20, ToEntriesSum: 10},
+			unbalanced: true,
+			expected:   -10,
+			actual:     -20,
+		},
+		{
+			name:       "MissingEntries",
+			transfer:   db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10},
+			unbalanced: true,
+			expected:   -10,
+			actual:     0,
+		},
+		{
+			name:       "ExtraEntries",
+			transfer:   db.ReconcileTransfersRow{ID: 1, Status: util.TransferPosted, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, EntriesCount: 4, FromEntriesSum: -10, ToEntriesSum: 10},
+			unbalanced: true,
+			expected:   2,
+			actual:     4,
+		},
+		{
+			name:     "Pending",
+			transfer: db.ReconcileTransfersRow{ID: 1, Status: util.TransferPending, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10},
+		},
+		{
+			name:       "RejectedWithEntries",
+			transfer:   db.ReconcileTransfersRow{ID: 1, Status: util.TransferRejected, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, EntriesCount: 2, FromEntriesSum: -10, ToEntriesSum: 10},
+			unbalanced: true,
+			expected:   0,
+			actual:     2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			issue, unbalanced := unbalancedTransfer(tc.transfer)
+			require.Equal(t, tc.unbalanced, unbalanced)
+			if !tc.unbalanced {
+				return
+			}
+
+			require.Equal(t, KindUnbalancedTransfer, issue.Kind)
+			require.Equal(t, tc.expected, issue.Expected)
+			require.Equal(t, tc.actual, issue.Actual)
+			require.NotEmpty(t, issue.Details)
+		})
+	}
+}
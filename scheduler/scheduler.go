@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// defaults used when the configured values are not positive
+const (
+	DefaultInterval      = time.Minute
+	DefaultMaxAttempts   = 3
+	DefaultRetryInterval = time.Hour
+)
+
+// Scheduler executes the due scheduled transfers periodically. Every scheduled transfer is executed
+// by its own ExecuteScheduledTransferTx, which claims it with FOR UPDATE SKIP LOCKED, so more schedulers
+// (one in every replica of the server) can run against the same database without executing
+// the same occurrence twice.
+type Scheduler struct {
+	store         db.Store
+	interval      time.Duration
+	maxAttempts   int32
+	retryInterval time.Duration
+	limits        db.DefaultTransferLimits
+	// executions above it create pending transfers, zero posts all of them
+	approvalThreshold int64
+}
+
+// NewScheduler creates a new scheduler which looks for the due scheduled transfers every interval.
+// The occurrence which fails because of insufficient funds or the transfer limits is retried after retryInterval
+// and skipped after maxAttempts failed attempts. Limits are the default transfer limits of the users and transfers
+// above approvalThreshold wait for the approval of a banker like the transfers created through the API.
+func NewScheduler(store db.Store, interval time.Duration, maxAttempts int32, retryInterval time.Duration,
+	limits db.DefaultTransferLimits, approvalThreshold int64) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if retryInterval <= 0 {
+		retryInterval = DefaultRetryInterval
+	}
+
+	return &Scheduler{
+		store:             store,
+		interval:          interval,
+		maxAttempts:       maxAttempts,
+		retryInterval:     retryInterval,
+		limits:            limits,
+		approvalThreshold: approvalThreshold,
+	}
+}
+
+// Run executes the due scheduled transfers every interval until the context is cancelled
+func (scheduler *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := scheduler.ExecuteDue(ctx, time.Now()); err != nil {
+			log.Println("cannot execute scheduled transfers:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExecuteDue executes all scheduled transfers due at now and returns the number of executed ones.
+// Transfers which missed more occurrences, e.g. when no server was running, execute all of them.
+// A scheduled transfer failing with an unexpected error is recorded as a failed attempt and the remaining
+// due transfers are still executed, only an error of the database itself stops the run.
+func (scheduler *Scheduler) ExecuteDue(ctx context.Context, now time.Time) (int, error) {
+	executed := 0
+
+	for ctx.Err() == nil {
+		result, err := scheduler.store.ExecuteScheduledTransferTx(ctx, db.ExecuteScheduledTransferTxParams{
+			Now:               now,
+			MaxAttempts:       scheduler.maxAttempts,
+			RetryInterval:     scheduler.retryInterval,
+			Limits:            scheduler.limits,
+			ApprovalThreshold: scheduler.approvalThreshold,
+			ChargeFee:         true,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return executed, nil
+			}
+
+			var scheduledErr *db.ScheduledTransferError
+			if !errors.As(err, &scheduledErr) {
+				return executed, err
+			}
+
+			// rozbitý převod se zaznamená zvlášť a posune, jinak by ho claim vybral znovu a zablokoval ostatní
+			log.Println("cannot execute scheduled transfer:", err)
+			result, err = scheduler.store.FailScheduledTransferTx(ctx, db.FailScheduledTransferTxParams{
+				ScheduledTransfer: scheduledErr.ScheduledTransfer,
+				Error:             scheduledErr.Err.Error(),
+				Now:               now,
+				MaxAttempts:       scheduler.maxAttempts,
+				RetryInterval:     scheduler.retryInterval,
+			})
+			if err != nil {
+				return executed, err
+			}
+		}
+
+		executed++
+		if result.Execution.Error != "" {
+			log.Printf("scheduled transfer %d failed with status %s: %s",
+				result.ScheduledTransfer.ID, result.Execution.Status, result.Execution.Error)
+		}
+	}
+
+	return executed, ctx.Err()
+}
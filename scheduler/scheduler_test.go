@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/karlib/simple_bank/db/mock"
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Now()
+	limits := db.DefaultTransferLimits{util.USD: {PerTransaction: 1000, Daily: 5000, Monthly: 20000}}
+	arg := db.ExecuteScheduledTransferTxParams{
+		Now:               now,
+		MaxAttempts:       2,
+		RetryInterval:     time.Minute,
+		Limits:            limits,
+		ApprovalThreshold: 10000,
+		ChargeFee:         true,
+	}
+
+	// dva splatné převody, jeden bez peněz, pak už žádný
+	gomock.InOrder(
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{
+				Execution: db.ScheduledTransferExecution{Status: util.ExecutionSucceeded},
+			}, nil),
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{
+				Execution: db.ScheduledTransferExecution{Status: util.ExecutionRetrying, Error: db.ErrInsufficientFunds.Error()},
+			}, nil),
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows),
+	)
+
+	executed, err := NewScheduler(store, time.Second, 2, time.Minute, limits, 10000).ExecuteDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, executed)
+}
+
+func TestExecuteDueError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrConnDone)
+
+	executed, err := NewScheduler(store, 0, 0, 0, nil, 0).ExecuteDue(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, executed)
+}
+
+func TestExecuteDueBrokenTransfer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	now := time.Now()
+	broken := db.ScheduledTransfer{ID: 1, Runs: 2, NextRunAt: now.Add(-time.Hour)}
+
+	// rozbitý převod se zaznamená jako neúspěšný pokus a další splatné převody se provedou
+	gomock.InOrder(
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{}, &db.ScheduledTransferError{ScheduledTransfer: broken, Err: sql.ErrTxDone}),
+		store.EXPECT().
+			FailScheduledTransferTx(gomock.Any(), gomock.Eq(db.FailScheduledTransferTxParams{
+				ScheduledTransfer: broken,
+				Error:             sql.ErrTxDone.Error(),
+				Now:               now,
+				MaxAttempts:       3,
+				RetryInterval:     time.Minute,
+			})).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{
+				Execution: db.ScheduledTransferExecution{Status: util.ExecutionRetrying, Error: sql.ErrTxDone.Error()},
+			}, nil),
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{
+				Execution: db.ScheduledTransferExecution{Status: util.ExecutionSucceeded},
+			}, nil),
+		store.EXPECT().
+			ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows),
+	)
+
+	executed, err := NewScheduler(store, 0, 3, time.Minute, nil, 0).ExecuteDue(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, executed)
+}
+
+func TestExecuteDueFailureNotRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExecuteScheduledTransferTxResult{}, &db.ScheduledTransferError{Err: sql.ErrTxDone})
+	// bez záznamu by se stejný převod vybral znovu, takže se běh zastaví
+	store.EXPECT().
+		FailScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrConnDone)
+
+	executed, err := NewScheduler(store, 0, 0, 0, nil, 0).ExecuteDue(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, executed)
+}
+
+func TestExecuteDueCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	executed, err := NewScheduler(store, 0, 0, 0, nil, 0).ExecuteDue(ctx, time.Now())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, executed)
+}
+
+func TestNewSchedulerDefaults(t *testing.T) {
+	scheduler := NewScheduler(nil, 0, 0, 0, nil, 0)
+	require.Equal(t, DefaultInterval, scheduler.interval)
+	require.Equal(t, int32(DefaultMaxAttempts), scheduler.maxAttempts)
+	require.Equal(t, DefaultRetryInterval, scheduler.retryInterval)
+}
@@ -0,0 +1,158 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053Writer writes the statement as the ISO 20022 BankToCustomerStatement (camt.053.001.02),
+// both balances are written before the entries as the schema requires
+type camt053Writer struct {
+	x    *xmlWriter
+	info Info
+}
+
+func newCamt053Writer(w io.Writer, info Info) Writer {
+	return &camt053Writer{
+		x:    newXMLWriter(w),
+		info: info,
+	}
+}
+
+func (writer *camt053Writer) WriteBalances(opening int64, closing int64) error {
+	info := writer.info
+	id := fmt.Sprintf("STMT-%d-%s", info.Account.ID, info.CreatedAt.UTC().Format("20060102150405"))
+
+	x := writer.x
+	x.prolog(`<?xml version="1.0" encoding="UTF-8"?>`)
+	x.start("Document", attr("xmlns", camt053Namespace))
+	x.start("BkToCstmrStmt")
+
+	x.start("GrpHdr")
+	x.element("MsgId", id)
+	x.element("CreDtTm", camt053Time(info.CreatedAt))
+	x.end()
+
+	x.start("Stmt")
+	x.element("Id", id)
+	x.element("CreDtTm", camt053Time(info.CreatedAt))
+	x.start("FrToDt")
+	x.element("FrDtTm", camt053Time(info.From))
+	x.element("ToDtTm", camt053Time(info.To))
+	x.end()
+
+	x.start("Acct")
+	writer.accountID(info.Account.ID)
+	x.element("Ccy", info.Account.Currency)
+	x.start("Ownr")
+	x.element("Nm", info.Account.Owner)
+	x.end()
+	x.end()
+
+	// OPBD = opening booked, CLBD = closing booked
+	writer.balance("OPBD", opening, info.From)
+	writer.balance("CLBD", closing, info.To)
+
+	return x.err
+}
+
+func (writer *camt053Writer) WriteLine(line db.StatementLine) error {
+	ref := strconv.FormatInt(line.TransferID, 10)
+	// ICDT = issued credit transfer, RCDT = received credit transfer, BOOK = transfer within the bank
+	family, counterparty := "RCDT", "DbtrAcct"
+	if line.Amount < 0 {
+		family, counterparty = "ICDT", "CdtrAcct"
+	}
+
+	x := writer.x
+	x.start("Ntry")
+	x.element("NtryRef", ref)
+	writer.amount(line.Amount)
+	x.element("Sts", "BOOK")
+	x.start("BookgDt")
+	x.element("DtTm", camt053Time(line.CreatedAt))
+	x.end()
+	x.start("ValDt")
+	x.element("DtTm", camt053Time(line.CreatedAt))
+	x.end()
+	x.element("AcctSvcrRef", ref)
+	x.start("BkTxCd")
+	x.start("Domn")
+	x.element("Cd", "PMNT")
+	x.start("Fmly")
+	x.element("Cd", family)
+	x.element("SubFmlyCd", "BOOK")
+	x.end()
+	x.end()
+	x.end()
+
+	x.start("NtryDtls")
+	x.start("TxDtls")
+	x.start("Refs")
+	x.element("AcctSvcrRef", ref)
+	x.end()
+	x.start("RltdPties")
+	x.start(counterparty)
+	writer.accountID(line.CounterpartyAccountID)
+	x.end()
+	x.end()
+	x.element("AddtlTxInf", description(line))
+	x.end()
+	x.end()
+	x.end()
+
+	return x.err
+}
+
+func (writer *camt053Writer) Close() error {
+	writer.x.end() // Stmt
+	writer.x.end() // BkToCstmrStmt
+	writer.x.end() // Document
+
+	return writer.x.flush()
+}
+
+func (writer *camt053Writer) accountID(id int64) {
+	writer.x.start("Id")
+	writer.x.start("Othr")
+	writer.x.element("Id", strconv.FormatInt(id, 10))
+	writer.x.end()
+	writer.x.end()
+}
+
+// amount writes the absolute amount with the credit/debit indicator, camt.053 has no negative amounts
+func (writer *camt053Writer) amount(amount int64) {
+	indicator := "CRDT"
+	if amount < 0 {
+		indicator = "DBIT"
+		amount = -amount
+	}
+
+	writer.x.element("Amt", formatAmount(amount), attr("Ccy", writer.info.Account.Currency))
+	writer.x.element("CdtDbtInd", indicator)
+}
+
+func (writer *camt053Writer) balance(code string, balance int64, date time.Time) {
+	x := writer.x
+	x.start("Bal")
+	x.start("Tp")
+	x.start("CdOrPrtry")
+	x.element("Cd", code)
+	x.end()
+	x.end()
+	writer.amount(balance)
+	x.start("Dt")
+	x.element("DtTm", camt053Time(date))
+	x.end()
+	x.end()
+}
+
+func camt053Time(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
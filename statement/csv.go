@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+var csvHeader = []string{
+	"date", "type", "transfer_id", "counterparty_account_id", "description",
+	"amount", "currency", "exchange_rate", "balance",
+}
+
+// csvWriter writes the statement as CSV, the opening and the closing balance
+// are the first and the last row after the header
+type csvWriter struct {
+	w       *csv.Writer
+	info    Info
+	closing int64
+}
+
+func newCSVWriter(w io.Writer, info Info) Writer {
+	return &csvWriter{
+		w:    csv.NewWriter(w),
+		info: info,
+	}
+}
+
+func (writer *csvWriter) WriteBalances(opening int64, closing int64) error {
+	writer.closing = closing
+
+	if err := writer.w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	return writer.writeBalance(writer.info.From, "opening_balance", opening)
+}
+
+func (writer *csvWriter) WriteLine(line db.StatementLine) error {
+	return writer.w.Write([]string{
+		line.CreatedAt.UTC().Format(time.RFC3339),
+		"transfer",
+		strconv.FormatInt(line.TransferID, 10),
+		strconv.FormatInt(line.CounterpartyAccountID, 10),
+		description(line),
+		formatAmount(line.Amount),
+		writer.info.Account.Currency,
+		line.ExchangeRate,
+		formatAmount(line.Balance),
+	})
+}
+
+func (writer *csvWriter) Close() error {
+	if err := writer.writeBalance(writer.info.To, "closing_balance", writer.closing); err != nil {
+		return err
+	}
+
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+func (writer *csvWriter) writeBalance(date time.Time, balanceType string, balance int64) error {
+	return writer.w.Write([]string{
+		date.UTC().Format(time.RFC3339),
+		balanceType,
+		"", "", "", "",
+		writer.info.Account.Currency,
+		"",
+		formatAmount(balance),
+	})
+}
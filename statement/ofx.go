@@ -0,0 +1,122 @@
+package statement
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// BANKID je v OFX routing number banky, simple bank žádné nemá
+const ofxBankID = "SIMPLEBNK"
+
+// ofxTime formats the time as the OFX datetime YYYYMMDDHHMMSS.XXX[gmt offset:tz name]
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxWriter writes the statement as the OFX 2.2 bank statement response,
+// OFX has no opening balance element so it is written to BALLIST
+type ofxWriter struct {
+	x       *xmlWriter
+	info    Info
+	opening int64
+	closing int64
+}
+
+func newOFXWriter(w io.Writer, info Info) Writer {
+	return &ofxWriter{
+		x:    newXMLWriter(w),
+		info: info,
+	}
+}
+
+func (writer *ofxWriter) WriteBalances(opening int64, closing int64) error {
+	writer.opening = opening
+	writer.closing = closing
+
+	x := writer.x
+	x.prolog(
+		`<?xml version="1.0" encoding="UTF-8" standalone="no"?>`,
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`,
+	)
+	x.start("OFX")
+
+	x.start("SIGNONMSGSRSV1")
+	x.start("SONRS")
+	writer.status()
+	x.element("DTSERVER", ofxTime(writer.info.CreatedAt))
+	x.element("LANGUAGE", "ENG")
+	x.end()
+	x.end()
+
+	x.start("BANKMSGSRSV1")
+	x.start("STMTTRNRS")
+	x.element("TRNUID", "0")
+	writer.status()
+	x.start("STMTRS")
+	x.element("CURDEF", writer.info.Account.Currency)
+	x.start("BANKACCTFROM")
+	x.element("BANKID", ofxBankID)
+	x.element("ACCTID", strconv.FormatInt(writer.info.Account.ID, 10))
+	x.element("ACCTTYPE", "CHECKING")
+	x.end()
+	x.start("BANKTRANLIST")
+	x.element("DTSTART", ofxTime(writer.info.From))
+	x.element("DTEND", ofxTime(writer.info.To))
+
+	return x.err
+}
+
+func (writer *ofxWriter) WriteLine(line db.StatementLine) error {
+	trnType := "CREDIT"
+	if line.Amount < 0 {
+		trnType = "DEBIT"
+	}
+
+	x := writer.x
+	x.start("STMTTRN")
+	x.element("TRNTYPE", trnType)
+	x.element("DTPOSTED", ofxTime(line.CreatedAt))
+	x.element("TRNAMT", formatAmount(line.Amount))
+	x.element("FITID", strconv.FormatInt(line.TransferID, 10))
+	x.element("NAME", description(line))
+	x.end()
+
+	return x.err
+}
+
+func (writer *ofxWriter) Close() error {
+	x := writer.x
+	x.end() // BANKTRANLIST
+
+	x.start("LEDGERBAL")
+	x.element("BALAMT", formatAmount(writer.closing))
+	x.element("DTASOF", ofxTime(writer.info.To))
+	x.end()
+
+	x.start("BALLIST")
+	x.start("BAL")
+	x.element("NAME", "Opening balance")
+	x.element("DESC", "Balance at the start of the statement")
+	x.element("BALTYPE", "DOLLAR")
+	x.element("VALUE", formatAmount(writer.opening))
+	x.element("DTASOF", ofxTime(writer.info.From))
+	x.end()
+	x.end()
+
+	x.end() // STMTRS
+	x.end() // STMTTRNRS
+	x.end() // BANKMSGSRSV1
+	x.end() // OFX
+
+	return x.flush()
+}
+
+func (writer *ofxWriter) status() {
+	writer.x.start("STATUS")
+	writer.x.element("CODE", "0")
+	writer.x.element("SEVERITY", "INFO")
+	writer.x.end()
+}
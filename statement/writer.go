@@ -0,0 +1,94 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+)
+
+// supported export formats of the statement
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+// ErrUnknownFormat is returned by NewWriter for a format which is not supported
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Info describes the exported statement, From and To is the period of the statement
+// and CreatedAt is the time when the document was generated
+type Info struct {
+	Account   db.Account
+	From      time.Time
+	To        time.Time
+	CreatedAt time.Time
+}
+
+// Writer writes the statement as it is streamed from db.Store.ExportStatement,
+// Close writes the end of the document and must be called after the last line
+type Writer interface {
+	db.StatementWriter
+	Close() error
+}
+
+type format struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer, info Info) Writer
+}
+
+var formats = map[string]format{
+	FormatCSV:     {contentType: "text/csv; charset=utf-8", extension: "csv", newWriter: newCSVWriter},
+	FormatOFX:     {contentType: "application/x-ofx", extension: "ofx", newWriter: newOFXWriter},
+	FormatCamt053: {contentType: "application/xml", extension: "xml", newWriter: newCamt053Writer},
+}
+
+// NewWriter returns the writer of the statement in the format
+func NewWriter(format string, w io.Writer, info Info) (Writer, error) {
+	f, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	return f.newWriter(w, info), nil
+}
+
+// ContentType returns the MIME type of the format
+func ContentType(format string) string {
+	return formats[format].contentType
+}
+
+// FileName returns the name of the exported file, e.g. statement-1-20240101-20240201.csv
+func FileName(format string, info Info) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s",
+		info.Account.ID,
+		info.From.UTC().Format("20060102"),
+		info.To.UTC().Format("20060102"),
+		formats[format].extension,
+	)
+}
+
+// formatAmount formats the amount in minor units as a decimal number,
+// all supported currencies have two decimal places
+func formatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// description returns the text of the movement shown in the statement
+func description(line db.StatementLine) string {
+	if line.Amount < 0 {
+		return fmt.Sprintf("Transfer to account %d", line.CounterpartyAccountID)
+	}
+
+	return fmt.Sprintf("Transfer from account %d", line.CounterpartyAccountID)
+}
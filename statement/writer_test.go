@@ -0,0 +1,136 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	db "github.com/karlib/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// golden soubory se přegenerují pomocí: go test ./statement -update
+var update = flag.Bool("update", false, "update the golden files")
+
+func testStatement() (Info, int64, int64, []db.StatementLine) {
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	info := Info{
+		Account: db.Account{
+			ID:       42,
+			Owner:    "alice & bob",
+			Currency: "USD",
+			Balance:  1500,
+		},
+		From:      from,
+		To:        from.AddDate(0, 1, 0),
+		CreatedAt: time.Date(2024, time.February, 1, 8, 30, 0, 0, time.UTC),
+	}
+
+	lines := []db.StatementLine{
+		{
+			TransferID:            7,
+			CounterpartyAccountID: 3,
+			Amount:                2050,
+			ExchangeRate:          "1.00000000",
+			Balance:               12050,
+			CreatedAt:             from.Add(26 * time.Hour),
+		},
+		{
+			TransferID:            9,
+			CounterpartyAccountID: 5,
+			Amount:                -10005,
+			ExchangeRate:          "0.92000000",
+			Balance:               2045,
+			CreatedAt:             from.Add(10 * 24 * time.Hour),
+		},
+		{
+			TransferID:            12,
+			CounterpartyAccountID: 3,
+			Amount:                -545,
+			ExchangeRate:          "1.00000000",
+			Balance:               1500,
+			CreatedAt:             from.Add(20*24*time.Hour + 90*time.Minute),
+		},
+	}
+
+	return info, 10000, 1500, lines
+}
+
+func TestWriterGolden(t *testing.T) {
+	testCases := []struct {
+		format string
+		golden string
+	}{
+		{format: FormatCSV, golden: "statement.csv.golden"},
+		{format: FormatOFX, golden: "statement.ofx.golden"},
+		{format: FormatCamt053, golden: "statement.camt053.golden"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.format, func(t *testing.T) {
+			info, opening, closing, lines := testStatement()
+
+			var buf bytes.Buffer
+			writer, err := NewWriter(tc.format, &buf, info)
+			require.NoError(t, err)
+
+			require.NoError(t, writer.WriteBalances(opening, closing))
+			for _, line := range lines {
+				require.NoError(t, writer.WriteLine(line))
+			}
+			require.NoError(t, writer.Close())
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+			}
+
+			golden, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(golden), buf.String())
+		})
+	}
+}
+
+func TestWriterEmptyStatement(t *testing.T) {
+	for format := range formats {
+		info, _, _, _ := testStatement()
+
+		var buf bytes.Buffer
+		writer, err := NewWriter(format, &buf, info)
+		require.NoError(t, err)
+
+		require.NoError(t, writer.WriteBalances(1500, 1500))
+		require.NoError(t, writer.Close())
+		require.Contains(t, buf.String(), "15.00")
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	info, _, _, _ := testStatement()
+
+	writer, err := NewWriter("pdf", &bytes.Buffer{}, info)
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	require.Nil(t, writer)
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", formatAmount(0))
+	require.Equal(t, "0.05", formatAmount(5))
+	require.Equal(t, "12.34", formatAmount(1234))
+	require.Equal(t, "-0.05", formatAmount(-5))
+	require.Equal(t, "-100.00", formatAmount(-10000))
+}
+
+func TestFileName(t *testing.T) {
+	info, _, _, _ := testStatement()
+
+	require.Equal(t, "statement-42-20240101-20240201.csv", FileName(FormatCSV, info))
+	require.Equal(t, "statement-42-20240101-20240201.xml", FileName(FormatCamt053, info))
+}
@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xmlWriter streams the XML document element by element, the first error is kept
+// and all following writes are skipped, so it is checked only once in flush
+type xmlWriter struct {
+	w    io.Writer
+	enc  *xml.Encoder
+	open []xml.Name
+	err  error
+}
+
+func newXMLWriter(w io.Writer) *xmlWriter {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xmlWriter{w: w, enc: enc}
+}
+
+func (x *xmlWriter) token(token xml.Token) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(token)
+	}
+}
+
+// prolog writes the XML declaration and the processing instructions, each on its own line,
+// the encoder doesn't indent them, so they are written directly before the first element
+func (x *xmlWriter) prolog(lines ...string) {
+	for _, line := range lines {
+		if x.err == nil {
+			_, x.err = io.WriteString(x.w, line+"\n")
+		}
+	}
+}
+
+func (x *xmlWriter) start(name string, attrs ...xml.Attr) {
+	x.open = append(x.open, xml.Name{Local: name})
+	x.token(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+}
+
+// end closes the last opened element
+func (x *xmlWriter) end() {
+	name := x.open[len(x.open)-1]
+	x.open = x.open[:len(x.open)-1]
+	x.token(xml.EndElement{Name: name})
+}
+
+// element writes the element with the text content
+func (x *xmlWriter) element(name string, value string, attrs ...xml.Attr) {
+	x.start(name, attrs...)
+	x.token(xml.CharData(value))
+	x.end()
+}
+
+// flush writes the buffered document and returns the first error of the writes
+func (x *xmlWriter) flush() error {
+	if x.err != nil {
+		return x.err
+	}
+
+	if err := x.enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
+
+func attr(name string, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
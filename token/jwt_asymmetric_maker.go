@@ -0,0 +1,96 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const minRSAKeyBits = 2048
+
+// ErrMissingPrivateKey is returned when a maker created only with the public key is asked to create a token
+var ErrMissingPrivateKey = errors.New("the token maker has no private key, it can only verify tokens")
+
+// JWTAsymmetricMaker is a JSON web token maker which signs tokens with a private key
+// and verifies them with the public key, so other services need only the public key
+type JWTAsymmetricMaker struct {
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+	// optional ID of the key stored in the kid header, it is set by the Keyring
+	keyID string
+}
+
+// NewJWTEdDSAMaker creates a new JWT maker using the EdDSA (Ed25519) algorithm
+// privateKey can be nil, then the maker can only verify tokens
+func NewJWTEdDSAMaker(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) (Maker, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+
+	maker := &JWTAsymmetricMaker{
+		method:    SigningMethodEd25519,
+		publicKey: publicKey,
+	}
+	if privateKey != nil {
+		maker.privateKey = privateKey
+	}
+
+	return maker, nil
+}
+
+// NewJWTRS256Maker creates a new JWT maker using the RS256 (RSA + SHA256) algorithm
+// privateKey can be nil, then the maker can only verify tokens
+func NewJWTRS256Maker(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) (Maker, error) {
+	if publicKey == nil || publicKey.N.BitLen() < minRSAKeyBits {
+		return nil, errors.New("invalid RSA public key: must be at least 2048 bits")
+	}
+
+	maker := &JWTAsymmetricMaker{
+		method:    jwt.SigningMethodRS256,
+		publicKey: publicKey,
+	}
+	if privateKey != nil {
+		maker.privateKey = privateKey
+	}
+
+	return maker, nil
+}
+
+// CreateToken creates a new token of the token type for a specific username, role and duration
+func (maker *JWTAsymmetricMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrMissingPrivateKey
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, payload)
+	setKeyIDHeader(jwtToken, maker.keyID)
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+// If the token will be valid this fuction will return the Payload data stored inside the token
+func (maker *JWTAsymmetricMaker) VerifyToken(token string) (*Payload, error) {
+	// The algorithm from the token header must be exactly the one used by this maker.
+	// Checking only the family of the algorithm is not enough, because an attacker can take our
+	// public key and use it as HMAC secret for a HS256 token (the algorithm confusion described in notes.txt),
+	// or set the algorithm to none to skip the signature verification completely.
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != maker.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return maker.publicKey, nil
+	}
+
+	return verifyJWT(token, keyFunc)
+}
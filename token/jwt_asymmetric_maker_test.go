@@ -0,0 +1,146 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newEdDSAKeys(t *testing.T) (ed25519.PrivateKey, ed25519.PublicKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey, publicKey
+}
+
+func newRSAKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PublicKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	require.NoError(t, err)
+	return privateKey, &privateKey.PublicKey
+}
+
+func requireValidMaker(t *testing.T, maker Maker) {
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+	token, payload, err := maker.CreateToken(username, role, TokenTypeAccess, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, TokenTypeAccess, payload.TokenType)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	// expired token
+	token, _, err = maker.CreateToken(username, role, TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTEdDSAMaker(t *testing.T) {
+	maker, err := NewJWTEdDSAMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+	requireValidMaker(t, maker)
+}
+
+func TestJWTRS256Maker(t *testing.T) {
+	maker, err := NewJWTRS256Maker(newRSAKeys(t))
+	require.NoError(t, err)
+	requireValidMaker(t, maker)
+}
+
+func TestJWTAsymmetricMakerVerifyOnly(t *testing.T) {
+	privateKey, publicKey := newEdDSAKeys(t)
+
+	signer, err := NewJWTEdDSAMaker(privateKey, publicKey)
+	require.NoError(t, err)
+
+	// other services get only the public key
+	verifier, err := NewJWTEdDSAMaker(nil, publicKey)
+	require.NoError(t, err)
+
+	token, _, err := signer.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	_, _, err = verifier.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.EqualError(t, err, ErrMissingPrivateKey.Error())
+}
+
+func TestJWTRS256AlgorithmConfusion(t *testing.T) {
+	_, publicKey := newRSAKeys(t)
+	maker, err := NewJWTRS256Maker(nil, publicKey)
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	// the attacker knows our public key and uses it as the HMAC secret of a HS256 token
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString(publicKeyPEM)
+	require.NoError(t, err)
+
+	verified, err := maker.VerifyToken(forged)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+
+	// the alg none token must be rejected as well
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, payload).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	verified, err = maker.VerifyToken(none)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, verified)
+}
+
+func TestJWTEdDSARejectsOtherAlgorithm(t *testing.T) {
+	// token signed correctly by RS256 must not be accepted by the EdDSA maker
+	rsaMaker, err := NewJWTRS256Maker(newRSAKeys(t))
+	require.NoError(t, err)
+
+	token, _, err := rsaMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	eddsaMaker, err := NewJWTEdDSAMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+
+	payload, err := eddsaMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestNewJWTRS256MakerShortKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	maker, err := NewJWTRS256Maker(privateKey, &privateKey.PublicKey)
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
@@ -13,6 +13,8 @@ const minSecretKeySize = 32
 // JWTMaker is a JSON web token maker
 type JWTMaker struct {
 	secretKey string
+	// optional ID of the key stored in the kid header, it is set by the Keyring
+	keyID string
 }
 
 // NewJWTMaker creates a new JWTMaker
@@ -21,17 +23,19 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
 	}
 
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{secretKey: secretKey}, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token of the token type for a specific username, role and duration
+func (maker *JWTMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", payload, err
 	}
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	return jwtToken.SignedString([]byte(maker.secretKey))
+	setKeyIDHeader(jwtToken, maker.keyID)
+	token, err := jwtToken.SignedString([]byte(maker.secretKey))
+	return token, payload, err
 }
 
 // VerifyToken checks if the token is valid or not
@@ -48,6 +52,19 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		}
 		return []byte(maker.secretKey), nil
 	}
+	return verifyJWT(token, keyFunc)
+}
+
+// setKeyIDHeader stores the key ID in the kid header, so the Keyring knows which key verifies the token
+func setKeyIDHeader(jwtToken *jwt.Token, keyID string) {
+	if keyID != "" {
+		jwtToken.Header["kid"] = keyID
+	}
+}
+
+// verifyJWT parses the token with the key returned by keyFunc and converts the errors
+// of the jwt package to the errors of this package, it is shared by all JWT makers
+func verifyJWT(token string, keyFunc jwt.Keyfunc) (*Payload, error) {
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
 	// if here the error is not equal to nil
 	// than this can be from two reasons, first the token is invalid and the second one it is expired
@@ -0,0 +1,334 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/o1egl/paseto"
+)
+
+// KeyConfig describes one key of the keyring
+type KeyConfig struct {
+	// ID is stored in every token signed by this key (JWT kid header / PASETO footer)
+	// the key with empty ID verifies the tokens without any key ID
+	ID string `json:"id"`
+	// Type is one of the token types defined in maker.go
+	Type string `json:"type"`
+	// SymmetricKey is used by paseto_local and jwt_hs256 keys
+	SymmetricKey string `json:"symmetric_key,omitempty"`
+	// PrivateKeyPath and PublicKeyPath are PEM files used by the asymmetric keys,
+	// the private key is needed only for the active key
+	PrivateKeyPath string `json:"private_key_path,omitempty"`
+	PublicKeyPath  string `json:"public_key_path,omitempty"`
+	// RetiredAt is the time when the key stops to be valid for verification,
+	// it should be at least the longest token duration after the key stopped to be active
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+// KeyringConfig is the content of the keyring file
+type KeyringConfig struct {
+	// ActiveKeyID is the ID of the key which signs the new tokens
+	ActiveKeyID string      `json:"active_key_id"`
+	Keys        []KeyConfig `json:"keys"`
+}
+
+// LoadKeyringConfig reads the keyring config from the JSON file
+func LoadKeyringConfig(path string) (config KeyringConfig, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &config)
+	return
+}
+
+type keyringKey struct {
+	config    KeyConfig
+	maker     Maker
+	publicKey crypto.PublicKey
+}
+
+// Keyring is a Maker which signs new tokens with the active key and verifies tokens
+// with the key selected by the key ID stored inside the token.
+// The keys can be replaced with Reload without restarting the server.
+type Keyring struct {
+	mu          sync.RWMutex
+	activeKeyID string
+	keys        map[string]keyringKey
+}
+
+// NewKeyring creates a new keyring from the config
+func NewKeyring(config KeyringConfig) (*Keyring, error) {
+	keyring := &Keyring{}
+	if err := keyring.Reload(config); err != nil {
+		return nil, err
+	}
+
+	return keyring, nil
+}
+
+// Reload replaces all keys of the keyring, if the config is not valid
+// the error is returned and the keyring keeps the old keys
+func (keyring *Keyring) Reload(config KeyringConfig) error {
+	keys := make(map[string]keyringKey, len(config.Keys))
+	for _, keyConfig := range config.Keys {
+		if _, ok := keys[keyConfig.ID]; ok {
+			return fmt.Errorf("duplicate key ID %q", keyConfig.ID)
+		}
+
+		maker, publicKey, err := newKeyMaker(keyConfig, keyConfig.ID == config.ActiveKeyID)
+		if err != nil {
+			return fmt.Errorf("cannot create key %q: %w", keyConfig.ID, err)
+		}
+
+		keys[keyConfig.ID] = keyringKey{
+			config:    keyConfig,
+			maker:     maker,
+			publicKey: publicKey,
+		}
+	}
+
+	active, ok := keys[config.ActiveKeyID]
+	if !ok {
+		return fmt.Errorf("active key %q is not in the keyring", config.ActiveKeyID)
+	}
+	if active.config.RetiredAt != nil {
+		return fmt.Errorf("active key %q can't be retired", config.ActiveKeyID)
+	}
+
+	keyring.mu.Lock()
+	keyring.activeKeyID = config.ActiveKeyID
+	keyring.keys = keys
+	keyring.mu.Unlock()
+
+	return nil
+}
+
+// CreateToken creates a new token signed by the active key
+func (keyring *Keyring) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	keyring.mu.RLock()
+	active := keyring.keys[keyring.activeKeyID]
+	keyring.mu.RUnlock()
+
+	return active.maker.CreateToken(username, role, tokenType, duration)
+}
+
+// VerifyToken finds the key by the key ID stored inside the token and verifies the token with it
+func (keyring *Keyring) VerifyToken(token string) (*Payload, error) {
+	keyID, err := tokenKeyID(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	keyring.mu.RLock()
+	key, ok := keyring.keys[keyID]
+	keyring.mu.RUnlock()
+
+	if !ok || key.config.RetiredAt != nil && time.Now().After(*key.config.RetiredAt) {
+		return nil, ErrInvalidToken
+	}
+
+	return key.maker.VerifyToken(token)
+}
+
+// ActiveKeyID returns the ID of the key which signs the new tokens
+func (keyring *Keyring) ActiveKeyID() string {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	return keyring.activeKeyID
+}
+
+// tokenKeyID reads the key ID from the token without verifying it
+func tokenKeyID(token string) (string, error) {
+	if strings.HasPrefix(token, "v2.") {
+		var footer pasetoFooter
+		var raw []byte
+		if err := paseto.ParseFooter(token, &raw); err != nil {
+			return "", err
+		}
+		// tokens without key ID have no footer or the "null" footer
+		if len(raw) == 0 || string(raw) == "null" {
+			return "", nil
+		}
+		if err := json.Unmarshal(raw, &footer); err != nil {
+			return "", err
+		}
+		return footer.KeyID, nil
+	}
+
+	jwtToken, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+	if err != nil {
+		return "", err
+	}
+
+	keyID, _ := jwtToken.Header["kid"].(string)
+	return keyID, nil
+}
+
+// newKeyMaker creates the maker for one key of the keyring and returns also its public key
+// (nil for symmetric keys), the private key is loaded only when the key signs tokens
+func newKeyMaker(config KeyConfig, signing bool) (Maker, crypto.PublicKey, error) {
+	switch config.Type {
+	case TypePasetoLocal:
+		maker, err := NewPassetoMaker(config.SymmetricKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		maker.(*PasetoMaker).keyID = config.ID
+		return maker, nil, nil
+
+	case TypeJWTHS256:
+		maker, err := NewJWTMaker(config.SymmetricKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		maker.(*JWTMaker).keyID = config.ID
+		return maker, nil, nil
+
+	case TypePasetoPublic, TypeJWTEdDSA:
+		publicKey, err := LoadEd25519PublicKey(config.PublicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var privateKey ed25519.PrivateKey
+		if signing {
+			privateKey, err = LoadEd25519PrivateKey(config.PrivateKeyPath)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if config.Type == TypePasetoPublic {
+			maker, err := NewPasetoPublicMaker(privateKey, publicKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			maker.(*PasetoPublicMaker).keyID = config.ID
+			return maker, publicKey, nil
+		}
+
+		maker, err := NewJWTEdDSAMaker(privateKey, publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		maker.(*JWTAsymmetricMaker).keyID = config.ID
+		return maker, publicKey, nil
+
+	case TypeJWTRS256:
+		publicKey, err := LoadRSAPublicKey(config.PublicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var privateKey *rsa.PrivateKey
+		if signing {
+			privateKey, err = LoadRSAPrivateKey(config.PrivateKeyPath)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		maker, err := NewJWTRS256Maker(privateKey, publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		maker.(*JWTAsymmetricMaker).keyID = config.ID
+		return maker, publicKey, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported token type %q", config.Type)
+}
+
+// JSONWebKey is the public key in the JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg,omitempty"`
+	// Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the content of the /.well-known/jwks.json endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var errNoPublicKey = errors.New("key has no public key")
+
+// JWKS returns the public keys of all asymmetric keys which are not retired yet,
+// the symmetric keys are secret and they are never published
+func (keyring *Keyring) JWKS() JSONWebKeySet {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range keyring.keys {
+		if key.config.RetiredAt != nil && time.Now().After(*key.config.RetiredAt) {
+			continue
+		}
+
+		jwk, err := newJSONWebKey(key)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
+
+func newJSONWebKey(key keyringKey) (JSONWebKey, error) {
+	encoding := base64.RawURLEncoding
+
+	switch publicKey := key.publicKey.(type) {
+	case ed25519.PublicKey:
+		jwk := JSONWebKey{
+			KeyType: "OKP",
+			KeyID:   key.config.ID,
+			Use:     "sig",
+			Curve:   "Ed25519",
+			X:       encoding.EncodeToString(publicKey),
+		}
+		// PASETO keys have no JWA algorithm name
+		if key.config.Type == TypeJWTEdDSA {
+			jwk.Algorithm = SigningMethodEd25519.Alg()
+		}
+		return jwk, nil
+
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			KeyType:   "RSA",
+			KeyID:     key.config.ID,
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         encoding.EncodeToString(publicKey.N.Bytes()),
+			E:         encoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}, nil
+	}
+
+	return JSONWebKey{}, errNoPublicKey
+}
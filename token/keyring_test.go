@@ -0,0 +1,153 @@
+package token
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEdDSAKeyFiles writes a new Ed25519 key pair into PEM files and returns their paths
+func writeEdDSAKeyFiles(t *testing.T) (privateKeyPath string, publicKeyPath string) {
+	privateKey, publicKey := newEdDSAKeys(t)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	privateKeyPath = writePEM(t, "private.pem", "PRIVATE KEY", privateDER)
+	publicKeyPath = writePEM(t, "public.pem", "PUBLIC KEY", publicDER)
+	return
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey := KeyConfig{ID: "old", Type: TypePasetoLocal, SymmetricKey: util.RandomString(32)}
+	keyring, err := NewKeyring(KeyringConfig{ActiveKeyID: "old", Keys: []KeyConfig{oldKey}})
+	require.NoError(t, err)
+	requireValidMaker(t, keyring)
+
+	oldToken, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	keyID, err := tokenKeyID(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "old", keyID)
+
+	// rotate to the new asymmetric key, the old key still verifies its tokens
+	privateKeyPath, publicKeyPath := writeEdDSAKeyFiles(t)
+	newKey := KeyConfig{ID: "new", Type: TypeJWTEdDSA, PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath}
+	err = keyring.Reload(KeyringConfig{ActiveKeyID: "new", Keys: []KeyConfig{newKey, oldKey}})
+	require.NoError(t, err)
+	require.Equal(t, "new", keyring.ActiveKeyID())
+
+	newToken, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	keyID, err = tokenKeyID(newToken)
+	require.NoError(t, err)
+	require.Equal(t, "new", keyID)
+
+	_, err = keyring.VerifyToken(oldToken)
+	require.NoError(t, err)
+	_, err = keyring.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	// retire the old key
+	retiredAt := time.Now().Add(-time.Second)
+	oldKey.RetiredAt = &retiredAt
+	err = keyring.Reload(KeyringConfig{ActiveKeyID: "new", Keys: []KeyConfig{newKey, oldKey}})
+	require.NoError(t, err)
+
+	payload, err := keyring.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	_, err = keyring.VerifyToken(newToken)
+	require.NoError(t, err)
+}
+
+func TestKeyringUnknownKeyID(t *testing.T) {
+	symmetricKey := util.RandomString(32)
+
+	keyring1, err := NewKeyring(KeyringConfig{ActiveKeyID: "k1", Keys: []KeyConfig{
+		{ID: "k1", Type: TypeJWTHS256, SymmetricKey: symmetricKey},
+	}})
+	require.NoError(t, err)
+
+	keyring2, err := NewKeyring(KeyringConfig{ActiveKeyID: "k2", Keys: []KeyConfig{
+		{ID: "k2", Type: TypeJWTHS256, SymmetricKey: symmetricKey},
+	}})
+	require.NoError(t, err)
+
+	token, _, err := keyring1.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := keyring2.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestKeyringWithoutKeyID(t *testing.T) {
+	// the key without ID verifies the tokens created before the keyring existed
+	symmetricKey := util.RandomString(32)
+	legacyMaker, err := NewPassetoMaker(symmetricKey)
+	require.NoError(t, err)
+
+	token, _, err := legacyMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring(KeyringConfig{ActiveKeyID: "", Keys: []KeyConfig{
+		{ID: "", Type: TypePasetoLocal, SymmetricKey: symmetricKey},
+	}})
+	require.NoError(t, err)
+
+	_, err = keyring.VerifyToken(token)
+	require.NoError(t, err)
+}
+
+func TestKeyringInvalidReload(t *testing.T) {
+	key := KeyConfig{ID: "k1", Type: TypePasetoLocal, SymmetricKey: util.RandomString(32)}
+	keyring, err := NewKeyring(KeyringConfig{ActiveKeyID: "k1", Keys: []KeyConfig{key}})
+	require.NoError(t, err)
+
+	testCases := []KeyringConfig{
+		{ActiveKeyID: "missing", Keys: []KeyConfig{key}},
+		{ActiveKeyID: "k1", Keys: []KeyConfig{key, key}},
+		{ActiveKeyID: "k2", Keys: []KeyConfig{key, {ID: "k2", Type: "unknown"}}},
+		{ActiveKeyID: "k2", Keys: []KeyConfig{key, {ID: "k2", Type: TypePasetoLocal, SymmetricKey: "short"}}},
+	}
+
+	for _, config := range testCases {
+		err = keyring.Reload(config)
+		require.Error(t, err)
+		// the keyring keeps the old keys
+		require.Equal(t, "k1", keyring.ActiveKeyID())
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	privateKeyPath, publicKeyPath := writeEdDSAKeyFiles(t)
+
+	keyring, err := NewKeyring(KeyringConfig{ActiveKeyID: "eddsa", Keys: []KeyConfig{
+		{ID: "eddsa", Type: TypeJWTEdDSA, PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath},
+		// verification only key, it needs no private key
+		{ID: "paseto", Type: TypePasetoPublic, PublicKeyPath: publicKeyPath},
+		{ID: "secret", Type: TypePasetoLocal, SymmetricKey: util.RandomString(32)},
+	}})
+	require.NoError(t, err)
+
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	require.Equal(t, "eddsa", jwks.Keys[0].KeyID)
+	require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	require.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+	require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+	require.NotEmpty(t, jwks.Keys[0].X)
+
+	require.Equal(t, "paseto", jwks.Keys[1].KeyID)
+	require.Empty(t, jwks.Keys[1].Algorithm)
+}
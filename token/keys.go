@@ -0,0 +1,101 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var errInvalidPEM = errors.New("failed to decode PEM block")
+
+// LoadEd25519PrivateKey reads a PKCS#8 PEM encoded Ed25519 private key from the file
+// the key can be generated with: openssl genpkey -algorithm ed25519 -out private.pem
+func LoadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key %s: %w", path, err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an Ed25519 key", path)
+	}
+
+	return privateKey, nil
+}
+
+// LoadEd25519PublicKey reads a PKIX PEM encoded Ed25519 public key from the file
+// the key can be generated with: openssl pkey -in private.pem -pubout -out public.pem
+func LoadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", path, err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+
+	return publicKey, nil
+}
+
+// LoadRSAPrivateKey reads a PKCS#1 or PKCS#8 PEM encoded RSA private key from the file
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key %s: %w", path, err)
+	}
+
+	return privateKey, nil
+}
+
+// LoadRSAPublicKey reads a PKIX PEM encoded RSA public key (or certificate) from the file
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", path, err)
+	}
+
+	return publicKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: %w", path, errInvalidPEM)
+	}
+
+	return block, nil
+}
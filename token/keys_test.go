@@ -0,0 +1,71 @@
+package token
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, name string, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestLoadEd25519Keys(t *testing.T) {
+	privateKey, publicKey := newEdDSAKeys(t)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	loadedPrivate, err := LoadEd25519PrivateKey(writePEM(t, "private.pem", "PRIVATE KEY", privateDER))
+	require.NoError(t, err)
+	require.Equal(t, privateKey, loadedPrivate)
+
+	loadedPublic, err := LoadEd25519PublicKey(writePEM(t, "public.pem", "PUBLIC KEY", publicDER))
+	require.NoError(t, err)
+	require.Equal(t, publicKey, loadedPublic)
+}
+
+func TestLoadRSAKeys(t *testing.T) {
+	privateKey, publicKey := newRSAKeys(t)
+
+	privateDER := x509.MarshalPKCS1PrivateKey(privateKey)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	loadedPrivate, err := LoadRSAPrivateKey(writePEM(t, "private.pem", "RSA PRIVATE KEY", privateDER))
+	require.NoError(t, err)
+	require.True(t, privateKey.Equal(loadedPrivate))
+
+	loadedPublic, err := LoadRSAPublicKey(writePEM(t, "public.pem", "PUBLIC KEY", publicDER))
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(loadedPublic))
+}
+
+func TestLoadKeyWrongType(t *testing.T) {
+	// RSA key can't be loaded as the Ed25519 key
+	privateKey, _ := newRSAKeys(t)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	key, err := LoadEd25519PrivateKey(writePEM(t, "private.pem", "PRIVATE KEY", privateDER))
+	require.Error(t, err)
+	require.Nil(t, key)
+}
+
+func TestLoadKeyInvalidPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a pem"), 0600))
+
+	key, err := LoadEd25519PublicKey(path)
+	require.ErrorIs(t, err, errInvalidPEM)
+	require.Nil(t, key)
+}
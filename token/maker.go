@@ -2,10 +2,25 @@ package token
 
 import "time"
 
+// Supported token types, the server selects one of them with TOKEN_TYPE config
+const (
+	// PASETO v2.local, symmetric key TOKEN_SYMMETRIC_KEY
+	TypePasetoLocal = "paseto_local"
+	// PASETO v2.public, Ed25519 key pair
+	TypePasetoPublic = "paseto_public"
+	// JWT HS256, symmetric key TOKEN_SYMMETRIC_KEY
+	TypeJWTHS256 = "jwt_hs256"
+	// JWT EdDSA, Ed25519 key pair
+	TypeJWTEdDSA = "jwt_eddsa"
+	// JWT RS256, RSA key pair
+	TypeJWTRS256 = "jwt_rs256"
+)
+
 // Maker is an interface for managing tokens
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken creates a new token of the token type for a specific username, role and duration
+	// It also returns the payload so the caller can store its ID and expiration time
+	CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken checks if the token is valid or not
 	// If the token will be valid this fuction will return the Payload data stored inside the token
@@ -0,0 +1,84 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// PasetoPublicMaker implements the Maker interface with PASETO v2.public tokens,
+// the token is signed with the Ed25519 private key and verified with the public key,
+// so it can be verified by other services without sharing any secret
+type PasetoPublicMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	// optional ID of the key stored in the footer, it is set by the Keyring
+	keyID string
+}
+
+// NewPasetoPublicMaker creates a new PASETO v2.public maker
+// privateKey can be nil, then the maker can only verify tokens
+func NewPasetoPublicMaker(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) (Maker, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}
+
+	return maker, nil
+}
+
+// CreateToken creates a new token of the token type for a specific username, role and duration
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrMissingPrivateKey
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	token, err := maker.paseto.Sign(maker.privateKey, payload, keyIDFooter(maker.keyID))
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+// If the token will be valid this fuction will return the Payload data stored inside the token
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	payload := &Payload{}
+
+	// the version and purpose are part of the token header (v2.public), the paseto library
+	// refuses every other header, so there is no algorithm which could be confused
+	err := maker.paseto.Verify(token, maker.publicKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// pasetoFooter is the not encrypted, but authenticated part of the PASETO token
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// keyIDFooter returns the footer with the key ID, or nil if the key has no ID
+func keyIDFooter(keyID string) interface{} {
+	if keyID == "" {
+		return nil
+	}
+	return pasetoFooter{KeyID: keyID}
+}
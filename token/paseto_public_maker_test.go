@@ -0,0 +1,45 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/karlib/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+	requireValidMaker(t, maker)
+}
+
+func TestPasetoPublicMakerWrongKey(t *testing.T) {
+	maker1, err := NewPasetoPublicMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+
+	maker2, err := NewPasetoPublicMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+
+	token, _, err := maker1.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker2.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerRejectsLocalToken(t *testing.T) {
+	localMaker, err := NewPassetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := localMaker.CreateToken(util.RandomOwner(), util.DepositorRole, TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	publicMaker, err := NewPasetoPublicMaker(newEdDSAKeys(t))
+	require.NoError(t, err)
+
+	payload, err := publicMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
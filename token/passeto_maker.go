@@ -13,6 +13,8 @@ import (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	// optional ID of the key stored in the footer, it is set by the Keyring
+	keyID string
 }
 
 // NewPassetoMaker crates a new paseto Maker
@@ -30,14 +32,15 @@ func NewPassetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token of the token type for a specific username, role and duration
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", nil
+		return "", payload, err
 	}
-	// nil inside the Encrypt func argument is just value for optional paseto footer
-	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	// the optional paseto footer is used only for the key ID
+	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, keyIDFooter(maker.keyID))
+	return token, payload, err
 }
 
 // VerifyToken checks if the token is valid or not
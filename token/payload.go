@@ -11,6 +11,15 @@ import (
 var (
 	ErrInvalidToken = errors.New("token is invalid") //
 	ErrExpiredToken = errors.New("token has expired")
+	// ErrWrongTokenType is returned when e.g. the refresh token is used instead of the access token
+	ErrWrongTokenType = errors.New("token has a wrong token type")
+)
+
+// Token types stored inside the payload, the access token is used to access the routes
+// and the refresh token only to renew the access token
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 )
 
 // Payload contains the payload data of the token
@@ -20,6 +29,10 @@ type Payload struct {
 	ID uuid.UUID `json:"id"`
 	// This field will be used to identify token owner
 	Username string `json:"username"`
+	// The role of the token owner, it is used to authorize the access to the routes
+	Role string `json:"role"`
+	// Access or refresh token, both are signed by the same key so they must be distinguished by this claim
+	TokenType string `json:"token_type"`
 	// This filed is time when the token was created.
 	IssuedAt time.Time `json:"issued_at"`
 	// It is absolutely neccessary to create just tokens with sort expiration
@@ -27,9 +40,9 @@ type Payload struct {
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with specific username and duration
+// NewPayload creates a new token payload with specific username, role, token type and duration
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, tokenType string, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -38,6 +51,8 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
+		Role:      role,
+		TokenType: tokenType,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -53,3 +68,12 @@ func (payload *Payload) Valid() error {
 
 	return nil
 }
+
+// CheckType returns ErrWrongTokenType if the token isn't of the expected token type
+func (payload *Payload) CheckType(tokenType string) error {
+	if payload.TokenType != tokenType {
+		return ErrWrongTokenType
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package util
+
+// Constants with supported roles of the account members
+const (
+	// MemberOwner is the role of the owner of the account, every account has exactly one
+	MemberOwner = "owner"
+	// MemberCoOwner can do everything with the account like its owner, except removing the owner
+	MemberCoOwner = "co-owner"
+	// MemberViewer can only view the account, its entries and transfers
+	MemberViewer = "viewer"
+	// MemberCanInitiate can view the account and send transfers from it
+	MemberCanInitiate = "can-initiate"
+	// MemberNeedsApproval can send transfers from the account, but they are created as pending
+	// and wait until the owner or a co-owner of the account authorizes them
+	MemberNeedsApproval = "needs-approval"
+)
+
+// IsSupportedMemberRole returns true if the role of the account member is supported
+func IsSupportedMemberRole(role string) bool {
+	switch role {
+	case MemberOwner, MemberCoOwner, MemberViewer, MemberCanInitiate, MemberNeedsApproval:
+		return true
+	}
+	return false
+}
+
+// CanViewAccount returns true if the member with the role can view the account
+func CanViewAccount(role string) bool {
+	return IsSupportedMemberRole(role)
+}
+
+// CanInitiateTransfers returns true if the member with the role can send transfers from the account
+func CanInitiateTransfers(role string) bool {
+	switch role {
+	case MemberOwner, MemberCoOwner, MemberCanInitiate, MemberNeedsApproval:
+		return true
+	}
+	return false
+}
+
+// CanManageAccount returns true if the member with the role can close the account and manage its members
+func CanManageAccount(role string) bool {
+	switch role {
+	case MemberOwner, MemberCoOwner:
+		return true
+	}
+	return false
+}
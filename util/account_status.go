@@ -0,0 +1,11 @@
+package util
+
+// Constants with supported account statuses
+const (
+	// AccountActive is the status of the account which can send and receive money
+	AccountActive = "active"
+	// AccountFrozen is the status of the account blocked by a banker, for example when it is compromised
+	AccountFrozen = "frozen"
+	// AccountClosed is the final status of the account closed by its owner, it can't be reopened
+	AccountClosed = "closed"
+)
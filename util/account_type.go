@@ -0,0 +1,29 @@
+package util
+
+// Constants with supported account types
+const (
+	// AccountChecking is the everyday account, it can go below zero down to its overdraft limit
+	AccountChecking = "checking"
+	// AccountSavings is the account with the limited number of outgoing transfers per month
+	AccountSavings = "savings"
+	// AccountBusiness is the account of a company, unlike the other types it can be created with several owners
+	AccountBusiness = "business"
+	// AccountCash is the account of the system user which deposits and withdrawals of one currency
+	// are posted against, users can't create it
+	AccountCash = "cash"
+	// AccountInterestExpense is the account of the system user which pays the interest of one currency,
+	// users can't create it
+	AccountInterestExpense = "interest_expense"
+	// AccountFeeRevenue is the account of the system user which collects the fees of one currency,
+	// users can't create it
+	AccountFeeRevenue = "fee_revenue"
+)
+
+// IsSupportedAccountType returns true if the account type is supported
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case AccountChecking, AccountSavings, AccountBusiness:
+		return true
+	}
+	return false
+}
@@ -9,11 +9,55 @@ import (
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or enviroment variables.
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
-	TokenSymetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymetricKey     string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPrivateKeyPath  string        `mapstructure:"TOKEN_PRIVATE_KEY_PATH"`
+	TokenPublicKeyPath   string        `mapstructure:"TOKEN_PUBLIC_KEY_PATH"`
+	TokenKeyringPath     string        `mapstructure:"TOKEN_KEYRING_PATH"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RevocationCacheTTL   time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	IdempotencyKeyTTL    time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	FXRatesPath          string        `mapstructure:"FX_RATES_PATH"`
+	FXQuoteDuration      time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	// number of accounts or transfers read by one query of the reconciliation
+	ReconciliationBatchSize int32 `mapstructure:"RECONCILIATION_BATCH_SIZE"`
+	// how often the scheduler looks for due scheduled transfers and how it retries them when funds are insufficient
+	SchedulerInterval              time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	ScheduledTransferMaxAttempts   int32         `mapstructure:"SCHEDULED_TRANSFER_MAX_ATTEMPTS"`
+	ScheduledTransferRetryInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_RETRY_INTERVAL"`
+	// default transfer limits of the users in minor units of each currency, zero is unlimited,
+	// bankers can set other limits for a user or an account in the database
+	TransferLimitPerTransactionUSD int64 `mapstructure:"TRANSFER_LIMIT_PER_TRANSACTION_USD"`
+	TransferLimitDailyUSD          int64 `mapstructure:"TRANSFER_LIMIT_DAILY_USD"`
+	TransferLimitMonthlyUSD        int64 `mapstructure:"TRANSFER_LIMIT_MONTHLY_USD"`
+	TransferLimitPerTransactionEUR int64 `mapstructure:"TRANSFER_LIMIT_PER_TRANSACTION_EUR"`
+	TransferLimitDailyEUR          int64 `mapstructure:"TRANSFER_LIMIT_DAILY_EUR"`
+	TransferLimitMonthlyEUR        int64 `mapstructure:"TRANSFER_LIMIT_MONTHLY_EUR"`
+	TransferLimitPerTransactionCAD int64 `mapstructure:"TRANSFER_LIMIT_PER_TRANSACTION_CAD"`
+	TransferLimitDailyCAD          int64 `mapstructure:"TRANSFER_LIMIT_DAILY_CAD"`
+	TransferLimitMonthlyCAD        int64 `mapstructure:"TRANSFER_LIMIT_MONTHLY_CAD"`
+	// transfers with a larger amount in minor units wait for the approval of a banker, zero posts all transfers
+	TransferApprovalThreshold int64 `mapstructure:"TRANSFER_APPROVAL_THRESHOLD"`
+	// expiration of the account holds placed without their own expiration
+	HoldDuration time.Duration `mapstructure:"HOLD_DURATION"`
+	// every user can look up at most this number of transfer recipients by the username or email in one window,
+	// zero doesn't limit the lookups
+	RecipientLookupLimit  int           `mapstructure:"RECIPIENT_LOOKUP_LIMIT"`
+	RecipientLookupWindow time.Duration `mapstructure:"RECIPIENT_LOOKUP_WINDOW"`
+	// expiration of the payment requests created without their own expiration
+	PaymentRequestDuration time.Duration `mapstructure:"PAYMENT_REQUEST_DURATION"`
+	// overdraft limit of the new checking accounts in minor units, bankers can change it per account
+	CheckingOverdraftLimit int64 `mapstructure:"CHECKING_OVERDRAFT_LIMIT"`
+	// maximum number of outgoing transfers per month of the new savings accounts, zero is unlimited
+	SavingsMonthlyTransfers int32 `mapstructure:"SAVINGS_MONTHLY_TRANSFERS"`
+	// how often the interest engine looks for finished days to accrue and finished months to post
+	InterestInterval time.Duration `mapstructure:"INTEREST_INTERVAL"`
+	// how often the fee engine looks for the accounts to charge the maintenance fee of the previous month
+	FeeInterval time.Duration `mapstructure:"FEE_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,18 @@
+package util
+
+// Constants with supported day-count conventions of the interest rates
+const (
+	// DayCountActual365 accrues every calendar day as 1/365 of the year, also in leap years
+	DayCountActual365 = "act/365"
+	// DayCount30360 counts every month as 30 days and the year as 360 days
+	DayCount30360 = "30/360"
+)
+
+// IsSupportedDayCount returns true if the day-count convention is supported
+func IsSupportedDayCount(dayCount string) bool {
+	switch dayCount {
+	case DayCountActual365, DayCount30360:
+		return true
+	}
+	return false
+}
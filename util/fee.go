@@ -0,0 +1,35 @@
+package util
+
+// Constants with supported fee kinds
+const (
+	// FeeKindTransfer is charged to the from account of every transfer initiated by a user
+	FeeKindTransfer = "transfer"
+	// FeeKindMaintenance is charged monthly to the accounts with a balance below the minimum balance
+	FeeKindMaintenance = "maintenance"
+)
+
+// Constants with supported methods of computing the fee
+const (
+	// FeeFlat charges the same amount regardless of the transferred amount
+	FeeFlat = "flat"
+	// FeePercentage charges a part of the transferred amount, limited by the minimum and maximum fee
+	FeePercentage = "percentage"
+)
+
+// IsSupportedFeeKind returns true if the fee kind is supported
+func IsSupportedFeeKind(kind string) bool {
+	switch kind {
+	case FeeKindTransfer, FeeKindMaintenance:
+		return true
+	}
+	return false
+}
+
+// IsSupportedFeeMethod returns true if the fee method is supported
+func IsSupportedFeeMethod(method string) bool {
+	switch method {
+	case FeeFlat, FeePercentage:
+		return true
+	}
+	return false
+}
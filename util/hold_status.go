@@ -0,0 +1,13 @@
+package util
+
+// Constants with supported account hold statuses, active hold can become captured, released or expired
+const (
+	// HoldActive is the status of the hold which reserves its amount on the account
+	HoldActive = "active"
+	// HoldCaptured is the status of the hold converted into a transfer
+	HoldCaptured = "captured"
+	// HoldReleased is the status of the hold released by a banker without moving any money
+	HoldReleased = "released"
+	// HoldExpired is the status of the hold which was neither captured nor released before its expiration
+	HoldExpired = "expired"
+)
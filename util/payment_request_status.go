@@ -0,0 +1,18 @@
+package util
+
+// Constants with supported payment request statuses, open request can become paid, pending, declined, cancelled
+// or expired, pending request becomes paid or open again when its transfer is decided
+const (
+	// PaymentRequestOpen is the status of the request waiting for the payer
+	PaymentRequestOpen = "open"
+	// PaymentRequestPaid is the status of the request accepted by the payer and paid by a transfer
+	PaymentRequestPaid = "paid"
+	// PaymentRequestPending is the status of the request accepted by the payer whose transfer waits for the approval
+	PaymentRequestPending = "pending"
+	// PaymentRequestDeclined is the status of the request declined by the payer
+	PaymentRequestDeclined = "declined"
+	// PaymentRequestCancelled is the status of the request withdrawn by the requester
+	PaymentRequestCancelled = "cancelled"
+	// PaymentRequestExpired is the status of the request which was not decided before its expiration
+	PaymentRequestExpired = "expired"
+)
@@ -0,0 +1,18 @@
+package util
+
+// Constants with supported user roles
+const (
+	// DepositorRole is the default role, depositor can work only with his/her own accounts
+	DepositorRole = "depositor"
+	// BankerRole is the role of the bank staff
+	BankerRole = "banker"
+)
+
+// It returns true if the role inside the input is supported
+func IsSupportedRole(role string) bool {
+	switch role {
+	case DepositorRole, BankerRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package util
+
+import "time"
+
+// Constants with supported recurrences of the scheduled transfers
+const (
+	// RecurrenceOnce executes the scheduled transfer only at its start
+	RecurrenceOnce = "once"
+	// RecurrenceDaily executes the scheduled transfer every day at the time of its start
+	RecurrenceDaily = "daily"
+	// RecurrenceWeekly executes the scheduled transfer every week on the weekday of its start
+	RecurrenceWeekly = "weekly"
+	// RecurrenceMonthly executes the scheduled transfer every month on the day of its start,
+	// in shorter months on their last day
+	RecurrenceMonthly = "monthly"
+)
+
+// Constants with statuses of the scheduled transfers
+const (
+	// ScheduledTransferActive is waiting for its next occurrence
+	ScheduledTransferActive = "active"
+	// ScheduledTransferCompleted has no more occurrences
+	ScheduledTransferCompleted = "completed"
+	// ScheduledTransferFailed has no more occurrences and the last one failed
+	ScheduledTransferFailed = "failed"
+	// ScheduledTransferCancelled was cancelled by its owner
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// Constants with statuses of the executions of the scheduled transfers
+const (
+	// ExecutionSucceeded created the transfer
+	ExecutionSucceeded = "succeeded"
+	// ExecutionRetrying failed and the occurrence will be retried
+	ExecutionRetrying = "retrying"
+	// ExecutionFailed failed and the occurrence is skipped
+	ExecutionFailed = "failed"
+)
+
+// It returns true if the recurrence inside the input is supported
+func IsSupportedRecurrence(recurrence string) bool {
+	switch recurrence {
+	case RecurrenceOnce, RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+		return true
+	}
+	return false
+}
+
+// Occurrence returns the n-th occurrence (counted from zero) of the transfer scheduled from start,
+// it returns false if the recurrence doesn't have the n-th occurrence. The occurrences are computed
+// from start and not from the previous occurrence, so the monthly transfer from the 31st
+// goes back to the 31st after February.
+func Occurrence(start time.Time, recurrence string, n int32) (time.Time, bool) {
+	start = start.UTC()
+
+	switch recurrence {
+	case RecurrenceOnce:
+		return start, n == 0
+	case RecurrenceDaily:
+		return start.AddDate(0, 0, int(n)), true
+	case RecurrenceWeekly:
+		return start.AddDate(0, 0, 7*int(n)), true
+	case RecurrenceMonthly:
+		year, month, day := start.Date()
+		// AddDate by přetekl do dalšího měsíce, např. 31. ledna + 1 měsíc je 3. března
+		firstDay := time.Date(year, month+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+		lastDay := firstDay.AddDate(0, 1, -1).Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return firstDay.AddDate(0, 0, day-1), true
+	}
+
+	return time.Time{}, false
+}
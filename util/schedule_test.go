@@ -0,0 +1,94 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 8, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		recurrence string
+		n          int32
+		expected   time.Time
+		ok         bool
+	}{
+		{
+			name:       "Once",
+			recurrence: RecurrenceOnce,
+			n:          0,
+			expected:   start,
+			ok:         true,
+		},
+		{
+			name:       "OnceSecondOccurrence",
+			recurrence: RecurrenceOnce,
+			n:          1,
+			ok:         false,
+		},
+		{
+			name:       "Daily",
+			recurrence: RecurrenceDaily,
+			n:          2,
+			expected:   time.Date(2024, time.February, 2, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "Weekly",
+			recurrence: RecurrenceWeekly,
+			n:          1,
+			expected:   time.Date(2024, time.February, 7, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "MonthlyLeapFebruary",
+			recurrence: RecurrenceMonthly,
+			n:          1,
+			expected:   time.Date(2024, time.February, 29, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "MonthlyBackToLastDay",
+			recurrence: RecurrenceMonthly,
+			n:          2,
+			expected:   time.Date(2024, time.March, 31, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "MonthlyShortMonth",
+			recurrence: RecurrenceMonthly,
+			n:          3,
+			expected:   time.Date(2024, time.April, 30, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "MonthlyNextYear",
+			recurrence: RecurrenceMonthly,
+			n:          13,
+			expected:   time.Date(2025, time.February, 28, 8, 30, 0, 0, time.UTC),
+			ok:         true,
+		},
+		{
+			name:       "Unsupported",
+			recurrence: "yearly",
+			n:          0,
+			ok:         false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			occurrence, ok := Occurrence(start, tc.recurrence, tc.n)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, tc.expected, occurrence)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package util
+
+// SystemUser owns the cash accounts of the bank, one account of the cash type per supported currency.
+// Deposits are transfers from the cash account and withdrawals are transfers to it,
+// so the sum of all entries of each currency stays zero.
+const SystemUser = "system"
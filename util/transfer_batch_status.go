@@ -0,0 +1,28 @@
+package util
+
+// Constants with supported transfer batch modes
+const (
+	// BatchAllOrNothing executes the batch only if every item can be executed
+	BatchAllOrNothing = "all_or_nothing"
+	// BatchBestEffort executes the items which can be executed and fails the others
+	BatchBestEffort = "best_effort"
+)
+
+// Constants with supported transfer batch statuses, the batch is executed synchronously so it never waits
+const (
+	// BatchCompleted is the status of the batch with all items executed
+	BatchCompleted = "completed"
+	// BatchPartiallyCompleted is the status of the best effort batch with some items failed
+	BatchPartiallyCompleted = "partially_completed"
+	// BatchFailed is the status of the batch without any executed item
+	BatchFailed = "failed"
+)
+
+// Constants with the statuses of the batch items which were not executed, executed items have
+// the status of their transfer, TransferPosted or TransferPending
+const (
+	// BatchItemFailed is the status of the item which can't be executed, e.g. for the insufficient funds
+	BatchItemFailed = "failed"
+	// BatchItemSkipped is the status of the valid item of the all or nothing batch with a failed item
+	BatchItemSkipped = "skipped"
+)
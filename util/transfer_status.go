@@ -0,0 +1,14 @@
+package util
+
+// Constants with supported transfer statuses, pending transfer can become posted, rejected or cancelled
+const (
+	// TransferPending is the status of the transfer waiting for the approval of a banker,
+	// its amount is reserved on the from account but no entries are posted yet
+	TransferPending = "pending"
+	// TransferPosted is the status of the transfer with posted entries
+	TransferPosted = "posted"
+	// TransferRejected is the status of the pending transfer rejected by a banker
+	TransferRejected = "rejected"
+	// TransferCancelled is the status of the pending transfer cancelled by the owner of the from account
+	TransferCancelled = "cancelled"
+)